@@ -3,177 +3,309 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"sort"
 	"strings"
-
-	"github.com/google/generative-ai-go/genai"
-	"github.com/tmc/langchaingo/llms"
-	"github.com/tmc/langchaingo/llms/openai"
-	"google.golang.org/api/iterator"
-	"google.golang.org/api/option"
+	"sync"
+	"time"
 )
 
-// GenerateCommand uses an LLM to convert a natural language prompt into a bash command.
-func GenerateCommand(ctx context.Context, prompt string, onToken func(string)) (string, error) {
-	cfg, _ := LoadConfig() // Ignore error, treat as empty config
+// aiProvider is one of the backends command generation can use.
+type aiProvider struct {
+	ID           string // As stored in the config
+	Label        string
+	DefaultModel string
+	KeyEnv       string // Environment variable holding the API key, empty if none is needed
+}
 
-	// Priority: Env Vars > Config File
+// aiProviders are offered in this order in the provider selection.
+var aiProviders = []aiProvider{
+	{ID: "google", Label: "Google Gemini", DefaultModel: "gemini-2.0-flash", KeyEnv: "GOOGLE_API_KEY"},
+	{ID: "openai", Label: "OpenAI", DefaultModel: "gpt-4o", KeyEnv: "OPENAI_API_KEY"},
+	{ID: "anthropic", Label: "Anthropic Claude", DefaultModel: "claude-3-5-haiku-latest", KeyEnv: "ANTHROPIC_API_KEY"},
+	{ID: "ollama", Label: "Ollama (local)", DefaultModel: "llama3.2"},
+}
 
-	googleKey := os.Getenv("GOOGLE_API_KEY")
-	if googleKey == "" && cfg != nil {
-		googleKey = cfg.GoogleAPIKey
+func findProvider(id string) (aiProvider, bool) {
+	for _, p := range aiProviders {
+		if p.ID == id {
+			return p, true
+		}
 	}
+	return aiProvider{}, false
+}
 
-	openaiKey := os.Getenv("OPENAI_API_KEY")
-	if openaiKey == "" && cfg != nil {
-		openaiKey = cfg.OpenAIAPIKey
-	}
+// defaultOllamaURL is where a local Ollama listens unless configured.
+const defaultOllamaURL = "http://localhost:11434"
 
-	// Check for Google API Key first
-	if googleKey != "" {
-		client, err := genai.NewClient(ctx, option.WithAPIKey(googleKey))
-		if err != nil {
-			return "", fmt.Errorf("failed to create GoogleAI client: %w", err)
+// ollamaURL returns the Ollama server to talk to: the config, then
+// $OLLAMA_HOST, then the local default.
+func ollamaURL(cfg *Config) string {
+	if cfg != nil && cfg.OllamaBaseURL != "" {
+		return strings.TrimSuffix(cfg.OllamaBaseURL, "/")
+	}
+	if host := os.Getenv("OLLAMA_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
 		}
-		defer client.Close()
+		return strings.TrimSuffix(host, "/")
+	}
+	return defaultOllamaURL
+}
 
-		modelName := "gemini-2.0-flash"
-		if cfg != nil && cfg.GoogleModel != "" {
-			modelName = cfg.GoogleModel
-		}
+// errNoProvider means no AI provider is set up yet. The UI answers it with
+// the provider selection.
+var errNoProvider = errors.New("no AI provider set up")
 
-		model := client.GenerativeModel(modelName)
-		var temp float32 = 0.0
-		model.Temperature = &temp
-		var maxTokens int32 = 256
-		model.MaxOutputTokens = &maxTokens
+// errNoAI is returned by every AI feature in a build without the providers.
+var errNoAI = errors.New("this build of just-do-it has no AI features (built with -tags noai)")
 
-		iter := model.GenerateContentStream(ctx, genai.Text(
-			"You are a helpful assistant that converts natural language requests into a single bash command.\n"+
-				"Output ONLY the command. Do not include markdown code blocks, explanations, or quotes.\n"+
-				"Request: "+prompt+"\n"+
-				"Command:",
-		))
+// errTruncated is returned along with the answer so far when the provider
+// stopped at the token limit.
+var errTruncated = errors.New("the answer was cut off at the token limit")
 
-		var fullResponse strings.Builder
-		for {
-			resp, err := iter.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return "", fmt.Errorf("stream error: %w", err)
-			}
+// Answers are capped at defaultMaxTokens, plenty for a command. Requests
+// for longer answers raise the cap on their context with withMaxTokens.
+const (
+	defaultMaxTokens  = 256
+	generateMaxTokens = 1024 // The command and its breakdown
+	routeMaxTokens    = 512
+	explainMaxTokens  = 2048
+	importMaxTokens   = 8192
+)
+
+type maxTokensKey struct{}
+
+// withMaxTokens has complete cap the answer at n tokens.
+func withMaxTokens(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxTokensKey{}, n)
+}
+
+// maxTokens is the cap for the answer to a request on ctx.
+func maxTokens(ctx context.Context) int {
+	if n, ok := ctx.Value(maxTokensKey{}).(int); ok {
+		return n
+	}
+	return defaultMaxTokens
+}
 
-			if len(resp.Candidates) > 0 {
-				for _, part := range resp.Candidates[0].Content.Parts {
-					if txt, ok := part.(genai.Text); ok {
-						chunk := string(txt)
-						fullResponse.WriteString(chunk)
-						logDebug("Received chunk: %q", chunk)
-						if onToken != nil {
-							onToken(chunk)
-						}
-					}
-				}
+// migrateKeysOnce moves plaintext API keys to the keychain on first use.
+var migrateKeysOnce sync.Once
+
+// EnsureProvider is where every AI feature gets its provider: the one chosen
+// in the AI settings, otherwise the first with an API key. It returns the
+// provider with its key, or an error wrapping errNoProvider when the user
+// has to pick one first. It never goes to the network, and nothing AI
+// related happens before it is first called.
+func EnsureProvider(cfg *Config) (aiProvider, string, error) {
+	if !aiEnabled {
+		return aiProvider{}, "", errNoAI
+	}
+	migrateKeysOnce.Do(func() {
+		if cfg.migrateAPIKeys() {
+			if err := SaveConfig(cfg); err != nil {
+				logDebug("Saving config after moving API keys to the keychain: %v", err)
 			}
 		}
-		return fullResponse.String(), nil
+	})
 
-	} else if openaiKey != "" {
-		model := "gpt-4o"
-		if cfg != nil && cfg.OpenAIModel != "" {
-			model = cfg.OpenAIModel
+	if cfg.Provider != "" {
+		p, ok := findProvider(cfg.Provider)
+		if !ok {
+			return aiProvider{}, "", fmt.Errorf("unknown AI provider %q in config", cfg.Provider)
 		}
-		llm, err := openai.New(openai.WithToken(openaiKey), openai.WithModel(model))
-		if err != nil {
-			return "", fmt.Errorf("failed to create OpenAI client: %w", err)
-		}
-
-		content := []llms.MessageContent{
-			llms.TextParts(llms.ChatMessageTypeHuman,
-				`You are a helpful assistant that converts natural language requests into a single bash command. 
-Output ONLY the command. Do not include markdown code blocks, explanations, or quotes.
-Request: `+prompt+`
-Command:`),
-		}
-
-		completion, err := llm.GenerateContent(ctx, content,
-			llms.WithTemperature(0.0),
-			llms.WithMaxTokens(256),
-			llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
-				logDebug("Received chunk: %q", string(chunk))
-				if onToken != nil && len(chunk) > 0 {
-					onToken(string(chunk))
-				}
-				return nil
-			}),
-		)
-		if err != nil {
-			return "", fmt.Errorf("AI generation failed: %w", err)
+		key := cfg.apiKey(p)
+		if p.KeyEnv != "" && key == "" {
+			return aiProvider{}, "", fmt.Errorf("%w: %s has no API key", errNoProvider, p.Label)
+		}
+		return p, key, nil
+	}
+	for _, p := range aiProviders {
+		if key := cfg.apiKey(p); p.KeyEnv != "" && key != "" {
+			return p, key, nil
 		}
+	}
+	return aiProvider{}, "", errNoProvider
+}
+
+// The prompts start with the instructions from promptInstructions, the
+// output format after them is what parseGeneration reads.
+const generatePrompt = `%[1]s
+Output the command on the first line, without markdown code blocks or quotes.
+Then output a line containing only ---, followed by one line per part of the command (each flag, argument or pipe segment) in the form: part: what it does. Keep each explanation short.
+Request: %[2]s
+Command:`
+
+const refinePrompt = `%[1]s
+The conversation so far, the last command being the current one:
+%[2]s
+Revise the current command as the follow-up asks, keeping everything it doesn't ask to change.
+Output the command on the first line, without markdown code blocks or quotes.
+Then output a line containing only ---, followed by one line per part of the command (each flag, argument or pipe segment) in the form: part: what it does. Keep each explanation short.
+Follow-up: %[3]s
+Command:`
+
+// aiTurn is one request in a refinement conversation and the command it
+// ended with, as edited by the user.
+type aiTurn struct {
+	Request string
+	Command string
+}
+
+// RefineCommand revises the command from the earlier turns by the last
+// turn's request, so follow-ups like "make it recursive" build on it.
+func RefineCommand(ctx context.Context, turns []aiTurn, project projectContext, onToken func(string)) (string, error) {
+	var history strings.Builder
+	for _, t := range turns[:len(turns)-1] {
+		fmt.Fprintf(&history, "Request: %s\nCommand: %s\n", t.Request, t.Command)
+	}
+	followUp := turns[len(turns)-1].Request
+	return complete(ctx, fmt.Sprintf(refinePrompt, promptInstructions(project), history.String(), followUp), onToken)
+}
+
+// GenerateCommand uses an LLM to convert a natural language prompt into a
+// command for the project's shell, telling it about the project and its
+// recipes.
+func GenerateCommand(ctx context.Context, prompt string, project projectContext, onToken func(string)) (string, error) {
+	return complete(ctx, fmt.Sprintf(generatePrompt, promptInstructions(project), prompt), onToken)
+}
+
+// complete sends a prompt to the configured provider, or its fallbacks when
+// it fails, and returns the whole response, streaming it to onToken as it
+// arrives.
+func complete(ctx context.Context, prompt string, onToken func(string)) (string, error) {
+	cfg, _ := LoadConfig() // Ignore error, treat as empty config
+	if cfg == nil {
+		cfg = &Config{}
+	}
 
-		if len(completion.Choices) == 0 {
-			return "", fmt.Errorf("no response from AI")
+	provider, key, err := EnsureProvider(cfg)
+	if err != nil {
+		return "", err
+	}
+	if len(cfg.RaceProviders) > 1 {
+		return race(ctx, cfg, cfg.RaceProviders, prompt, onToken)
+	}
+	return completeWithFallback(ctx, cfg, provider, key, prompt, onToken)
+}
+
+// completeWith sends a prompt to one provider, logging the exchange when
+// ai_debug is set.
+func completeWith(ctx context.Context, cfg *Config, provider aiProvider, key, prompt string, onToken func(string)) (string, error) {
+	start := time.Now()
+	response, err := requestCompletion(ctx, cfg, provider, key, prompt, onToken)
+	if cfg.AIDebug {
+		logAIExchange(cfg, aiLogEntry{
+			At:       start,
+			Provider: provider.ID,
+			Model:    cfg.model(provider),
+			Prompt:   prompt,
+			Response: response,
+			Error:    errString(err),
+			Duration: time.Since(start).Round(time.Millisecond).String(),
+		})
+	}
+	return response, err
+}
+
+// explanationSep separates the command from its breakdown in the response.
+const explanationSep = "---"
+
+// parseGeneration splits a response into the command and the explanation
+// lines that follow it. Models that ignore the format still give a usable
+// command.
+func parseGeneration(response string) (command string, explanation []string) {
+	response = strings.TrimSpace(response)
+	head, tail, _ := strings.Cut(response, "\n"+explanationSep)
+	command = extractCommand(head)
+
+	for _, line := range strings.Split(tail, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+		if line != "" && line != explanationSep {
+			explanation = append(explanation, line)
 		}
+	}
+	return command, explanation
+}
 
-		return completion.Choices[0].Content, nil
-	} else {
-		// Return specific error type/string to trigger UI flow
-		return "", fmt.Errorf("MISSING_API_KEY")
+// commandComplete reports whether a response cut off at the token limit
+// still has its whole command, the breakdown after it having started.
+func commandComplete(response string) bool {
+	response = strings.TrimSpace(response)
+	if _, _, found := strings.Cut(response, "\n"+explanationSep); found {
+		return true
+	}
+	if strings.HasPrefix(response, "```") {
+		return strings.Count(response, "```") >= 2
 	}
+	line, _, found := strings.Cut(response, "\n")
+	return found && !strings.HasSuffix(strings.TrimSpace(line), "\\")
 }
 
 // ListModels returns a list of available model names for the given provider and key.
 func ListModels(provider, key string) ([]string, error) {
+	cfg, _ := LoadConfig()
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
 	if provider == "google" {
-		ctx := context.Background()
-		client, err := genai.NewClient(ctx, option.WithAPIKey(key))
+		return listGoogleModels(key)
+	} else if provider == "openai" {
+		req, err := http.NewRequest("GET", openAIURL(cfg)+"/models", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+key)
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
 		if err != nil {
 			return nil, err
 		}
-		defer client.Close()
+		defer resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			return nil, fmt.Errorf("OpenAI API returned status: %s", resp.Status)
+		}
+
+		var result struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, err
+		}
 
 		var models []string
-		iter := client.ListModels(ctx)
-		for {
-			m, err := iter.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return nil, err
-			}
-			// Only include generation models
-			if strings.Contains(m.Name, "gemini") {
-				// Name comes as "models/gemini-pro", strip prefix if needed or keep it
-				// langchaingo usually expects just "gemini-pro" but "models/" might be needed for pure API
-				// Let's strip "models/" for display
-				name := strings.TrimPrefix(m.Name, "models/")
-				models = append(models, name)
+		for _, m := range result.Data {
+			// OpenAI-compatible servers name their models however they like
+			if strings.HasPrefix(m.ID, "gpt") || cfg.OpenAIBaseURL != "" {
+				models = append(models, m.ID)
 			}
 		}
+		sort.Strings(models)
 		return models, nil
-	} else if provider == "openai" {
-		// Simple HTTP request for OpenAI
-		req, err := http.NewRequest("GET", "https://api.openai.com/v1/models", nil)
+	} else if provider == "anthropic" {
+		req, err := http.NewRequest("GET", "https://api.anthropic.com/v1/models?limit=100", nil)
 		if err != nil {
 			return nil, err
 		}
-		req.Header.Set("Authorization", "Bearer "+key)
+		req.Header.Set("x-api-key", key)
+		req.Header.Set("anthropic-version", "2023-06-01")
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
 			return nil, err
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
-			return nil, fmt.Errorf("OpenAI API returned status: %s", resp.Status)
+			return nil, fmt.Errorf("Anthropic API returned status: %s", resp.Status)
 		}
 
 		var result struct {
@@ -185,11 +317,35 @@ func ListModels(provider, key string) ([]string, error) {
 			return nil, err
 		}
 
+		// Newest first, as the API returns them
 		var models []string
 		for _, m := range result.Data {
-			if strings.HasPrefix(m.ID, "gpt") {
-				models = append(models, m.ID)
-			}
+			models = append(models, m.ID)
+		}
+		return models, nil
+	} else if provider == "ollama" {
+		resp, err := http.Get(ollamaURL(cfg) + "/api/tags")
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != 200 {
+			return nil, fmt.Errorf("Ollama returned status: %s", resp.Status)
+		}
+
+		var result struct {
+			Models []struct {
+				Name string `json:"name"`
+			} `json:"models"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, err
+		}
+
+		var models []string
+		for _, m := range result.Models {
+			models = append(models, m.Name)
 		}
 		sort.Strings(models)
 		return models, nil
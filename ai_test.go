@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGeneration(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		command     string
+		explanation []string
+	}{
+		{"plain", "ls -la", "ls -la", nil},
+		{
+			"explained",
+			"find . -name '*.go'\n---\n- find: searches files\n- -name: matches the name",
+			"find . -name '*.go'",
+			[]string{"find: searches files", "-name: matches the name"},
+		},
+		{"fenced", "```bash\ngit status\n```\n---\n- status", "git status", []string{"status"}},
+		{"prompt", "$ du -sh *", "du -sh *", nil},
+		{"backticks", "`make test`", "make test", nil},
+		{"continued", "docker run \\\n  --rm alpine", "docker run --rm alpine", nil},
+		{"padded", "\n\n  echo hi  \n\n---\n\n- echo\n", "echo hi", []string{"echo"}},
+	}
+	for _, tt := range tests {
+		command, explanation := parseGeneration(tt.response)
+		if command != tt.command || !reflect.DeepEqual(explanation, tt.explanation) {
+			t.Errorf("%s: parseGeneration() = %q, %q, want %q, %q", tt.name, command, explanation, tt.command, tt.explanation)
+		}
+	}
+}
+
+func TestCommandComplete(t *testing.T) {
+	tests := []struct {
+		response string
+		want     bool
+	}{
+		{"ls -la", false},
+		{"ls -la\n", false},
+		{"ls -la\n---\n- ls: li", true},
+		{"ls -la\nsomething", true},
+		{"docker run \\\n  --rm", false},
+		{"```bash\nls", false},
+		{"```bash\nls\n```", true},
+	}
+	for _, tt := range tests {
+		if got := commandComplete(tt.response); got != tt.want {
+			t.Errorf("commandComplete(%q) = %v, want %v", tt.response, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// `just-do-it ai "request"` generates a command without the TUI, for
+// scripts and shell aliases. The command is streamed to stdout as it comes
+// in, on a line of its own; --run runs it, with flagged commands confirmed
+// on the terminal as in the TUI.
+
+func runAI(args []string) int {
+	fs := flag.NewFlagSet("just-do-it ai", flag.ContinueOnError)
+	run := fs.Bool("run", false, "run the command after generating it")
+	yes := fs.Bool("yes", false, "with --run, don't ask before running commands that look dangerous")
+	explain := fs.Bool("explain", false, "also print what each part of the command does, to stderr")
+	shell := fs.String("shell", "", "write the command for this shell instead of ai_shell or $SHELL")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	request := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if request == "" {
+		fmt.Fprintln(os.Stderr, `Usage: just-do-it ai [--run] [--yes] [--explain] [--shell name] "request"`)
+		return 2
+	}
+
+	cfg, _ := LoadConfig()
+	if *shell == "" {
+		*shell = detectShell(cfg)
+	} else if _, ok := findShell(*shell); !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown shell %q\n", *shell)
+		return 2
+	}
+	if err := checkProvider(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v (pick one with ctrl+p in the TUI)\n", err)
+		return 1
+	}
+
+	// Outside a project there are no recipes to suggest, that's fine
+	recipes := map[string]Recipe{}
+	if dump, err := getJustDump(); err == nil {
+		recipes = dump.Recipes
+	}
+	project := gatherProjectContext(recipes, *shell)
+	project.Examples = similarExamples(request, *shell)
+
+	stream := &commandStream{out: os.Stdout}
+	response, err := GenerateCommand(withMaxTokens(context.Background(), generateMaxTokens), request, project, stream.write)
+	if errors.Is(err, errTruncated) && commandComplete(response) {
+		err = nil // Only the breakdown was cut off
+	}
+	if err != nil {
+		stream.end()
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	command, explanation := parseGeneration(response)
+	stream.finish(command)
+	if command == "" {
+		fmt.Fprintln(os.Stderr, "Error: the answer had no command")
+		return 1
+	}
+	if *explain {
+		for _, line := range explanation {
+			fmt.Fprintln(os.Stderr, line)
+		}
+	}
+	if !*run {
+		return 0
+	}
+
+	if reasons := commandDangers(cfg, command); len(reasons) > 0 && !*yes {
+		if err := confirmDanger(reasons, os.Stdin, os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	if err := rememberExample(aiExample{Request: request, Command: command, Shell: *shell, At: time.Now()}); err != nil {
+		logDebug("Saving example failed: %v", err)
+	}
+	execCommand(shellCommand(*shell, command))
+	return 0
+}
+
+// commandStream writes the first line of the answer, the command, as it's
+// streamed. An answer that doesn't start with the bare command, such as one
+// in a code block, is held back and the command printed once parsed.
+type commandStream struct {
+	out     io.Writer
+	started bool // Some of the command was written
+	held    bool // Waiting for the whole answer
+	done    bool // The command's line is complete
+	pending strings.Builder
+}
+
+func (s *commandStream) write(token string) {
+	if s.done || s.held {
+		return
+	}
+	if !s.started {
+		s.pending.WriteString(token)
+		text := strings.TrimLeft(s.pending.String(), " \t\n")
+		if text == "" {
+			return
+		}
+		if strings.HasPrefix(text, "`") || strings.HasPrefix(text, `"`) {
+			s.held = true
+			return
+		}
+		token = text
+		s.started = true
+	}
+	if line, _, found := strings.Cut(token, "\n"); found {
+		fmt.Fprintln(s.out, strings.TrimRight(line, " \t\r"))
+		s.done = true
+		return
+	}
+	fmt.Fprint(s.out, token)
+}
+
+// end finishes a line left open by an error.
+func (s *commandStream) end() {
+	if s.started && !s.done {
+		fmt.Fprintln(s.out)
+		s.done = true
+	}
+}
+
+// finish prints the parsed command if it wasn't streamed.
+func (s *commandStream) finish(command string) {
+	if !s.started {
+		if command != "" {
+			fmt.Fprintln(s.out, command)
+		}
+		return
+	}
+	s.end()
+}
+
+// confirmDanger has a flagged command confirmed by typing the confirm word
+// on the terminal, refusing when there is none.
+func confirmDanger(reasons []string, in *os.File, out io.Writer) error {
+	reason := strings.Join(reasons, " and ")
+	if info, err := in.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("not running the command, it %s (pass --yes to run it anyway)", reason)
+	}
+	fmt.Fprintf(out, "The command %s.\nType %s to run it anyway: ", reason, dangerConfirmWord)
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	if strings.TrimSpace(line) != dangerConfirmWord {
+		return fmt.Errorf("not running the command")
+	}
+	return nil
+}
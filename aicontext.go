@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// projectContext is what the AI is told about the project, so it can
+// suggest an existing recipe instead of reinventing it in shell.
+type projectContext struct {
+	Shell    string
+	Dir      string
+	Language string
+	Branch   string
+	Recipes  []string    // One line per recipe: signature and doc
+	Examples []aiExample // Accepted commands for similar requests
+}
+
+// maxContextRecipes caps how many recipes go into the prompt.
+const maxContextRecipes = 100
+
+// languageMarkers map files in the project root to its language.
+var languageMarkers = []struct{ file, language string }{
+	{"go.mod", "Go"},
+	{"Cargo.toml", "Rust"},
+	{"package.json", "JavaScript/TypeScript"},
+	{"pyproject.toml", "Python"},
+	{"requirements.txt", "Python"},
+	{"Gemfile", "Ruby"},
+	{"pom.xml", "Java"},
+	{"build.gradle", "Java/Kotlin"},
+	{"build.gradle.kts", "Kotlin"},
+	{"mix.exs", "Elixir"},
+	{"composer.json", "PHP"},
+	{"CMakeLists.txt", "C/C++"},
+	{"flake.nix", "Nix"},
+}
+
+func gatherProjectContext(recipes map[string]Recipe, shell string) projectContext {
+	dir := projectDir()
+	ctx := projectContext{Shell: shell, Dir: dir}
+
+	var languages []string
+	for _, m := range languageMarkers {
+		if _, err := os.Stat(filepath.Join(dir, m.file)); err == nil && !containsString(languages, m.language) {
+			languages = append(languages, m.language)
+		}
+	}
+	ctx.Language = strings.Join(languages, ", ")
+
+	if out, err := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD").Output(); err == nil {
+		ctx.Branch = strings.TrimSpace(string(out))
+	}
+
+	var names []string
+	for name := range recipes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if len(ctx.Recipes) == maxContextRecipes {
+			break
+		}
+		line := "just " + recipeSignature(recipes[name])
+		if doc := recipes[name].Doc; doc != nil && *doc != "" {
+			line += "  # " + *doc
+		}
+		ctx.Recipes = append(ctx.Recipes, line)
+	}
+	return ctx
+}
+
+// recipeSignature renders how a recipe is called, e.g. `deploy env target="web"`.
+func recipeSignature(r Recipe) string {
+	parts := []string{strings.ReplaceAll(r.Name, moduleSep, " ")}
+	for _, p := range r.Parameters {
+		param := p.Name
+		switch p.Kind {
+		case "plus":
+			param = "+" + param
+		case "star":
+			param = "*" + param
+		}
+		if p.Default != nil && p.DefaultExpr {
+			param += "=(" + *p.Default + ")"
+		} else if p.Default != nil {
+			param += fmt.Sprintf("=%q", *p.Default)
+		}
+		parts = append(parts, param)
+	}
+	return strings.Join(parts, " ")
+}
+
+// String renders the context as a prompt section.
+func (c projectContext) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Working directory: %s\n", c.Dir)
+	if c.Language != "" {
+		fmt.Fprintf(&b, "Project language: %s\n", c.Language)
+	}
+	if c.Branch != "" {
+		fmt.Fprintf(&b, "Git branch: %s\n", c.Branch)
+	}
+	if len(c.Recipes) > 0 {
+		b.WriteString("Recipes in the justfile (prefer running one of these with `just` when it fits the request):\n")
+		for _, r := range c.Recipes {
+			b.WriteString("  " + r + "\n")
+		}
+	}
+	if len(c.Examples) > 0 {
+		b.WriteString("Commands the user accepted for similar requests in this project (follow their tools and conventions):\n")
+		for _, ex := range c.Examples {
+			fmt.Fprintf(&b, "  Request: %s\n  Command: %s\n", ex.Request, ex.Command)
+		}
+	}
+	return b.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Where the AI item is listed, see Config.AIItem.
+const (
+	aiItemBottom = "bottom"
+	aiItemTop    = "top"
+	aiItemHidden = "hidden"
+)
+
+// How the AI item gets its request, see Config.AIItemPrompt.
+const (
+	aiPromptFilter = "filter" // The filter text, asking only when there is none
+	aiPromptEdit   = "edit"   // Asked for, starting from the filter text
+	aiPromptEmpty  = "empty"  // Asked for from scratch
+)
+
+// newAIItem sets up the AI item as configured.
+func newAIItem(cfg *Config, prompt, shell *string) aiItem {
+	item := aiItem{prompt: prompt, shell: shell, mode: aiPromptFilter}
+	if cfg != nil {
+		item.label = cfg.AIItemLabel
+		if cfg.AIItemPrompt != "" {
+			item.mode = cfg.AIItemPrompt
+		}
+	}
+	return item
+}
+
+// aiItemPlacement is where the config puts the AI item, the bottom by
+// default. Builds without AI don't list it.
+func aiItemPlacement(cfg *Config) string {
+	if !aiEnabled {
+		return aiItemHidden
+	}
+	if cfg != nil && (cfg.AIItem == aiItemTop || cfg.AIItem == aiItemHidden) {
+		return cfg.AIItem
+	}
+	return aiItemBottom
+}
+
+// withAIItem places the AI item among the listed items.
+func (m model) withAIItem(items []list.Item) []list.Item {
+	switch m.aiPlacement {
+	case aiItemHidden:
+		return items
+	case aiItemTop:
+		return append([]list.Item{m.aiItem}, items...)
+	}
+	return append(items, m.aiItem)
+}
+
+// previewText explains the AI item in the preview pane.
+func (a aiItem) previewText() string {
+	if a.mode == aiPromptFilter {
+		return "Select to generate a command using AI based on your search text."
+	}
+	return "Select to describe a command for AI to generate."
+}
+
+// aiItemIndex is the AI item's index among n list items, -1 when hidden.
+func aiItemIndex(placement string, n int) int {
+	switch placement {
+	case aiItemHidden:
+		return -1
+	case aiItemTop:
+		return 0
+	}
+	return n - 1
+}
+
+// requestAI starts generating a command for the AI item, first asking for
+// the request when the item doesn't take it from the filter.
+func (m *model) requestAI(filter string) tea.Cmd {
+	switch {
+	case m.aiItem.mode == aiPromptEmpty:
+		return m.startAIPrompt("")
+	case m.aiItem.mode == aiPromptEdit, strings.TrimSpace(filter) == "":
+		return m.startAIPrompt(filter)
+	}
+	return m.generateFor(filter)
+}
+
+// generateFor checks the provider, then routes the request to a recipe or
+// generates a command for it.
+func (m *model) generateFor(request string) tea.Cmd {
+	if err := checkProvider(); errors.Is(err, errNoProvider) {
+		m.startProviderSetup()
+		return nil
+	} else if err != nil {
+		m.err = err
+		return nil
+	}
+	if route := routeMode(); route != "" {
+		m.state = viewGenerating
+		m.streamContent = ""
+		return tea.Batch(m.spinTick(), routeRecipe(route, m.recipes, request))
+	}
+	return m.startGeneration(request)
+}
+
+// startAIPrompt asks what the AI command should do.
+func (m *model) startAIPrompt(initial string) tea.Cmd {
+	t := textinput.New()
+	t.Prompt = "Request: "
+	t.Placeholder = "e.g. find the largest files in this project"
+	t.Width = min(m.terminalWidth-10, 80)
+	t.SetValue(initial)
+	t.Focus()
+	m.aiPromptInput = t
+	m.state = viewAIPrompt
+	return textinput.Blink
+}
+
+func (m model) updateAIPrompt(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewList
+		return m, nil
+	case key.Matches(msg, m.keys.Select):
+		request := strings.TrimSpace(m.aiPromptInput.Value())
+		if request == "" {
+			return m, nil
+		}
+		m.state = viewList
+		return m, m.generateFor(request)
+	}
+
+	var cmd tea.Cmd
+	m.aiPromptInput, cmd = m.aiPromptInput.Update(msg)
+	return m, cmd
+}
+
+func (m model) aiPromptView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.aiItem.labelText()))
+	b.WriteString("\n\n")
+	b.WriteString(helpStyle.Render("Describe what the " + *m.aiShell + " command should do."))
+	b.WriteString("\n\n")
+	b.WriteString(m.aiPromptInput.View())
+	return lipgloss.Place(m.terminalWidth, m.terminalHeight-1, lipgloss.Center, lipgloss.Center, b.String())
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// aiLogShown is how many of the latest exchanges the viewer shows.
+const aiLogShown = 20
+
+// aiLogEntry is one request to a provider and what came back.
+type aiLogEntry struct {
+	At       time.Time `json:"at"`
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	Prompt   string    `json:"prompt"`
+	Response string    `json:"response"`
+	Error    string    `json:"error,omitempty"`
+	Duration string    `json:"duration"`
+}
+
+// keyPatterns match API keys by their usual shape, in case one ends up in a
+// prompt or error message.
+var keyPatterns = regexp.MustCompile(`sk-[A-Za-z0-9_-]{16,}|AIza[0-9A-Za-z_-]{30,}`)
+
+func aiLogPath() (string, error) {
+	return xdg.StateFile("just-do-it/ai-requests.jsonl")
+}
+
+// redactKeys replaces the configured keys and anything shaped like one.
+func redactKeys(cfg *Config, s string) string {
+	for _, p := range aiProviders {
+		if key := cfg.apiKey(p); key != "" {
+			s = strings.ReplaceAll(s, key, "[redacted]")
+		}
+	}
+	return keyPatterns.ReplaceAllString(s, "[redacted]")
+}
+
+func logAIExchange(cfg *Config, e aiLogEntry) {
+	e.Prompt = redactKeys(cfg, e.Prompt)
+	e.Response = redactKeys(cfg, e.Response)
+	e.Error = redactKeys(cfg, e.Error)
+
+	path, err := aiLogPath()
+	if err != nil {
+		logDebug("AI log: %v", err)
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		logDebug("AI log: %v", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// readAILog returns the latest n exchanges, newest first.
+func readAILog(n int) ([]aiLogEntry, error) {
+	path, err := aiLogPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []aiLogEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e aiLogEntry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, scanner.Err()
+}
+
+// startAILog opens the viewer on the latest exchanges.
+func (m *model) startAILog() {
+	var b strings.Builder
+	cfg, _ := LoadConfig()
+	entries, err := readAILog(aiLogShown)
+	switch {
+	case err != nil && os.IsNotExist(err) || err == nil && len(entries) == 0:
+		b.WriteString("Nothing logged yet.\n")
+		if cfg == nil || !cfg.AIDebug {
+			b.WriteString("Set \"ai_debug\": true in the config to log AI requests and responses.\n")
+		}
+	case err != nil:
+		fmt.Fprintf(&b, "Reading the log failed: %v\n", err)
+	}
+
+	heading := lipgloss.NewStyle().Foreground(activeTheme.Accent).Bold(true)
+	for _, e := range entries {
+		b.WriteString(heading.Render(fmt.Sprintf("%s · %s %s · %s", e.At.Format("2006-01-02 15:04:05"), e.Provider, e.Model, e.Duration)))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("Request:"))
+		b.WriteString("\n" + strings.TrimSpace(e.Prompt) + "\n")
+		b.WriteString(helpStyle.Render("Response:"))
+		b.WriteString("\n" + strings.TrimSpace(e.Response) + "\n")
+		if e.Error != "" {
+			b.WriteString(helpStyle.Render("Error: ") + e.Error + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if path, err := aiLogPath(); err == nil {
+		b.WriteString(helpStyle.Render("Full log: " + path))
+	}
+
+	m.aiLog = viewport.New(m.terminalWidth-4, m.terminalHeight-5)
+	m.aiLog.SetContent(lipgloss.NewStyle().Width(m.terminalWidth - 4).Render(b.String()))
+	m.state = viewAILog
+}
+
+func (m model) updateAILog(msg tea.KeyMsg) (model, tea.Cmd) {
+	if key.Matches(msg, m.keys.Cancel, m.keys.Quit) {
+		m.state = viewList
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.aiLog, cmd = m.aiLog.Update(msg)
+	return m, cmd
+}
+
+func (m model) aiLogView() string {
+	return lipgloss.NewStyle().Margin(1, 2).Render(
+		lipgloss.JoinVertical(lipgloss.Left, titleStyle.Render("AI Requests"), "", m.aiLog.View()),
+	)
+}
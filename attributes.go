@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Recipes marked [private], or named with a leading underscore, are helpers
+// that `just --list` leaves out, and so does the list until ctrl+x h shows
+// them. Recipes marked [confirm] are confirmed in the TUI before they run,
+// and just is passed --yes so it doesn't ask again where it can't.
+
+// private reports whether just hides the recipe from its listing.
+func (r Recipe) private() bool {
+	if r.Private {
+		return true
+	}
+	for _, a := range r.Attributes {
+		if a.Name == "private" {
+			return true
+		}
+	}
+	name := r.Name
+	if i := strings.LastIndex(name, moduleSep); i >= 0 {
+		name = name[i+len(moduleSep):]
+	}
+	return strings.HasPrefix(name, "_")
+}
+
+// confirmPrompt is what [confirm] asks before the recipe runs, just's
+// default question when the attribute has no text.
+func (r Recipe) confirmPrompt() (string, bool) {
+	for _, a := range r.Attributes {
+		if a.Name == "confirm" {
+			if a.Value != "" {
+				return a.Value, true
+			}
+			return fmt.Sprintf("Run recipe `%s`?", r.Name), true
+		}
+	}
+	return "", false
+}
+
+// confirmPrompts collects the [confirm] questions of the recipe and the
+// dependencies it runs first, in the order just would ask them.
+func confirmPrompts(recipes map[string]Recipe, name string) []string {
+	var prompts []string
+	seen := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		r, ok := recipes[name]
+		if !ok || seen[name] {
+			return
+		}
+		seen[name] = true
+		module := ""
+		if i := strings.LastIndex(name, moduleSep); i >= 0 {
+			module = name[:i+len(moduleSep)]
+		}
+		for _, d := range r.Dependencies {
+			if _, ok := recipes[module+d.Recipe]; ok {
+				visit(module + d.Recipe)
+			} else {
+				visit(d.Recipe)
+			}
+		}
+		if p, ok := r.confirmPrompt(); ok {
+			prompts = append(prompts, p)
+		}
+	}
+	visit(name)
+	return prompts
+}
+
+// listed reports whether the recipe belongs in the list: in the group
+// filtered for, and not private unless those are shown.
+func (m model) listed(r Recipe) bool {
+	if m.groupFilter != "" && !r.inGroup(m.groupFilter) {
+		return false
+	}
+	return m.showPrivate || !r.private()
+}
+
+// toggleShowPrivate lists private recipes, or hides them again.
+func (m *model) toggleShowPrivate() tea.Cmd {
+	m.showPrivate = !m.showPrivate
+	m.list.Title = m.listTitle()
+	status := "Private recipes hidden"
+	if m.showPrivate {
+		status = "Showing private recipes"
+	}
+	return tea.Batch(m.list.SetItems(m.buildItems()), func() tea.Msg { return statusMsg(status) })
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Recipes marked in the list run as a batch in the output pane, reusing the
+// matrix run: one run per recipe instead of one per parameter value.
+
+// toggleMark marks or unmarks the recipe for the next batch. Recipes that
+// need arguments can't be batched, there is no form to fill them in.
+func (m *model) toggleMark(name string) tea.Cmd {
+	if i := slices.Index(*m.marked, name); i >= 0 {
+		*m.marked = slices.Delete(*m.marked, i, i+1)
+		return nil
+	}
+	for _, p := range m.recipes[name].Parameters {
+		if p.Default == nil && p.Kind != "star" {
+			return func() tea.Msg { return statusMsg(name + " needs arguments, it can't be part of a batch") }
+		}
+	}
+	*m.marked = append(*m.marked, name)
+	return nil
+}
+
+// batchConfirmedMsg runs the marked recipes once a git guard is confirmed.
+type batchConfirmedMsg struct{}
+
+// startBatch runs the marked recipes in the output pane, in the order they
+// were marked, or all at once when parallel. Unless confirmed, git guards
+// are checked first.
+func (m *model) startBatch(confirmed bool) tea.Cmd {
+	var names []string
+	var commands [][]string
+	for _, name := range *m.marked {
+		if _, ok := m.recipes[name]; ok { // Gone after a reload
+			names = append(names, name)
+			commands = append(commands, m.recipeCommand(name))
+		}
+	}
+	if !confirmed {
+		if guard := guardRecipes(m.recipes, names, func() tea.Msg { return batchConfirmedMsg{} }); guard != nil {
+			return guard
+		}
+	}
+	*m.marked = nil
+	if len(names) == 0 {
+		return nil
+	}
+	limit := 1
+	if m.batchParallel {
+		limit = len(names)
+	}
+	return m.runMatrix(&matrixRun{
+		batch:    true,
+		values:   names,
+		commands: commands,
+		jobs:     make([]*job, len(names)),
+		limit:    limit,
+	})
+}
+
+// markIndex is the recipe's position in the batch, 0 if it isn't marked.
+func markIndex(marked *[]string, name string) int {
+	if marked == nil {
+		return 0
+	}
+	return slices.Index(*marked, name) + 1
+}
+
+func batchHelp(parallel bool) string {
+	if parallel {
+		return "batch runs in parallel"
+	}
+	return "batch runs in order"
+}
+
+// batchSummary describes how the batch runs, above its per-recipe states.
+func (r *matrixRun) batchSummary() string {
+	if r.limit > 1 {
+		return fmt.Sprintf("%d recipes in parallel", len(r.values))
+	}
+	return fmt.Sprintf("%d recipes in order, stopping at the first failure", len(r.values))
+}
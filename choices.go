@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Parameters that only take a few values are picked from a list in the form
+// instead of typed. The values come from, in order of preference:
+//
+//	# env: dev|staging|prod           a comment naming the parameter
+//	# values: dev|staging|prod        the same, for recipes with one parameter
+//	[arg("env", pattern="dev|prod")]  an arg attribute with a plain alternation
+//	if env == "prod" { ... }          string literals compared to it in the body
+
+var (
+	valuesComment = regexp.MustCompile(`^#\s*([\w-]+)(?:\s+values)?\s*:\s*(\S+(?:\s*\|\s*\S+)+)\s*$`)
+	argAttribute  = regexp.MustCompile(`arg\(\s*["']([\w-]+)["']\s*,.*?pattern\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// paramChoices are the values to choose from in the form, per parameter of
+// recipe; recipe says which recipe they were found for.
+type paramChoices struct {
+	recipe string
+	values [][]string
+}
+
+// findParamChoices looks for the values each parameter of the recipe takes,
+// nil for parameters that take anything.
+func findParamChoices(recipe Recipe) [][]string {
+	header, body := recipeLines(recipe.Name)
+	choices := make([][]string, len(recipe.Parameters))
+	found := false
+	for i, p := range recipe.Parameters {
+		values := declaredChoices(p.Name, len(recipe.Parameters) == 1, header)
+		if values == nil {
+			values = comparedChoices(p, body)
+		}
+		if len(values) > 1 {
+			choices[i] = values
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+	return choices
+}
+
+// recipeLines finds the recipe in the justfile tree, returning the comments
+// and attributes above it and the recipe itself.
+func recipeLines(name string) (header, body []string) {
+	docs, err := loadJustfileTree()
+	if err != nil {
+		return nil, nil
+	}
+	for _, doc := range docs {
+		span, ok := doc.recipeSpan(name)
+		if !ok {
+			continue
+		}
+		block, _ := doc.recipeBlock(name)
+		return doc.Lines(lineSpan{Start: block.Start, End: span.Start}), doc.Lines(span)
+	}
+	return nil, nil
+}
+
+// declaredChoices reads the values from the comments and attributes above a
+// recipe. Comments saying just "values" apply when there is one parameter.
+func declaredChoices(param string, only bool, header []string) []string {
+	for _, line := range header {
+		line = strings.TrimSpace(line)
+		if m := valuesComment.FindStringSubmatch(line); m != nil && (m[1] == param || m[1] == "values" && only) {
+			return splitChoices(m[2])
+		}
+		if m := argAttribute.FindStringSubmatch(line); m != nil && m[1] == param {
+			if values := patternChoices(m[2] + m[3]); values != nil {
+				return values
+			}
+		}
+	}
+	return nil
+}
+
+// patternChoices returns the alternatives of a pattern like `dev|prod` or
+// `^(dev|prod)$`, or nil if it matches anything more than a list of words.
+func patternChoices(pattern string) []string {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "^"), "$")
+	if strings.HasPrefix(pattern, "(") && strings.HasSuffix(pattern, ")") {
+		pattern = strings.TrimPrefix(pattern[1:len(pattern)-1], "?:")
+	}
+	values := splitChoices(pattern)
+	for _, v := range values {
+		if regexp.QuoteMeta(v) != v {
+			return nil
+		}
+	}
+	return values
+}
+
+func splitChoices(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, "|") {
+		if v = strings.TrimSpace(v); v != "" && !containsString(values, v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// comparedChoices collects the string literals the recipe compares the
+// parameter to, e.g. `if env == "prod"`, along with its default.
+func comparedChoices(p Parameter, body []string) []string {
+	name := regexp.QuoteMeta(p.Name)
+	literal := `(?:"([^"]*)"|'([^']*)')`
+	re := regexp.MustCompile(fmt.Sprintf(`\b%[1]s\s*[!=]=\s*%[2]s|%[2]s\s*[!=]=\s*%[1]s\b`, name, literal))
+
+	var values []string
+	if p.Default != nil && *p.Default != "" && !p.DefaultExpr {
+		values = append(values, *p.Default)
+	}
+	for _, line := range body {
+		for _, m := range re.FindAllStringSubmatch(line, -1) {
+			if v := strings.Join(m[1:], ""); v != "" && !containsString(values, v) {
+				values = append(values, v)
+			}
+		}
+	}
+	if len(values) == 1 {
+		return nil // Only the default
+	}
+	return values
+}
+
+// inputChoices returns the values to choose from for the form field i, nil
+// for a free text field.
+func (m model) inputChoices(i int) []string {
+	if m.state != viewInput || m.selectedRecipe == nil || m.choices.recipe != m.selectedRecipe.Name || i >= len(m.choices.values) {
+		return nil
+	}
+	return m.choices.values[i]
+}
+
+// cycleChoice moves the focused field to the next or previous value.
+func (m *model) cycleChoice(delta int) {
+	values := m.inputChoices(m.focusIndex)
+	current := 0
+	for i, v := range values {
+		if v == m.inputs[m.focusIndex].Value() {
+			current = i
+		}
+	}
+	next := (current + delta + len(values)) % len(values)
+	m.inputs[m.focusIndex].SetValue(values[next])
+}
+
+// choiceView renders a choice field as its values with the chosen one
+// highlighted.
+func (m model) choiceView(i int) string {
+	input := m.inputs[i]
+	chosen := lipgloss.NewStyle().Foreground(activeTheme.Accent).Bold(true)
+	var parts []string
+	for _, v := range m.inputChoices(i) {
+		switch {
+		case v == input.Value() && input.Focused():
+			parts = append(parts, chosen.Render("‹"+v+"›"))
+		case v == input.Value():
+			parts = append(parts, chosen.Render(" "+v+" "))
+		default:
+			parts = append(parts, helpStyle.Render(" "+v+" "))
+		}
+	}
+	return input.Prompt + strings.Join(parts, " ")
+}
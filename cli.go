@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exitNoMatch is the exit code used by --exit-0 when nothing matches.
+const exitNoMatch = 3
+
+// cliOptions holds everything parsed from the command line.
+type cliOptions struct {
+	recipe  string
+	params  map[string]string
+	query   string
+	select1 bool
+	exit0   bool
+
+	recursive bool
+	depth     int
+	ignore    listFlag
+
+	script string // Keys to play instead of reading the keyboard
+}
+
+// justFlags are forwarded to every just invocation, so the tool works from
+// outside the project or with a justfile of another name.
+var justFlags struct {
+	justfile   string // -f/--justfile
+	workingDir string // -d/--working-directory
+}
+
+// addJustFlags registers the forwarded flags on a flag set.
+func addJustFlags(fs *flag.FlagSet) {
+	fs.StringVar(&justFlags.justfile, "f", "", "same as --justfile")
+	fs.StringVar(&justFlags.justfile, "justfile", "", "use this justfile, passed on to just")
+	fs.StringVar(&justFlags.workingDir, "d", "", "same as --working-directory")
+	fs.StringVar(&justFlags.workingDir, "working-directory", "", "run recipes in this directory, passed on to just")
+}
+
+// splitJustFlags takes the forwarded flags off the front of args, so they
+// can come before a subcommand.
+func splitJustFlags(args []string) ([]string, error) {
+	fs := flag.NewFlagSet("just-do-it", flag.ContinueOnError)
+	addJustFlags(fs)
+	var rest []string
+	for len(args) > 0 {
+		name, _, _ := strings.Cut(strings.TrimLeft(args[0], "-"), "=")
+		if !strings.HasPrefix(args[0], "-") || fs.Lookup(name) == nil {
+			break
+		}
+		n := 1
+		if !strings.Contains(args[0], "=") {
+			n = 2
+		}
+		if len(args) < n {
+			return nil, fmt.Errorf("flag needs an argument: %s", args[0])
+		}
+		rest = append(rest, args[:n]...)
+		args = args[n:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return nil, err
+	}
+	return args, resolveJustFlags()
+}
+
+// resolveJustFlags makes the paths absolute, since just runs in the working
+// directory. just only takes --working-directory along with --justfile, so
+// the justfile is looked up from the working directory if it isn't given.
+// $JUST_JUSTFILE and $JUST_WORKING_DIRECTORY stand in for flags that aren't
+// given, as they do for just, so both agree on the justfile.
+func resolveJustFlags() error {
+	if justFlags.justfile == "" {
+		justFlags.justfile = os.Getenv("JUST_JUSTFILE")
+	}
+	if justFlags.workingDir == "" {
+		justFlags.workingDir = os.Getenv("JUST_WORKING_DIRECTORY")
+	}
+
+	var err error
+	if justFlags.workingDir != "" {
+		if justFlags.workingDir, err = filepath.Abs(justFlags.workingDir); err != nil {
+			return err
+		}
+		if justFlags.justfile == "" {
+			if justFlags.justfile, err = findJustfile(justFlags.workingDir); err != nil {
+				return fmt.Errorf("%v in %s", err, justFlags.workingDir)
+			}
+		}
+	}
+	if justFlags.justfile != "" {
+		if justFlags.justfile, err = filepath.Abs(justFlags.justfile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// paramFlag collects repeated `--param name=value` flags.
+type paramFlag map[string]string
+
+func (p paramFlag) String() string {
+	var parts []string
+	for k, v := range p {
+		parts = append(parts, k+"="+v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (p paramFlag) Set(value string) error {
+	name, val, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	p[name] = val
+	return nil
+}
+
+// listFlag collects a repeated flag's values.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func parseFlags(args []string) (*cliOptions, error) {
+	opts := &cliOptions{params: make(map[string]string)}
+
+	fs := flag.NewFlagSet("just-do-it", flag.ContinueOnError)
+	fs.StringVar(&opts.recipe, "recipe", "", "open the parameter form for this recipe")
+	fs.Var(paramFlag(opts.params), "param", "pre-fill a parameter as name=value (repeatable, requires --recipe)")
+	fs.StringVar(&opts.query, "q", "", "start with the filter set to this query")
+	fs.StringVar(&opts.query, "query", "", "same as -q")
+	fs.BoolVar(&opts.select1, "select-1", false, "run the recipe immediately if exactly one matches the query")
+	fs.BoolVar(&opts.exit0, "exit-0", false, fmt.Sprintf("exit with status %d if no recipe matches the query", exitNoMatch))
+	fs.BoolVar(&opts.recursive, "recursive", false, "also list the recipes of justfiles in subdirectories")
+	fs.IntVar(&opts.depth, "depth", defaultDiscoverDepth, "how many directories deep --recursive looks")
+	fs.Var(&opts.ignore, "ignore", "a directory pattern --recursive skips (repeatable)")
+	fs.StringVar(&opts.script, "script", "", "play the keys in this file, then print the final state instead of running anything")
+	addJustFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := resolveJustFlags(); err != nil {
+		return nil, err
+	}
+	if len(opts.params) > 0 && opts.recipe == "" {
+		return nil, fmt.Errorf("--param requires --recipe")
+	}
+	return opts, nil
+}
+
+// runSubcommand handles the non-TUI subcommands. It returns false if args
+// don't name one, in which case the TUI starts as usual.
+func runSubcommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "doctor":
+		runDoctor(os.Stdout)
+	case "run":
+		os.Exit(runHeadless(args[1:]))
+	case "import":
+		os.Exit(runImport(args[1:]))
+	case "daemon":
+		os.Exit(runDaemon(args[1:]))
+	case "report":
+		os.Exit(runReport(args[1:]))
+	case "ai":
+		os.Exit(runAI(args[1:]))
+	default:
+		return false
+	}
+	return true
+}
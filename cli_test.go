@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// resetJustFlags clears the forwarded flags for a test and restores them
+// afterwards.
+func resetJustFlags(t *testing.T) {
+	saved := justFlags
+	t.Cleanup(func() { justFlags = saved })
+	justFlags.justfile, justFlags.workingDir = "", ""
+	t.Setenv("JUST_JUSTFILE", "")
+	t.Setenv("JUST_WORKING_DIRECTORY", "")
+}
+
+func TestSplitJustFlags(t *testing.T) {
+	dir := t.TempDir()
+	justfile := filepath.Join(dir, "justfile")
+	if err := os.WriteFile(justfile, []byte("build:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "other.just")
+
+	tests := []struct {
+		name       string
+		args       []string
+		rest       []string
+		justfile   string
+		workingDir string
+		err        bool
+	}{
+		{"none", []string{"run", "build"}, []string{"run", "build"}, "", "", false},
+		{"empty", nil, nil, "", "", false},
+		{"justfile", []string{"--justfile", other, "list"}, []string{"list"}, other, "", false},
+		{"short", []string{"-f", other}, nil, other, "", false},
+		{"equals", []string{"--justfile=" + other, "run"}, []string{"run"}, other, "", false},
+		{"directory", []string{"-d", dir, "run"}, []string{"run"}, justfile, dir, false},
+		{"both", []string{"-f", other, "-d", dir}, nil, other, dir, false},
+		{"after subcommand", []string{"run", "-f", other}, []string{"run", "-f", other}, "", "", false},
+		{"unknown flag", []string{"--json", "-f", other}, []string{"--json", "-f", other}, "", "", false},
+		{"missing value", []string{"run", "--justfile"}, []string{"run", "--justfile"}, "", "", false},
+		{"no value", []string{"--justfile"}, nil, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetJustFlags(t)
+			rest, err := splitJustFlags(tt.args)
+			if (err != nil) != tt.err {
+				t.Fatalf("splitJustFlags(%q) error = %v, want error %v", tt.args, err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			if len(rest) != len(tt.rest) || len(rest) > 0 && !reflect.DeepEqual(rest, tt.rest) {
+				t.Errorf("splitJustFlags(%q) = %q, want %q", tt.args, rest, tt.rest)
+			}
+			if justFlags.justfile != tt.justfile || justFlags.workingDir != tt.workingDir {
+				t.Errorf("splitJustFlags(%q) set %q, %q, want %q, %q", tt.args, justFlags.justfile, justFlags.workingDir, tt.justfile, tt.workingDir)
+			}
+		})
+	}
+}
+
+func TestSplitJustFlagsEnvironment(t *testing.T) {
+	resetJustFlags(t)
+	t.Setenv("JUST_JUSTFILE", "/from/env/justfile")
+	if _, err := splitJustFlags([]string{"-f", "/from/flag/justfile"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.FromSlash("/from/flag/justfile"); justFlags.justfile != want {
+		t.Errorf("justfile = %q, want the flag's %q", justFlags.justfile, want)
+	}
+
+	resetJustFlags(t)
+	t.Setenv("JUST_JUSTFILE", "/from/env/justfile")
+	if _, err := splitJustFlags(nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.FromSlash("/from/env/justfile"); justFlags.justfile != want {
+		t.Errorf("justfile = %q, want the environment's %q", justFlags.justfile, want)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	osc52 "github.com/aymanbagabas/go-osc52/v2"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Copying goes to the system clipboard, or with "clipboard": "osc52" through
+// the terminal, which reaches the local clipboard from an SSH session in
+// terminals that support it. When neither can be used, as over SSH by
+// default or without a clipboard tool, the text is shown on a screen of its
+// own to be selected and copied by hand.
+
+// copyToClipboard copies the text as configured, returning why it couldn't
+// when the text has to be copied by hand.
+func copyToClipboard(text string) error {
+	cfg, _ := LoadConfig()
+	mode := ""
+	if cfg != nil {
+		mode = cfg.Clipboard
+	}
+	switch mode {
+	case "", "auto":
+		if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+			return errors.New(`over SSH the clipboard is the remote machine's. Set "clipboard": "osc52" if your terminal supports OSC 52`)
+		}
+	case "native":
+	case "osc52":
+		seq := osc52.New(text)
+		switch {
+		case os.Getenv("TMUX") != "":
+			seq = seq.Tmux()
+		case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+			seq = seq.Screen()
+		}
+		_, err := seq.WriteTo(os.Stdout)
+		return err
+	case "manual":
+		return errors.New(`"clipboard" is set to "manual"`)
+	default:
+		return fmt.Errorf("unknown \"clipboard\" setting %q", mode)
+	}
+	if err := clipboard.WriteAll(text); err != nil {
+		if runtime.GOOS == "linux" {
+			return fmt.Errorf("%v. Copying needs xclip, xsel or wl-clipboard", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// manualCopy shows text that couldn't be copied, over whatever screen is
+// open. The mouse is left to the terminal meanwhile so it can select.
+type manualCopy struct {
+	text   string
+	reason string
+}
+
+func (m *model) showManualCopy(text string, reason error) tea.Cmd {
+	logDebug("Copying failed: %v", reason)
+	m.manualCopy = &manualCopy{text: text, reason: reason.Error()}
+	return tea.DisableMouse
+}
+
+// updateManualCopy closes the screen on any key.
+func (m model) updateManualCopy(msg tea.KeyMsg) (model, tea.Cmd) {
+	if key.Matches(msg, m.keys.ForceQuit) {
+		m.stopRuns()
+		return m, tea.Quit
+	}
+	m.manualCopy = nil
+	return m, tea.EnableMouseCellMotion
+}
+
+// manualCopyView prints the text as is, without margins or borders, so
+// selecting it picks up nothing else. Lines wider than the terminal would be
+// cut off, so those are wrapped.
+func (m model) manualCopyView() string {
+	c := m.manualCopy
+	help := helpStyle.Width(max(m.terminalWidth, 20))
+	text := strings.TrimRight(c.text, "\n")
+	wrapped := ansi.Hardwrap(text, max(m.terminalWidth, 20), true)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Copy manually"))
+	b.WriteString("\n\n")
+	b.WriteString(help.Render("Couldn't copy to the clipboard: " + c.reason))
+	b.WriteString("\n")
+	note := "Select the text below with the mouse and copy it in the terminal. Press any key to close."
+	if wrapped != text {
+		note += " Long lines are wrapped to fit, join them again after pasting."
+	}
+	b.WriteString(help.Render(note))
+	b.WriteString("\n\n")
+	b.WriteString(wrapped)
+	b.WriteString("\n")
+	return b.String()
+}
@@ -2,45 +2,457 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	"github.com/adrg/xdg"
 )
 
 type Config struct {
+	// API keys are only written here when there is no OS keychain, or
+	// KeyStorage is "file". Keys found here are moved to the keychain when
+	// AI is first used.
+	KeyStorage string `json:"key_storage,omitempty"`
+
 	GoogleAPIKey string `json:"google_api_key,omitempty"`
 	OpenAIAPIKey string `json:"openai_api_key,omitempty"`
 	GoogleModel  string `json:"google_model,omitempty"`
 	OpenAIModel  string `json:"openai_model,omitempty"`
+
+	AnthropicAPIKey string `json:"anthropic_api_key,omitempty"`
+	AnthropicModel  string `json:"anthropic_model,omitempty"`
+
+	// Provider is the AI provider picked in the AI settings. Empty uses the
+	// first one with an API key.
+	Provider string `json:"provider,omitempty"`
+
+	// OpenAIBaseURL points the OpenAI provider at any OpenAI-compatible
+	// server, e.g. "http://localhost:1234/v1" for LM Studio.
+	OpenAIBaseURL string `json:"openai_base_url,omitempty"`
+
+	// OllamaBaseURL is the Ollama server, "http://localhost:11434" (or
+	// $OLLAMA_HOST) by default.
+	OllamaBaseURL string `json:"ollama_base_url,omitempty"`
+	OllamaModel   string `json:"ollama_model,omitempty"`
+
+	// AIShell is the shell AI commands are written for and run with:
+	// "bash", "zsh", "fish", "pwsh", "nu" or "sh". Empty uses $SHELL.
+	AIShell string `json:"ai_shell,omitempty"`
+
+	// PromptTemplate replaces the instructions at the start of AI prompts,
+	// with {os}, {shell}, {cwd}, {recipes} and {context} filled in.
+	PromptTemplate string `json:"prompt_template,omitempty"`
+
+	// AIItem is where the AI item is listed: "bottom" (the default), "top"
+	// or "hidden". AIItemLabel replaces its "Generate command with AI".
+	// AIItemPrompt is where its request comes from: the "filter" text (the
+	// default), a prompt pre-filled with it ("edit"), or an "empty" prompt.
+	AIItem       string `json:"ai_item,omitempty"`
+	AIItemLabel  string `json:"ai_item_label,omitempty"`
+	AIItemPrompt string `json:"ai_item_prompt,omitempty"`
+
+	// EmbeddingProvider and EmbeddingModel are used for semantic recipe
+	// search. The provider defaults to the AI provider in use, the model to
+	// that provider's usual embedding model.
+	EmbeddingProvider string `json:"embedding_provider,omitempty"`
+	EmbeddingModel    string `json:"embedding_model,omitempty"`
+
+	// RaceProviders sends each generation to all of these providers at
+	// once and uses whichever starts answering first, e.g.
+	// ["openai", "google"].
+	RaceProviders []string `json:"race_providers,omitempty"`
+
+	// FallbackProviders are tried in order when the provider fails, such as
+	// on a rate limit or an exhausted quota, e.g. ["anthropic", "ollama"].
+	// Those without an API key are skipped.
+	FallbackProviders []string `json:"fallback_providers,omitempty"`
+
+	// AIDebug logs every AI request and response, with keys redacted, to
+	// the state directory. ctrl+x a shows the log.
+	AIDebug bool `json:"ai_debug,omitempty"`
+
+	// LogOutput also writes the output of every in-app run to a log file
+	// in the state directory. ctrl+x L lists them.
+	LogOutput bool `json:"log_output,omitempty"`
+
+	// RouteRecipes first checks whether an AI request is something an
+	// existing recipe already does, asking the "llm" or comparing
+	// "embeddings". Empty goes straight to command generation.
+	RouteRecipes string `json:"route_recipes,omitempty"`
+
+	// ParamEnv maps recipe parameter names to environment variables used to
+	// pre-fill the parameter form, e.g. {"env": "DEPLOY_ENV"}.
+	ParamEnv map[string]string `json:"param_env,omitempty"`
+
+	// PathParams marks parameters as taking a "file" or a "dir", by name or
+	// a glob over names, e.g. {"target": "dir", "*_manifest": "file"}.
+	// Names with file, path, dir and the like are paths without it.
+	PathParams map[string]string `json:"path_params,omitempty"`
+
+	// Background forces the "dark" or "light" theme instead of asking the
+	// terminal. Empty or "auto" detects it.
+	Background string `json:"background,omitempty"`
+
+	// Theme is the color theme: "default", "dark", "light", "dracula",
+	// "solarized-dark" or "solarized-light". ThemeColors overrides single
+	// colors of it, e.g. {"accent": "#FF8800"}.
+	Theme       string            `json:"theme,omitempty"`
+	ThemeColors map[string]string `json:"theme_colors,omitempty"`
+
+	// ColorProfile pins the terminal color support: "truecolor", "256",
+	// "16" or "none". Empty detects it from the environment.
+	ColorProfile string `json:"color_profile,omitempty"`
+
+	// ReduceMotion stops the spinner, showing a still dot while work is
+	// in progress.
+	ReduceMotion bool `json:"reduce_motion,omitempty"`
+
+	// Clipboard is how copying works: "native" for the system clipboard,
+	// "osc52" through the terminal, or "manual" to always show the text to
+	// copy by hand. Empty or "auto" is native, but manual over SSH.
+	Clipboard string `json:"clipboard,omitempty"`
+
+	// SplitRatio is the share of the width the recipe list takes next to
+	// the preview, 0.35 by default. ctrl+left and ctrl+right change it.
+	SplitRatio float64 `json:"split_ratio,omitempty"`
+
+	// AutoFormat runs `just --fmt` after every change this tool writes to
+	// the justfile.
+	AutoFormat bool `json:"auto_format,omitempty"`
+
+	// ConfirmRun shows the exact command line before handing over to it,
+	// with DryRunPreview also what `just --dry-run` says the recipe runs.
+	ConfirmRun    bool `json:"confirm_run,omitempty"`
+	DryRunPreview bool `json:"dry_run_preview,omitempty"`
+
+	// EnterAction is what enter does on a recipe: "run", "confirm" (the
+	// same as ConfirmRun) or "edit-command". EnterActions overrides it for
+	// recipes matching a pattern, the first match winning.
+	EnterAction  string      `json:"enter_action,omitempty"`
+	EnterActions []EnterRule `json:"enter_actions,omitempty"`
+
+	// GitGuards make recipes matching a pattern ask for their name to be
+	// typed, or refuse to run, when the working tree is dirty or the branch
+	// isn't main, e.g. [{"pattern": "release*"}].
+	GitGuards []GitGuard `json:"git_guards,omitempty"`
+
+	// DangerPatterns are regular expressions flagging AI commands that have
+	// to be confirmed by typing before they run, on top of the built-in
+	// ones, e.g. [{"pattern": "\\bprod\\b", "reason": "touches production"}].
+	DangerPatterns []DangerRule `json:"danger_patterns,omitempty"`
+
+	// MatrixConcurrency is how many runs of a matrix run at once, 1 (the
+	// default) runs them one after the other.
+	MatrixConcurrency int `json:"matrix_concurrency,omitempty"`
+
+	// Environment runs recipes through a project environment tool:
+	// "direnv", "mise" or "none". Empty or "auto" uses whichever the project
+	// has set up.
+	Environment string `json:"environment,omitempty"`
+
+	// Multiplexer is where ctrl+x o opens recipes next to the TUI:
+	// "tmux-pane", "tmux-window" or "zellij". Empty uses a pane of the
+	// multiplexer the TUI runs in.
+	Multiplexer string `json:"multiplexer,omitempty"`
+
+	// Workspace lists project directories with their own justfiles, such as
+	// the services of a monorepo, relative to the project. Their recipes are
+	// listed together and each runs in its own directory.
+	Workspace []string `json:"workspace,omitempty"`
+
+	// LoginShell runs recipes through `$SHELL -lc`, so PATH changes from
+	// shell profiles apply. It's off by default as the profiles can take a
+	// while to load.
+	LoginShell bool `json:"login_shell,omitempty"`
+
+	// ExecWrapper runs every recipe inside a dev environment: a preset
+	// ("nix", "devbox", "devcontainer") or a template such as
+	// "docker compose exec app {cmd}". ExecWrappers overrides it per project
+	// directory, "none" turning it off.
+	ExecWrapper  string            `json:"exec_wrapper,omitempty"`
+	ExecWrappers map[string]string `json:"exec_wrappers,omitempty"`
+
+	// WatchIgnore are glob patterns watch mode skips, matched against paths
+	// relative to the project and against base names, e.g. ["*.log",
+	// "dist"]. Version control and dependency directories are always
+	// skipped.
+	WatchIgnore []string `json:"watch_ignore,omitempty"`
+
+	// KeyBindings overrides the keys for actions, e.g.
+	// {"run_in_app": ["ctrl+o"], "quit": ["q", "ctrl+q"]}.
+	KeyBindings map[string][]string `json:"keybindings,omitempty"`
+
+	// ResourceLimits throttle in-app runs of matching recipes, the first
+	// matching pattern wins.
+	ResourceLimits []ResourceLimit `json:"resource_limits,omitempty"`
+
+	// The global file's values and those the project file overrides, kept
+	// so saving doesn't copy project settings into the global file.
+	global, overrides, merged map[string]any
+}
+
+// apiKey returns the API key for a provider, the environment taking
+// precedence over the config file.
+func (c *Config) apiKey(p aiProvider) string {
+	if p.KeyEnv == "" {
+		return ""
+	}
+	if key := os.Getenv(p.KeyEnv); key != "" {
+		return key
+	}
+	if key := c.fileAPIKey(p); key != "" || !c.useKeychain() {
+		return key
+	}
+	key, err := keyringGet(p.ID)
+	if err != nil {
+		logDebug("Reading the %s key from the keychain: %v", p.ID, err)
+	}
+	return key
+}
+
+// setAPIKey stores the key in the keychain, or in the config if there is
+// none. The config still has to be saved afterwards.
+func (c *Config) setAPIKey(p aiProvider, key string) {
+	if c.useKeychain() {
+		err := keyringSet(p.ID, key)
+		if err == nil {
+			c.setFileAPIKey(p, "")
+			return
+		}
+		logDebug("Falling back to the config file for the %s key: %v", p.ID, err)
+	}
+	c.setFileAPIKey(p, key)
+}
+
+// keyStore says where setAPIKey would put the provider's key.
+func (c *Config) keyStore(p aiProvider) string {
+	if !c.useKeychain() {
+		return "your config file"
+	}
+	if _, err := keyringGet(p.ID); errors.Is(err, errNoKeyring) {
+		return "your config file, as there is no keychain"
+	}
+	return "the OS keychain"
+}
+
+func (c *Config) useKeychain() bool {
+	return c.KeyStorage != "file"
+}
+
+// fileAPIKey is the key as written in the config file.
+func (c *Config) fileAPIKey(p aiProvider) string {
+	switch p.ID {
+	case "google":
+		return c.GoogleAPIKey
+	case "openai":
+		return c.OpenAIAPIKey
+	case "anthropic":
+		return c.AnthropicAPIKey
+	}
+	return ""
+}
+
+func (c *Config) setFileAPIKey(p aiProvider, key string) {
+	switch p.ID {
+	case "google":
+		c.GoogleAPIKey = key
+	case "openai":
+		c.OpenAIAPIKey = key
+	case "anthropic":
+		c.AnthropicAPIKey = key
+	}
+}
+
+// migrateAPIKeys moves keys from the config file into the keychain. It
+// returns whether any moved, in which case the config needs saving.
+func (c *Config) migrateAPIKeys() bool {
+	if !c.useKeychain() {
+		return false
+	}
+	moved := false
+	for _, p := range aiProviders {
+		key := c.fileAPIKey(p)
+		if key == "" {
+			continue
+		}
+		if err := keyringSet(p.ID, key); err != nil {
+			logDebug("Not moving the %s key to the keychain: %v", p.ID, err)
+			return moved
+		}
+		c.setFileAPIKey(p, "")
+		moved = true
+	}
+	return moved
+}
+
+// model returns the configured model for a provider, or its default.
+func (c *Config) model(p aiProvider) string {
+	var model string
+	switch p.ID {
+	case "google":
+		model = c.GoogleModel
+	case "openai":
+		model = c.OpenAIModel
+	case "anthropic":
+		model = c.AnthropicModel
+	case "ollama":
+		model = c.OllamaModel
+	}
+	if model == "" {
+		return p.DefaultModel
+	}
+	return model
+}
+
+func (c *Config) setModel(p aiProvider, model string) {
+	switch p.ID {
+	case "google":
+		c.GoogleModel = model
+	case "openai":
+		c.OpenAIModel = model
+	case "anthropic":
+		c.AnthropicModel = model
+	case "ollama":
+		c.OllamaModel = model
+	}
+}
+
+// The config is config.json, or config.toml when that exists. A
+// .just-do-it.toml in the project overrides settings from it for that
+// project, tables such as theme_colors merging key by key.
+
+const projectConfigName = ".just-do-it.toml"
+
+// projectOnlyGlobal are settings a project file can't set: a cloned
+// repository shouldn't be able to pick where API keys are sent, change what
+// every command runs inside of, or have prompts logged.
+var projectOnlyGlobal = map[string]bool{
+	"key_storage":       true,
+	"google_api_key":    true,
+	"openai_api_key":    true,
+	"anthropic_api_key": true,
+	"openai_base_url":   true,
+	"ollama_base_url":   true,
+	"exec_wrapper":      true,
+	"exec_wrappers":     true,
+	"environment":       true,
+	"ai_debug":          true,
 }
 
 func GetConfigPath() (string, error) {
+	path, err := xdg.ConfigFile("just-do-it/config.toml")
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
 	return xdg.ConfigFile("just-do-it/config.json")
 }
 
+// projectConfigPath is the project's override file, which may not exist.
+func projectConfigPath() string {
+	return filepath.Join(projectDir(), projectConfigName)
+}
+
+// readConfigFile decodes a JSON or TOML config file into maps. A missing
+// file is empty.
+func readConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if filepath.Ext(path) == ".toml" {
+		values, err = parseTOML(string(data))
+	} else {
+		err = json.Unmarshal(data, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if values == nil {
+		values = map[string]any{}
+	}
+	return values, nil
+}
+
 func LoadConfig() (*Config, error) {
 	path, err := GetConfigPath()
 	if err != nil {
 		return nil, err
 	}
+	global, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	project, err := readConfigFile(projectConfigPath())
+	if err != nil {
+		return nil, err
+	}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return &Config{}, nil
+	merged := map[string]any{}
+	for k, v := range global {
+		merged[k] = v
+	}
+	for k, v := range project {
+		if projectOnlyGlobal[k] {
+			logDebug("Ignoring %s in %s, it can only be set globally", k, projectConfigName)
+			delete(project, k)
+			continue
+		}
+		base, baseIsTable := merged[k].(map[string]any)
+		override, isTable := v.(map[string]any)
+		if baseIsTable && isTable {
+			table := map[string]any{}
+			for tk, tv := range base {
+				table[tk] = tv
+			}
+			for tk, tv := range override {
+				table[tk] = tv
+			}
+			v = table
+		}
+		merged[k] = v
 	}
 
-	data, err := os.ReadFile(path)
+	data, err := json.Marshal(merged)
 	if err != nil {
 		return nil, err
 	}
-
 	var cfg Config
 	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(project) > 0 {
+		cfg.global = global
+		cfg.overrides = project
+		cfg.merged, err = configValues(&cfg)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return &cfg, nil
 }
 
+// configValues is the config as the maps it is written from.
+func configValues(cfg *Config) (map[string]any, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	return values, json.Unmarshal(data, &values)
+}
+
+// SaveConfig writes the config back to the global file. Settings still as
+// a project file set them keep their global values.
 func SaveConfig(cfg *Config) error {
 	path, err := GetConfigPath()
 	if err != nil {
@@ -52,7 +464,33 @@ func SaveConfig(cfg *Config) error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	var values any = cfg
+	if len(cfg.overrides) > 0 || filepath.Ext(path) == ".toml" {
+		current, err := configValues(cfg)
+		if err != nil {
+			return err
+		}
+		for k := range cfg.overrides {
+			if !reflect.DeepEqual(current[k], cfg.merged[k]) {
+				continue
+			}
+			if v, ok := cfg.global[k]; ok {
+				current[k] = v
+			} else {
+				delete(current, k)
+			}
+		}
+		values = current
+	}
+
+	if filepath.Ext(path) == ".toml" {
+		data, err := encodeTOML(values.(map[string]any))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(data), 0600)
+	}
+	data, err := json.MarshalIndent(values, "", "  ")
 	if err != nil {
 		return err
 	}
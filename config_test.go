@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+// testConfigDirs points the global config and the project at temporary
+// directories and returns both.
+func testConfigDirs(t *testing.T) (configHome, project string) {
+	configHome, project = t.TempDir(), t.TempDir()
+	t.Chdir(project) // Keep debug.log out of the repository
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	resetJustFlags(t)
+	justFlags.workingDir = project
+	justFlags.justfile = filepath.Join(project, "justfile")
+	return configHome, project
+}
+
+func TestConfigProjectOverrides(t *testing.T) {
+	configHome, project := testConfigDirs(t)
+	globalPath := filepath.Join(configHome, "just-do-it", "config.json")
+	if err := os.MkdirAll(filepath.Dir(globalPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	global := `{"google_model": "global-model", "openai_model": "gpt", "openai_base_url": "https://global.example"}`
+	if err := os.WriteFile(globalPath, []byte(global), 0600); err != nil {
+		t.Fatal(err)
+	}
+	override := `google_model = "project-model"
+openai_base_url = "https://evil.example"
+exec_wrapper = "evil"
+ai_debug = true
+`
+	if err := os.WriteFile(filepath.Join(project, projectConfigName), []byte(override), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := []struct {
+		key, got, want string
+	}{
+		{"google_model", cfg.GoogleModel, "project-model"},
+		{"openai_model", cfg.OpenAIModel, "gpt"},
+		{"openai_base_url", cfg.OpenAIBaseURL, "https://global.example"},
+	}
+	for _, tt := range loaded {
+		if tt.got != tt.want {
+			t.Errorf("LoadConfig() %s = %q, want %q", tt.key, tt.got, tt.want)
+		}
+	}
+	if cfg.AIDebug {
+		t.Error("LoadConfig() took ai_debug from the project file")
+	}
+
+	cfg.OpenAIModel = "gpt-changed"
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(globalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]any
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	written := []struct {
+		key  string
+		want any
+	}{
+		{"google_model", "global-model"},
+		{"openai_model", "gpt-changed"},
+		{"openai_base_url", "https://global.example"},
+		{"exec_wrapper", nil},
+		{"ai_debug", nil},
+	}
+	for _, tt := range written {
+		if saved[tt.key] != tt.want {
+			t.Errorf("SaveConfig() wrote %s = %v, want %v", tt.key, saved[tt.key], tt.want)
+		}
+	}
+}
+
+func TestConfigTOMLRoundTrip(t *testing.T) {
+	configHome, _ := testConfigDirs(t)
+	globalPath := filepath.Join(configHome, "just-do-it", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(globalPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(globalPath, []byte("google_model = \"gemini\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.AutoFormat = true
+	if err := SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.GoogleModel != "gemini" || !reloaded.AutoFormat {
+		t.Errorf("LoadConfig() after SaveConfig() = %q, %v, want gemini, true", reloaded.GoogleModel, reloaded.AutoFormat)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// confirmation is a yes/no screen with a scrollable body, used before
+// anything that changes files or runs something the user should double check.
+type confirmation struct {
+	title    string
+	body     viewport.Model
+	onYes    tea.Cmd
+	onNo     tea.Cmd // Runs on No but not on Cancel, when set
+	noHelp   string
+	returnTo state
+	typed    string // When set, this word has to be typed instead of pressing y
+	input    textinput.Model
+}
+
+// Msg to show a confirmation screen
+type confirmMsg struct {
+	title  string
+	body   string
+	onYes  tea.Cmd
+	onNo   tea.Cmd
+	noHelp string // Footer text for No when onNo is set
+	typed  string
+}
+
+func (m *model) startConfirm(msg confirmMsg) {
+	vp := viewport.New(m.terminalWidth-6, m.terminalHeight-8)
+	vp.SetContent(msg.body)
+	m.confirm = &confirmation{
+		title:    msg.title,
+		body:     vp,
+		onYes:    msg.onYes,
+		onNo:     msg.onNo,
+		noHelp:   msg.noHelp,
+		returnTo: m.state,
+		typed:    msg.typed,
+	}
+	if msg.typed != "" {
+		m.confirm.body.Height -= 2 // For the input
+		t := textinput.New()
+		t.Prompt = "Type " + msg.typed + " to confirm: "
+		t.Focus()
+		m.confirm.input = t
+	}
+	m.state = viewConfirm
+}
+
+func (m model) updateConfirm(msg tea.KeyMsg) (model, tea.Cmd) {
+	if m.confirm.typed != "" {
+		return m.updateTypedConfirm(msg)
+	}
+	switch {
+	case key.Matches(msg, m.keys.Yes):
+		cmd := m.confirm.onYes
+		m.state = m.confirm.returnTo
+		m.confirm = nil
+		return m, cmd
+	case key.Matches(msg, m.keys.No) && m.confirm.onNo != nil:
+		cmd := m.confirm.onNo
+		m.state = m.confirm.returnTo
+		m.confirm = nil
+		return m, cmd
+	case key.Matches(msg, m.keys.No, m.keys.Cancel):
+		m.state = m.confirm.returnTo
+		m.confirm = nil
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.confirm.body, cmd = m.confirm.body.Update(msg)
+	return m, cmd
+}
+
+// updateTypedConfirm runs onYes once the word is typed and entered.
+func (m model) updateTypedConfirm(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = m.confirm.returnTo
+		m.confirm = nil
+		return m, nil
+	case key.Matches(msg, m.keys.Select):
+		if m.confirm.input.Value() != m.confirm.typed {
+			return m, nil
+		}
+		cmd := m.confirm.onYes
+		m.state = m.confirm.returnTo
+		m.confirm = nil
+		return m, cmd
+	case msg.Type == tea.KeyUp || msg.Type == tea.KeyDown || msg.Type == tea.KeyPgUp || msg.Type == tea.KeyPgDown:
+		var cmd tea.Cmd
+		m.confirm.body, cmd = m.confirm.body.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.confirm.input, cmd = m.confirm.input.Update(msg)
+	return m, cmd
+}
+
+func (m model) confirmView() string {
+	body := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(activeTheme.Border).
+		Padding(0, 1).
+		Render(m.confirm.body.View())
+
+	parts := []string{titleStyle.Render(m.confirm.title), "", body}
+	if m.confirm.typed != "" {
+		parts = append(parts, "", m.confirm.input.View())
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+)
+
+// A panic shouldn't leave the terminal in raw mode on the alternate screen.
+// bubbletea restores it for panics in Update, View and commands; crashGuard
+// writes a crash report for those first. Goroutines the tool starts itself
+// defer recoverCrash, which restores the terminal on its own.
+
+var crash struct {
+	mu     sync.Mutex
+	report string      // Path of the report written, once
+	term   *term.State // Before the TUI started
+}
+
+// crashDir is where crash reports go, the state directory.
+func crashDir() (string, error) {
+	path, err := xdg.StateFile("just-do-it/crashes/x")
+	return filepath.Dir(path), err
+}
+
+// reportCrash writes a report for the panic with the stack of the calling
+// goroutine, returning its path. Only the first panic is reported, the
+// others usually follow from it.
+func reportCrash(r any) string {
+	crash.mu.Lock()
+	defer crash.mu.Unlock()
+	if crash.report != "" {
+		return crash.report
+	}
+	dir, err := crashDir()
+	if err != nil {
+		return ""
+	}
+	version := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "just-do-it %s crashed at %s\n", version, time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "%s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "args: %q\n\n", os.Args[1:])
+	fmt.Fprintf(&b, "panic: %v\n\n%s", r, debug.Stack())
+
+	path := filepath.Join(dir, "crash-"+time.Now().Format("20060102-150405")+".log")
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		return ""
+	}
+	crash.report = path
+	return path
+}
+
+// crashReport is the path of the report written, empty when nothing
+// crashed.
+func crashReport() string {
+	crash.mu.Lock()
+	defer crash.mu.Unlock()
+	return crash.report
+}
+
+// saveTerminal remembers the terminal state to go back to after a crash.
+func saveTerminal() {
+	if state, err := term.GetState(os.Stdin.Fd()); err == nil {
+		crash.term = state
+	}
+}
+
+// restoreTerminal leaves the alternate screen, shows the cursor, stops
+// mouse reporting and leaves raw mode.
+func restoreTerminal() {
+	fmt.Fprint(os.Stdout, "\x1b[?1002l\x1b[?1003l\x1b[?1006l\x1b[?1049l\x1b[?25h")
+	if crash.term != nil {
+		term.Restore(os.Stdin.Fd(), crash.term)
+	}
+}
+
+// recoverCrash is deferred at the top of goroutines the tool starts. A
+// panic there would end the process without bubbletea restoring anything.
+func recoverCrash() {
+	if r := recover(); r != nil {
+		restoreTerminal()
+		fmt.Fprintf(os.Stderr, "just-do-it crashed: %v\n", r)
+		if path := reportCrash(r); path != "" {
+			fmt.Fprintf(os.Stderr, "A crash report was written to %s, please attach it to an issue.\n", path)
+		}
+		popTerminalTitle()
+		os.Exit(2)
+	}
+}
+
+// reportPanic writes a crash report for a panic on its way to bubbletea.
+func reportPanic() {
+	if r := recover(); r != nil {
+		reportCrash(r)
+		panic(r)
+	}
+}
+
+// crashGuard wraps the model so its panics are reported, commands included.
+type crashGuard struct {
+	tea.Model
+}
+
+func (g crashGuard) Init() tea.Cmd {
+	defer reportPanic()
+	return guardCmd(g.Model.Init())
+}
+
+func (g crashGuard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer reportPanic()
+	next, cmd := g.Model.Update(msg)
+	return crashGuard{next}, guardCmd(cmd)
+}
+
+func (g crashGuard) View() string {
+	defer reportPanic()
+	return g.Model.View()
+}
+
+// guardCmd reports panics in the command, and in the commands of a batch it
+// returns.
+func guardCmd(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		defer reportPanic()
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			guarded := make(tea.BatchMsg, len(batch))
+			for i, c := range batch {
+				guarded[i] = guardCmd(c)
+			}
+			return guarded
+		}
+		return msg
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+// `just-do-it daemon` keeps the output of just's read-only commands (the
+// dump the list is built from and the recipe sources the preview shows)
+// warm across starts of the picker. The picker asks it over a unix socket
+// when it's running and runs just itself when it isn't, so the daemon is
+// purely an optimization. Entries are dropped when a justfile or import
+// they were read from changes, or one that was missing, such as an
+// `import?` target, appears.
+
+// daemonDialTimeout bounds how long the picker waits for the daemon before
+// running just itself.
+const daemonDialTimeout = 50 * time.Millisecond
+
+// cachedJustArgs are the just commands the daemon answers; they only read
+// the justfile, so their output depends on nothing else.
+var cachedJustArgs = []string{"--dump", "--show", "--summary", "--variables"}
+
+type daemonRequest struct {
+	Dir  string   `json:"dir"`
+	Args []string `json:"args"`
+	Env  []string `json:"env"`
+}
+
+type daemonResponse struct {
+	Output []byte `json:"output"`
+	Err    string `json:"err,omitempty"`
+}
+
+func daemonSocketPath() (string, error) {
+	return xdg.RuntimeFile("just-do-it/daemon.sock")
+}
+
+// justOutput runs just with the forwarded flags and returns its output,
+// through the daemon when it's running.
+func justOutput(args ...string) ([]byte, error) {
+	return justOutputArgs(justArgs(args...))
+}
+
+// justOutputArgs is justOutput with the arguments to just as they are.
+func justOutputArgs(args []string) ([]byte, error) {
+	command := append([]string{"just"}, args...)
+	if resp, ok := askDaemon(args); ok {
+		if resp.Err != "" {
+			return resp.Output, &commandError{command: command, stderr: resp.Err, err: errors.New("failed")}
+		}
+		return resp.Output, nil
+	}
+	out, err := justCommand(args...).Output()
+	if err != nil {
+		return out, newCommandError(command, err)
+	}
+	return out, nil
+}
+
+// askDaemon has the daemon run just. It returns false when there is no
+// daemon or it couldn't answer.
+func askDaemon(args []string) (daemonResponse, bool) {
+	if !daemonCaches(args) {
+		return daemonResponse{}, false
+	}
+	path, err := daemonSocketPath()
+	if err != nil {
+		return daemonResponse{}, false
+	}
+	conn, err := net.DialTimeout("unix", path, daemonDialTimeout)
+	if err != nil {
+		return daemonResponse{}, false
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	dir, _ := os.Getwd()
+	if err := json.NewEncoder(conn).Encode(daemonRequest{Dir: dir, Args: args, Env: os.Environ()}); err != nil {
+		return daemonResponse{}, false
+	}
+	var resp daemonResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		logDebug("Daemon didn't answer: %v", err)
+		return daemonResponse{}, false
+	}
+	return resp, true
+}
+
+// daemonCaches reports whether the just command is one the daemon answers.
+func daemonCaches(args []string) bool {
+	for _, a := range args {
+		for _, c := range cachedJustArgs {
+			if a == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// daemonEntry is a cached answer, with the modification times of the files
+// it was read from. Files that would have been read had they existed, such
+// as an `import?` target, have a zero time.
+type daemonEntry struct {
+	resp  daemonResponse
+	files map[string]time.Time
+}
+
+func (e daemonEntry) fresh() bool {
+	for path, mod := range e.files {
+		info, err := os.Stat(path)
+		if mod.IsZero() {
+			if err == nil {
+				return false // Created since
+			}
+			continue
+		}
+		if err != nil || !info.ModTime().Equal(mod) {
+			return false
+		}
+	}
+	return true
+}
+
+type daemon struct {
+	mu    sync.Mutex
+	cache map[[32]byte]daemonEntry
+}
+
+// daemonKey identifies a request: the same just command, from the same
+// directory, with the same JUST_ variables.
+func daemonKey(req daemonRequest) [32]byte {
+	parts := append([]string{req.Dir}, req.Args...)
+	for _, kv := range req.Env {
+		if strings.HasPrefix(kv, "JUST_") {
+			parts = append(parts, kv)
+		}
+	}
+	return sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+}
+
+// sourceFiles are the files a request reads: the justfile it resolves to,
+// its imports and the module files next to it. Those that don't exist are
+// included with a zero time: a justfile closer to the directory than the
+// one found, and imports that are optional.
+func sourceFiles(req daemonRequest) map[string]time.Time {
+	root := ""
+	for i, a := range req.Args {
+		if (a == "--justfile" || a == "-f") && i+1 < len(req.Args) {
+			root = req.Args[i+1]
+		}
+	}
+	var paths []string
+	if root == "" {
+		root, _ = findJustfile(req.Dir)
+		for dir := req.Dir; ; dir = filepath.Dir(dir) {
+			for _, name := range justfileNames {
+				paths = append(paths, filepath.Join(dir, name))
+			}
+			if dir == filepath.Dir(root) || dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	} else if !filepath.IsAbs(root) {
+		root = filepath.Join(req.Dir, root)
+	}
+
+	files := map[string]time.Time{}
+	if docs, err := justfileTreeFrom(root); err == nil {
+		for _, d := range docs {
+			paths = append(paths, d.path)
+			for _, imp := range d.imports() {
+				paths = append(paths, imp.path)
+			}
+		}
+	}
+	dir := filepath.Dir(root)
+	modules, _ := filepath.Glob(filepath.Join(dir, "*.just"))
+	paths = append(paths, modules...)
+	for _, name := range justfileNames {
+		nested, _ := filepath.Glob(filepath.Join(dir, "*", name))
+		paths = append(paths, nested...)
+	}
+	for _, path := range append(paths, root) {
+		if info, err := os.Stat(path); err == nil {
+			files[path] = info.ModTime()
+		} else if os.IsNotExist(err) {
+			files[path] = time.Time{}
+		}
+	}
+	return files
+}
+
+func (d *daemon) answer(req daemonRequest) daemonResponse {
+	key := daemonKey(req)
+	d.mu.Lock()
+	entry, ok := d.cache[key]
+	d.mu.Unlock()
+	if ok && entry.fresh() {
+		return entry.resp
+	}
+
+	// Stat before running, so a change while just reads is caught next time
+	files := sourceFiles(req)
+	cmd := justCommand(req.Args...)
+	cmd.Dir = req.Dir
+	cmd.Env = req.Env
+	out, err := cmd.Output()
+	resp := daemonResponse{Output: out}
+	if err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+			resp.Err = strings.TrimSpace(string(exit.Stderr))
+		} else {
+			resp.Err = err.Error()
+		}
+		return resp // Failures aren't cached, they're usually being fixed
+	}
+
+	d.mu.Lock()
+	d.cache[key] = daemonEntry{resp: resp, files: files}
+	d.mu.Unlock()
+	return resp
+}
+
+func (d *daemon) serve(conn net.Conn) {
+	defer conn.Close()
+	var req daemonRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		return
+	}
+	if !daemonCaches(req.Args) {
+		json.NewEncoder(conn).Encode(daemonResponse{Err: "not a command the daemon runs"})
+		return
+	}
+	json.NewEncoder(conn).Encode(d.answer(req))
+}
+
+// runDaemon serves requests until interrupted.
+func runDaemon(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "usage: just-do-it daemon")
+		return 2
+	}
+	path, err := daemonSocketPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if conn, err := net.DialTimeout("unix", path, daemonDialTimeout); err == nil {
+		conn.Close()
+		fmt.Fprintf(os.Stderr, "A daemon is already listening on %s\n", path)
+		return 1
+	}
+	os.Remove(path) // Left behind by one that didn't exit cleanly
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		ln.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", path)
+	d := &daemon{cache: map[[32]byte]daemonEntry{}}
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return 0
+			}
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		go d.serve(conn)
+	}
+}
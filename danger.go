@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// AI commands are checked for things that are hard to undo before they run.
+// A flagged command has to be confirmed by typing a word, so a quick enter
+// doesn't run it. The check is a safety net for the obvious cases, not a
+// sandbox.
+
+// dangerConfirmWord is typed to run a flagged command.
+const dangerConfirmWord = "run"
+
+// DangerRule flags commands matching Pattern, a regular expression.
+type DangerRule struct {
+	Pattern string `json:"pattern"`
+	Reason  string `json:"reason"`
+}
+
+type dangerCheck struct {
+	re     *regexp.Regexp
+	reason string
+}
+
+var defaultDangerRules = []DangerRule{
+	{`\brm\s+(?:-\S+\s+)*-\S*[rR]\S*\s+(?:-\S+\s+)*(?:/|/\*|~/?|\$HOME/?|\*|\.|\.\.)(?:$|[\s;&|])`, "deletes everything under /, your home or the current directory"},
+	{`--no-preserve-root`, "deletes from the root directory"},
+	{`\b(?:curl|wget)\b[^|;&]*\|\s*(?:sudo\s+)?(?:ba|z|da|k)?sh\b`, "runs a downloaded script"},
+	{`\b(?:ba|z)?sh\s+<\(\s*(?:curl|wget)\b`, "runs a downloaded script"},
+	{`\bdd\b.*\bof=/dev/(?:sd|nvme|hd|vd|xvd|disk|rdisk|mmcblk)`, "writes over a disk"},
+	{`>\s*/dev/(?:sd|nvme|hd|vd|xvd|disk|rdisk|mmcblk)`, "writes over a disk"},
+	{`\bmkfs(?:\.\w+)?\b`, "formats a file system"},
+	{`\bgit\s+push\b.*(?:\s--force(?:$|\s)|\s-f\b|\s\+\S)`, "force-pushes, replacing the remote's history"},
+	{`\bgit\s+(?:reset\s+--hard|clean\s+-\S*f)`, "throws away uncommitted changes"},
+	{`\bchmod\s+(?:-\S+\s+)*-\S*R\S*\s+0?777\s+/`, "makes files world-writable"},
+	{`\bch(?:own|mod)\s+(?:-\S+\s+)*-\S*R\S*\s+\S+\s+/(?:$|[\s;&|])`, "changes ownership or permissions of the whole system"},
+	{`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`, "is a fork bomb"},
+	{`(?:^|[;&|]\s*|sudo\s+)(?:shutdown|reboot|halt|poweroff)\b`, "shuts down or restarts the machine"},
+	{`(?i)\bdrop\s+(?:database|schema|table)\b`, "drops database objects"},
+	{`\bterraform\s+destroy\b|\bkubectl\s+delete\b`, "tears down infrastructure"},
+}
+
+// dangerChecks compiles the built-in rules and the config's danger_patterns.
+// Broken patterns are reported and skipped.
+func dangerChecks(cfg *Config) ([]dangerCheck, error) {
+	rules := defaultDangerRules
+	if cfg != nil {
+		rules = append(append([]DangerRule(nil), rules...), cfg.DangerPatterns...)
+	}
+	var checks []dangerCheck
+	var errs []string
+	for _, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("danger_patterns: %q: %v", r.Pattern, err))
+			continue
+		}
+		reason := r.Reason
+		if reason == "" {
+			reason = "matches " + r.Pattern
+		}
+		checks = append(checks, dangerCheck{re, reason})
+	}
+	if len(errs) > 0 {
+		return checks, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return checks, nil
+}
+
+// commandDangers lists why a command looks dangerous, nothing if it doesn't.
+func commandDangers(cfg *Config, command string) []string {
+	checks, err := dangerChecks(cfg)
+	if err != nil {
+		logDebug("%v", err)
+	}
+	var reasons []string
+	for _, c := range checks {
+		if c.re.MatchString(command) && !containsString(reasons, c.reason) {
+			reasons = append(reasons, c.reason)
+		}
+	}
+	return reasons
+}
+
+// runInAppMsg runs a command in the output pane once confirmed.
+type runInAppMsg struct {
+	recipe  string
+	command []string
+}
+
+// guardAICommand has a flagged AI command confirmed by typing before run
+// goes ahead. It returns nil when there's nothing to confirm.
+func (m model) guardAICommand(run tea.Cmd) tea.Cmd {
+	if !m.isAICommand() {
+		return nil
+	}
+	cfg, _ := LoadConfig()
+	command := m.inputs[0].Value()
+	reasons := commandDangers(cfg, command)
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "$ %s\n\n", command)
+	for _, r := range reasons {
+		fmt.Fprintf(&b, "⚠ This command %s.\n", r)
+	}
+	return func() tea.Msg {
+		return confirmMsg{
+			title: "Dangerous command",
+			body:  b.String(),
+			onYes: run,
+			typed: dangerConfirmWord,
+		}
+	}
+}
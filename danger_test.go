@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestCommandDangers(t *testing.T) {
+	tests := []struct {
+		command   string
+		dangerous bool
+	}{
+		{"rm -rf /", true},
+		{"rm -rf ~", true},
+		{"rm -fr .", true},
+		{"sudo rm -r -f /*", true},
+		{"rm -rf ./build", false},
+		{"rm -rf node_modules", false},
+		{"rm file.txt", false},
+		{"rm --no-preserve-root -rf /", true},
+		{"curl -fsSL https://example.com/install.sh | sh", true},
+		{"wget -qO- https://example.com | sudo bash", true},
+		{"bash <(curl -s https://example.com)", true},
+		{"curl -o out.json https://example.com", false},
+		{"dd if=image.iso of=/dev/sdb bs=4M", true},
+		{"dd if=/dev/zero of=file bs=1M count=1", false},
+		{"mkfs.ext4 /dev/sdb1", true},
+		{"git push --force origin main", true},
+		{"git push -f", true},
+		{"git push origin +main", true},
+		{"git push --force-with-lease", false},
+		{"git push origin main", false},
+		{"git reset --hard HEAD~1", true},
+		{"git clean -fdx", true},
+		{"git reset HEAD~1", false},
+		{"chmod -R 777 /", true},
+		{"chown -R me /", true},
+		{"chmod 644 file", false},
+		{":(){ :|:& };:", true},
+		{"sudo reboot", true},
+		{"echo reboot", false},
+		{"psql -c 'DROP TABLE users'", true},
+		{"terraform destroy", true},
+		{"kubectl delete pod web", true},
+		{"kubectl get pods", false},
+		{"ls -la", false},
+	}
+	for _, tt := range tests {
+		if got := commandDangers(nil, tt.command); (len(got) > 0) != tt.dangerous {
+			t.Errorf("commandDangers(%q) = %q, want dangerous %v", tt.command, got, tt.dangerous)
+		}
+	}
+}
+
+func TestDangerPatterns(t *testing.T) {
+	t.Chdir(t.TempDir()) // The broken pattern is logged to debug.log
+	cfg := &Config{DangerPatterns: []DangerRule{
+		{Pattern: `\bnpm\s+publish\b`, Reason: "publishes a package"},
+		{Pattern: `\bprod\b`},
+		{Pattern: `(`},
+	}}
+
+	checks, err := dangerChecks(cfg)
+	if err == nil {
+		t.Error("dangerChecks() with a broken pattern: want an error")
+	}
+	if want := len(defaultDangerRules) + 2; len(checks) != want {
+		t.Errorf("dangerChecks() = %d checks, want %d", len(checks), want)
+	}
+
+	tests := []struct {
+		command string
+		reasons []string
+	}{
+		{"npm publish", []string{"publishes a package"}},
+		{"deploy prod", []string{`matches \bprod\b`}},
+		{"npm test", nil},
+	}
+	for _, tt := range tests {
+		got := commandDangers(cfg, tt.command)
+		if len(got) != len(tt.reasons) || len(got) > 0 && got[0] != tt.reasons[0] {
+			t.Errorf("commandDangers(%q) = %q, want %q", tt.command, got, tt.reasons)
+		}
+	}
+}
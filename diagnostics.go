@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diagnostic is a problem found in the justfile that just itself may not
+// complain about, but that makes the recipe list misleading.
+type diagnostic struct {
+	Kind    string
+	Message string
+}
+
+// Msg with the result of analyzing the loaded recipes
+type diagnosticsMsg []diagnostic
+
+// checkDiagnostics analyzes the dump and the justfile sources.
+func checkDiagnostics(recipes map[string]Recipe, aliases map[string]Alias) tea.Cmd {
+	return func() tea.Msg {
+		diags := analyzeDump(recipes, aliases)
+		if docs, err := loadJustfileTree(); err == nil {
+			diags = append(diags, analyzeJustfiles(docs)...)
+		}
+		return diagnosticsMsg(diags)
+	}
+}
+
+// analyzeDump looks for aliases shadowing recipes and dependencies on
+// recipes that don't exist.
+func analyzeDump(recipes map[string]Recipe, aliases map[string]Alias) []diagnostic {
+	var diags []diagnostic
+
+	for name, alias := range aliases {
+		if _, ok := recipes[name]; ok {
+			diags = append(diags, diagnostic{
+				Kind:    "shadowed",
+				Message: fmt.Sprintf("alias %q (→ %s) has the same name as a recipe", name, alias.Target),
+			})
+		}
+		if _, ok := recipes[alias.Target]; !ok {
+			diags = append(diags, diagnostic{
+				Kind:    "missing",
+				Message: fmt.Sprintf("alias %q points to missing recipe %q", name, alias.Target),
+			})
+		}
+	}
+
+	for name, r := range recipes {
+		for _, dep := range r.Dependencies {
+			if _, ok := recipes[dep.Recipe]; !ok {
+				diags = append(diags, diagnostic{
+					Kind:    "missing",
+					Message: fmt.Sprintf("recipe %q depends on missing recipe %q", name, dep.Recipe),
+				})
+			}
+		}
+	}
+
+	sort.Slice(diags, func(i, j int) bool { return diags[i].Message < diags[j].Message })
+	return diags
+}
+
+// analyzeJustfiles looks for recipes defined more than once across the
+// justfile and its imports; only one of them ends up in the dump.
+func analyzeJustfiles(docs []*justfileDoc) []diagnostic {
+	defined := map[string][]string{}
+	var names []string
+	for _, doc := range docs {
+		for i, line := range doc.lines {
+			if name, ok := recipeHeader(line); ok {
+				if defined[name] == nil {
+					names = append(names, name)
+				}
+				defined[name] = append(defined[name], fmt.Sprintf("%s:%d", doc.path, i+1))
+			}
+		}
+	}
+
+	var diags []diagnostic
+	for _, name := range names {
+		if places := defined[name]; len(places) > 1 {
+			diags = append(diags, diagnostic{
+				Kind:    "duplicate",
+				Message: fmt.Sprintf("recipe %q is defined %d times: %s", name, len(places), strings.Join(places, ", ")),
+			})
+		}
+	}
+	return diags
+}
+
+func (m model) diagnosticsView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Diagnostics"))
+	b.WriteString("\n\n")
+	if len(m.diagnostics) == 0 {
+		b.WriteString("No problems found.\n")
+	}
+	warn := lipgloss.NewStyle().Foreground(activeTheme.Accent)
+	for _, d := range m.diagnostics {
+		b.WriteString(warn.Render("⚠ " + d.Kind))
+		b.WriteString("  ")
+		b.WriteString(d.Message)
+		b.WriteString("\n")
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Width(m.terminalWidth - 4).Render(b.String())
+}
+
+// listTitle is the list title, naming the group filter and flagging
+// diagnostics when there are any. It counts the recipes, and while filtering
+// how many of them match.
+func (m model) listTitle() string {
+	title := "Just Tasks · " + m.recipeCount()
+	if m.groupFilter != "" {
+		title += " · " + m.groupFilter
+	}
+	if m.semanticQuery != "" {
+		title += fmt.Sprintf(" · ~%q", m.semanticQuery)
+	}
+	if m.partial {
+		title += " · loading details..."
+	}
+	if len(m.diagnostics) > 0 {
+		title += fmt.Sprintf(" ⚠ %d", len(m.diagnostics))
+	}
+	return title
+}
+
+// recipeCount is "87 recipes", or "12/87 recipes" while a filter is typed.
+// Recipes listed under several groups count once, those in collapsed groups
+// count too.
+func (m model) recipeCount() string {
+	count := func(items []list.Item) int {
+		seen := map[string]bool{}
+		for _, item := range items {
+			if r, ok := item.(recipeItem); ok {
+				seen[r.name] = true
+			}
+		}
+		return len(seen)
+	}
+	total := count(m.list.Items())
+	if m.semanticQuery == "" {
+		total = 0
+		for _, r := range m.recipes {
+			if m.listed(r) {
+				total++
+			}
+		}
+	}
+	if m.list.FilterState() != list.Unfiltered && m.list.FilterValue() != "" {
+		return fmt.Sprintf("%d/%d recipes", count(m.list.VisibleItems()), total)
+	}
+	return fmt.Sprintf("%d recipes", total)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/muesli/termenv"
+)
+
+// runDoctor prints diagnostics useful when something doesn't work or renders
+// oddly. It never fails, it just reports what it finds.
+func runDoctor(w io.Writer) {
+	fmt.Fprintln(w, "just-do-it doctor")
+	fmt.Fprintln(w)
+
+	// just
+	if out, err := exec.Command("just", "--version").Output(); err != nil {
+		fmt.Fprintf(w, "✗ just: not found (%v)\n", err)
+	} else {
+		fmt.Fprintf(w, "✓ just: %s\n", strings.TrimSpace(string(out)))
+	}
+
+	// Config
+	cfg, err := LoadConfig()
+	path, _ := GetConfigPath()
+	if err != nil {
+		fmt.Fprintf(w, "✗ config: %s (%v)\n", path, err)
+	} else {
+		fmt.Fprintf(w, "✓ config: %s\n", path)
+	}
+	if project := projectConfigPath(); cfg != nil && cfg.overrides != nil {
+		fmt.Fprintf(w, "  project config: %s\n", project)
+	}
+
+	// Justfile, along with the variables just reads from the environment
+	if path, err := rootJustfile(); err != nil {
+		fmt.Fprintf(w, "✗ justfile: %v\n", err)
+	} else {
+		fmt.Fprintf(w, "✓ justfile: %s\n", path)
+	}
+	if projects, err := loadWorkspace(cfg); err != nil {
+		fmt.Fprintf(w, "✗ %v\n", err)
+	} else {
+		for _, p := range projects {
+			fmt.Fprintf(w, "✓ workspace %s: %s\n", p.name, p.justfile)
+		}
+	}
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "JUST_") {
+			fmt.Fprintf(w, "  $%s\n", kv)
+		}
+	}
+
+	if !aiEnabled {
+		fmt.Fprintln(w, "  AI: not included in this build (-tags noai)")
+	} else if cfg != nil {
+		for _, p := range aiProviders {
+			switch {
+			case p.KeyEnv == "":
+			case os.Getenv(p.KeyEnv) != "":
+				fmt.Fprintf(w, "✓ %s key: $%s\n", p.Label, p.KeyEnv)
+			case cfg.fileAPIKey(p) != "":
+				fmt.Fprintf(w, "  %s key: plaintext in the config file\n", p.Label)
+			case cfg.apiKey(p) != "":
+				fmt.Fprintf(w, "✓ %s key: OS keychain\n", p.Label)
+			}
+		}
+	}
+
+	if wrapper := execWrapper(cfg, projectDir()); wrapper != "" && wrapper != "none" {
+		fmt.Fprintf(w, "✓ environment: recipes run through %s\n", wrapper)
+	} else if t, ok := detectEnvTool(cfg); ok {
+		fmt.Fprintf(w, "✓ environment: recipes run through %s\n", t.name)
+	} else {
+		fmt.Fprintln(w, "  environment: none")
+	}
+
+	if cfg != nil && cfg.LoginShell {
+		if shell := os.Getenv("SHELL"); shell != "" {
+			fmt.Fprintf(w, "✓ login shell: recipes run through %s -l\n", shell)
+		} else {
+			fmt.Fprintln(w, "✗ login shell: $SHELL is not set, recipes run directly")
+		}
+	} else {
+		printEnvDiff(w)
+	}
+
+	if _, err := loadKeyMap(cfg); err != nil {
+		fmt.Fprintf(w, "✗ %v\n", strings.ReplaceAll(err.Error(), "\n", "\n✗ "))
+	}
+	if _, err := dangerChecks(cfg); err != nil {
+		fmt.Fprintf(w, "✗ %v\n", strings.ReplaceAll(err.Error(), "\n", "\n✗ "))
+	}
+
+	// Terminal
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  TERM: %s\n", os.Getenv("TERM"))
+	fmt.Fprintf(w, "  COLORTERM: %s\n", os.Getenv("COLORTERM"))
+	fmt.Fprintf(w, "  color profile: %s (detected: %s)\n",
+		profileName(detectColorProfile(cfg)), profileName(termenv.NewOutput(os.Stdout).EnvColorProfile()))
+
+	bg := detectBackground(cfg)
+	color := bg.Color
+	if color == "" {
+		color = "unknown"
+	}
+	fmt.Fprintf(w, "  background: %s (via %s)\n", color, bg.Source)
+	if t, err := loadTheme(cfg, bg); err != nil {
+		fmt.Fprintf(w, "✗ theme: %v\n", strings.ReplaceAll(err.Error(), "\n", "\n✗ "))
+	} else {
+		fmt.Fprintf(w, "  theme: %s\n", t.Name)
+	}
+}
+
+func profileName(p termenv.Profile) string {
+	switch p {
+	case termenv.TrueColor:
+		return "truecolor"
+	case termenv.ANSI256:
+		return "ansi256"
+	case termenv.ANSI:
+		return "ansi"
+	default:
+		return "ascii"
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Msg to leave the TUI and hand over to the command
+type execMsg []string
+
+// runCommand leaves the TUI to run the command, first asking for
+// confirmation if the recipe's enter action is "confirm".
+func (m *model) runCommand(command []string) tea.Cmd {
+	cfg, _ := LoadConfig()
+	// Edited and AI commands aren't recipes, only the global setting applies
+	recipe := ""
+	if m.selectedRecipe != nil && !m.editsCommand() {
+		recipe = m.selectedRecipe.Name
+		if guard := guardRecipes(m.recipes, []string{recipe}, func() tea.Msg { return execMsg(command) }); guard != nil {
+			return guard
+		}
+	}
+	if cfg == nil || cfg.enterActionFor(recipe) != enterConfirm {
+		m.finalCmd = command
+		return tea.Quit
+	}
+	dryRun := cfg.DryRunPreview
+	var selected *Recipe
+	if recipe != "" {
+		selected = m.selectedRecipe
+	}
+	return func() tea.Msg {
+		return confirmMsg{
+			title: "Run this?",
+			body:  runPreview(command, selected, dryRun),
+			onYes: func() tea.Msg { return execMsg(command) },
+		}
+	}
+}
+
+// runPreview shows the exact command line and, for recipes, the values of
+// the variables they interpolate and what `just --dry-run` says they would
+// run.
+func runPreview(command []string, recipe *Recipe, dryRun bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "$ %s\n", shellJoin(command))
+	if recipe != nil {
+		if vars := evaluatedVariables(*recipe, setFlags(command)); vars != "" {
+			b.WriteString("\n")
+			b.WriteString(vars)
+		}
+	}
+	if !dryRun || command[0] != "just" {
+		return b.String()
+	}
+
+	args := append([]string{"--color", "always", "--dry-run"}, command[1:]...)
+	out, err := justCommand(args...).CombinedOutput()
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("just --dry-run:"))
+	b.WriteString("\n")
+	b.Write(out)
+	if err != nil && len(out) == 0 {
+		fmt.Fprintf(&b, "%v\n", err)
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+// How long successful in-app runs of each recipe took is kept per project.
+// The list shows the typical duration next to recipes, and the output pane
+// a progress bar against it while one runs.
+
+const maxDurations = 10 // Kept per recipe, the oldest are dropped
+
+// durationLog holds the latest durations by recipe, newest last. It is
+// shared by the model and the list items, as a map.
+type durationLog map[string][]time.Duration
+
+func durationLogPath() (string, error) {
+	h := sha256.Sum256([]byte(projectDir()))
+	return xdg.DataFile(filepath.Join("just-do-it", "durations", hex.EncodeToString(h[:])[:16]+".json"))
+}
+
+func loadDurations() durationLog {
+	path, err := durationLogPath()
+	if err != nil {
+		return durationLog{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return durationLog{}
+	}
+	log := durationLog{}
+	if err := json.Unmarshal(data, &log); err != nil {
+		logDebug("Ignoring broken durations file %s: %v", path, err)
+		return durationLog{}
+	}
+	return log
+}
+
+// record adds a run's duration and saves the log.
+func (l durationLog) record(recipe string, d time.Duration) error {
+	runs := append(l[recipe], d)
+	l[recipe] = runs[max(len(runs)-maxDurations, 0):]
+
+	path, err := durationLogPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// estimate is the median of the recipe's recorded durations, 0 when it
+// hasn't been run yet. The median keeps one slow run from skewing it.
+func (l durationLog) estimate(recipe string) time.Duration {
+	runs := slices.Clone(l[recipe])
+	if len(runs) == 0 {
+		return 0
+	}
+	slices.Sort(runs)
+	return runs[len(runs)/2]
+}
+
+// formatEstimate renders a duration roughly, e.g. "~45s" or "~2m".
+func formatEstimate(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("~%ds", max(int(d.Round(time.Second).Seconds()), 1))
+	case d < time.Hour:
+		return fmt.Sprintf("~%dm", int(d.Round(time.Minute).Minutes()))
+	}
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("~%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// progressBar renders how far a run is into its estimate. Runs past it
+// stay at the end of the bar.
+func progressBar(elapsed, estimate time.Duration, width int) string {
+	done := min(float64(elapsed)/float64(estimate), 1)
+	filled := int(done * float64(width))
+	return fmt.Sprintf("%s%s %d%% of %s", strings.Repeat("█", filled), strings.Repeat("░", width-filled), int(done*100), formatEstimate(estimate))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const justManualURL = "https://just.systems/man/en/"
+
+// recipeTemplate is a set of starter recipes for a kind of project.
+type recipeTemplate struct {
+	marker  string // File in the project root that picks the template
+	project string
+	recipes []starterRecipe
+}
+
+type starterRecipe struct {
+	name, doc, body string
+}
+
+// recipeTemplates are tried in order, the last one fits any project.
+var recipeTemplates = []recipeTemplate{
+	{"go.mod", "Go", []starterRecipe{
+		{"build", "Build all packages", "go build ./..."},
+		{"test", "Run the tests", "go test ./..."},
+		{"lint", "Report suspicious code", "go vet ./..."},
+	}},
+	{"Cargo.toml", "Rust", []starterRecipe{
+		{"build", "Build the crate", "cargo build"},
+		{"test", "Run the tests", "cargo test"},
+		{"lint", "Run clippy", "cargo clippy"},
+	}},
+	{"package.json", "JavaScript", []starterRecipe{
+		{"install", "Install dependencies", "npm install"},
+		{"build", "Build the project", "npm run build"},
+		{"test", "Run the tests", "npm test"},
+	}},
+	{"pyproject.toml", "Python", []starterRecipe{
+		{"test", "Run the tests", "python -m pytest"},
+	}},
+	{"", "", []starterRecipe{
+		{"hello", "Say hello, replace with something useful", `echo "Hello from just"`},
+	}},
+}
+
+// projectTemplate picks the template for the project in dir.
+func projectTemplate(dir string) recipeTemplate {
+	for _, t := range recipeTemplates {
+		if t.marker == "" {
+			return t
+		}
+		if _, err := os.Stat(filepath.Join(dir, t.marker)); err == nil {
+			return t
+		}
+	}
+	return recipeTemplates[len(recipeTemplates)-1]
+}
+
+// addStarterRecipes appends the project's starter recipes that the justfile
+// doesn't have yet.
+func addStarterRecipes(recipes map[string]Recipe) tea.Cmd {
+	return func() tea.Msg {
+		path, err := rootJustfile()
+		if err != nil {
+			return err
+		}
+		d, err := readJustfile(path)
+		if err != nil {
+			return err
+		}
+
+		var added []string
+		for _, r := range projectTemplate(filepath.Dir(path)).recipes {
+			if _, ok := recipes[r.name]; ok {
+				continue
+			}
+			if n := len(d.lines); n > 0 && d.lines[n-1] == "" {
+				d.lines = d.lines[:n-1]
+			}
+			if len(d.lines) > 0 {
+				d.lines = append(d.lines, "")
+			}
+			d.lines = append(d.lines, "# "+r.doc, r.name+":", "    "+r.body, "")
+			added = append(added, r.name)
+		}
+		if len(added) == 0 {
+			return statusMsg("The justfile already has the starter recipes")
+		}
+		if err := d.Save(); err != nil {
+			return err
+		}
+		return afterJustfileWrite()
+	}
+}
+
+// listIsEmpty reports whether the list shows nothing but the AI item.
+func (m model) listIsEmpty() bool {
+	for _, item := range m.list.VisibleItems() {
+		if _, ok := item.(aiItem); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// emptyStateView takes the place of the preview when there is nothing to
+// list, with what can be done about it.
+func (m model) emptyStateView() string {
+	k := m.keys
+	var title string
+	var actions [][2]string
+	switch {
+	case m.list.FilterValue() != "":
+		title = fmt.Sprintf("No recipes match %q.", m.list.FilterValue())
+		actions = append(actions, [2]string{k.Cancel.Help().Key, "clear the filter"})
+		if m.aiPlacement != aiItemHidden {
+			actions = append(actions, [2]string{k.Select.Help().Key, fmt.Sprintf("generate a command for %q with AI", m.list.FilterValue())})
+		}
+		actions = append(actions, [2]string{leaderKeys(k, k.Semantic), "search by what the recipe does instead"})
+	case m.groupFilter != "":
+		title = fmt.Sprintf("No recipes in the %s group.", m.groupFilter)
+		actions = append(actions, [2]string{leaderKeys(k, k.GroupFilter), "show the next group"})
+	default:
+		title = "This justfile has no recipes yet."
+		t := projectTemplate(projectDir())
+		starter := "add a starter recipe"
+		if t.project != "" {
+			starter = "add starter recipes for this " + t.project + " project"
+		}
+		actions = append(actions, [2]string{leaderKeys(k, k.NewRecipe), starter})
+		if m.aiPlacement != aiItemHidden {
+			actions = append(actions, [2]string{"type", "describe a task, then " + k.Select.Help().Key + " to generate a command with AI"})
+		}
+	}
+
+	width := 0
+	for _, a := range actions {
+		width = max(width, lipgloss.Width(a[0]))
+	}
+	accent := lipgloss.NewStyle().Foreground(activeTheme.Accent)
+	var b strings.Builder
+	b.WriteString(title + "\n\n")
+	for _, a := range actions {
+		fmt.Fprintf(&b, "%s  %s\n", accent.Render(fmt.Sprintf("%-*s", width, a[0])), a[1])
+	}
+	b.WriteString("\n" + helpStyle.Render("just manual: "+justManualURL))
+	return lipgloss.NewStyle().Width(m.viewport.Width).Render(b.String())
+}
+
+// leaderKeys is how a leader binding is typed, e.g. "ctrl+x n".
+func leaderKeys(k keyMap, b key.Binding) string {
+	return k.Leader.Help().Key + " " + b.Help().Key
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// What enter does on a recipe without parameters. Recipes with parameters
+// always open the parameter form first.
+const (
+	enterRun     = "run"          // Run it straight away
+	enterConfirm = "confirm"      // Show the command line for confirmation
+	enterEdit    = "edit-command" // Open the command line for editing
+)
+
+// editCommandName is the form shown for enterEdit, like "AI Command" it holds
+// a whole command line rather than parameters.
+const editCommandName = "Edit Command"
+
+// EnterRule sets the enter action for the recipes matching Pattern.
+type EnterRule struct {
+	// Pattern is a glob matched against the recipe name, e.g. "deploy*".
+	Pattern string `json:"pattern"`
+
+	// Action is "run", "confirm" or "edit-command".
+	Action string `json:"action"`
+}
+
+// enterActionFor returns what enter does for the recipe: the first matching
+// rule, then the global setting. An empty recipe only gets the global one.
+func (c *Config) enterActionFor(recipe string) string {
+	if recipe != "" {
+		for _, r := range c.EnterActions {
+			if ok, _ := path.Match(r.Pattern, recipe); ok && validEnterAction(r.Action) {
+				return r.Action
+			}
+		}
+	}
+	if validEnterAction(c.EnterAction) {
+		return c.EnterAction
+	}
+	if c.ConfirmRun {
+		return enterConfirm
+	}
+	return enterRun
+}
+
+func validEnterAction(action string) bool {
+	return action == enterRun || action == enterConfirm || action == enterEdit
+}
+
+// startCommandEdit opens the command line in the form, run with the shell
+// once confirmed with enter.
+func (m *model) startCommandEdit(command []string) tea.Cmd {
+	m.selectedRecipe = &Recipe{
+		Name:       editCommandName,
+		Parameters: []Parameter{{Name: "command"}},
+	}
+	m.state = viewInput
+	t := textinput.New()
+	t.Prompt = "Run: "
+	t.Width = m.terminalWidth - 10
+	t.SetValue(shellJoin(command))
+	t.Focus()
+	m.inputs = []textinput.Model{t}
+	m.inputSources = nil
+	m.choices = paramChoices{}
+	m.focusIndex = 0
+	return textinput.Blink
+}
+
+// editsCommand reports whether the form holds a whole command line, which
+// runs with the shell, rather than recipe parameters.
+func (m model) editsCommand() bool {
+	return m.selectedRecipe != nil && (m.selectedRecipe.Name == "AI Command" || m.selectedRecipe.Name == editCommandName)
+}
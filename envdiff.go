@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The usual reason a recipe works in a terminal but not from here is an
+// environment that differs from the one a login shell sets up, typically a
+// PATH without the version manager shims a profile adds.
+
+// volatileEnv differ between any two shells and aren't worth reporting.
+var volatileEnv = map[string]bool{
+	"_": true, "SHLVL": true, "PWD": true, "OLDPWD": true, "TERM": true, "COLUMNS": true, "LINES": true,
+	"TERM_PROGRAM": true, "TERM_PROGRAM_VERSION": true, "TERM_SESSION_ID": true, "WINDOWID": true,
+	"TMUX": true, "TMUX_PANE": true, "STY": true, "SSH_TTY": true, "GPG_TTY": true, "PS1": true,
+}
+
+// loginShellEnv returns the environment a fresh login shell ends up with.
+func loginShellEnv(shell string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, shell, "-l", "-c", "env").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseEnv(strings.Split(string(out), "\n")), nil
+}
+
+// parseEnv turns NAME=value lines into a map, lines without a name
+// continuing the previous value.
+func parseEnv(lines []string) map[string]string {
+	env := map[string]string{}
+	last := ""
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			if last != "" && line != "" {
+				env[last] += "\n" + line
+			}
+			continue
+		}
+		env[name] = value
+		last = name
+	}
+	return env
+}
+
+// printEnvDiff reports how this process's environment differs from a login
+// shell's: PATH entries it is missing, and variables missing or different.
+func printEnvDiff(w io.Writer) {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		fmt.Fprintln(w, "  login shell environment: $SHELL is not set, nothing to compare")
+		return
+	}
+	login, err := loginShellEnv(shell)
+	if err != nil {
+		fmt.Fprintf(w, "✗ login shell environment: running %s -l failed (%v)\n", shell, err)
+		return
+	}
+	current := parseEnv(os.Environ())
+
+	var missingPath []string
+	have := map[string]bool{}
+	for _, dir := range filepath.SplitList(current["PATH"]) {
+		have[dir] = true
+	}
+	for _, dir := range filepath.SplitList(login["PATH"]) {
+		if !have[dir] {
+			missingPath = append(missingPath, dir)
+		}
+	}
+
+	var missing, differ []string
+	for name, value := range login {
+		if volatileEnv[name] || name == "PATH" {
+			continue
+		}
+		if v, ok := current[name]; !ok {
+			missing = append(missing, name)
+		} else if v != value {
+			differ = append(differ, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(differ)
+
+	if len(missingPath)+len(missing)+len(differ) == 0 {
+		fmt.Fprintf(w, "✓ login shell environment: same as %s -l\n", shell)
+		return
+	}
+	fmt.Fprintf(w, "  login shell environment: differs from %s -l", shell)
+	fmt.Fprintln(w, ", which can make recipes fail here that work in a terminal")
+	for _, dir := range missingPath {
+		fmt.Fprintf(w, "✗   PATH is missing %s\n", dir)
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(w, "    not set here: %s\n", strings.Join(missing, ", "))
+	}
+	if len(differ) > 0 {
+		fmt.Fprintf(w, "    set differently here: %s\n", strings.Join(differ, ", "))
+	}
+	if len(missingPath) > 0 {
+		fmt.Fprintln(w, `    set "login_shell": true in the config to run recipes through a login shell`)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The env editor lists what a recipe takes from its environment, exported
+// justfile variables and the variables it reads, and overrides them for a
+// single run without editing the justfile.
+
+var (
+	envCall      = regexp.MustCompile(`\benv(?:_var|_var_or_default)?\(\s*["']([^"']+)["']`)
+	shellEnvRef  = regexp.MustCompile(`\$\{?([A-Z][A-Z0-9_]*)`)
+	shellEnvSet  = regexp.MustCompile(`(?:^|[\s;])(?:export\s+)?([A-Z][A-Z0-9_]*)=`)
+	commonEnvVar = map[string]bool{"PATH": true, "HOME": true, "USER": true, "SHELL": true, "PWD": true, "OLDPWD": true, "TMPDIR": true}
+)
+
+// envVar is a variable the recipe gets from its environment.
+type envVar struct {
+	name   string
+	source string // What asks for it
+	just   bool   // An exported justfile variable, overridden with --set
+}
+
+type envEditor struct {
+	recipe string
+	vars   []envVar
+	inputs []textinput.Model
+	focus  int
+}
+
+// runEnv holds the env editor's overrides for the next run of recipe, or
+// the directory picked for it.
+type runEnv struct {
+	recipe string
+	env    []string // NAME=value, added to the environment
+	set    []string // --set arguments for exported justfile variables
+	dir    string   // Working directory, empty for just's own
+}
+
+// recipeEnvVars finds the exported justfile variables and the environment
+// variables the recipe reads, either in its body or through env_var() in the
+// justfile's assignments, which are evaluated on every run.
+func recipeEnvVars(recipe Recipe) ([]envVar, error) {
+	dump, err := getJustDump()
+	if err != nil {
+		return nil, err
+	}
+	source, err := recipeSource(recipe.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var vars []envVar
+	seen := map[string]bool{}
+	for _, p := range recipe.Parameters {
+		seen[p.Name] = true // Exported parameters are asked for in the form
+	}
+	var names []string
+	for name := range dump.Assignments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if dump.Assignments[name].Export || dump.Settings.Export {
+			seen[name] = true
+			vars = append(vars, envVar{name: name, source: "export", just: true})
+		}
+	}
+
+	add := func(name, from string) {
+		if !seen[name] && !commonEnvVar[name] {
+			seen[name] = true
+			vars = append(vars, envVar{name: name, source: from})
+		}
+	}
+	if docs, err := loadJustfileTree(); err == nil {
+		for _, d := range docs {
+			for _, line := range d.lines {
+				if isIndented(line) {
+					continue
+				}
+				for _, match := range envCall.FindAllStringSubmatch(line, -1) {
+					add(match[1], "env_var")
+				}
+			}
+		}
+	}
+	for _, match := range envCall.FindAllStringSubmatch(string(source), -1) {
+		add(match[1], "env_var")
+	}
+	// Variables the recipe sets itself aren't taken from the environment
+	for _, match := range shellEnvSet.FindAllStringSubmatch(string(source), -1) {
+		seen[match[1]] = true
+	}
+	for _, match := range shellEnvRef.FindAllStringSubmatch(string(source), -1) {
+		add(match[1], "$"+match[1])
+	}
+	return vars, nil
+}
+
+// startEnvEditor opens the env editor for the selected recipe.
+func (m *model) startEnvEditor() tea.Cmd {
+	i, ok := m.list.SelectedItem().(recipeItem)
+	if !ok {
+		return nil
+	}
+	vars, err := recipeEnvVars(m.recipes[i.name])
+	if err != nil {
+		m.err = fmt.Errorf("reading %s: %v", i.name, err)
+		return nil
+	}
+	if len(vars) == 0 {
+		return func() tea.Msg { return statusMsg(i.name + " doesn't read any environment variables") }
+	}
+
+	width := 0
+	for _, v := range vars {
+		width = max(width, len(v.name))
+	}
+	e := &envEditor{recipe: i.name, vars: vars}
+	for i, v := range vars {
+		t := textinput.New()
+		t.Prompt = fmt.Sprintf("%-*s = ", width, v.name)
+		t.Width = min(m.terminalWidth-width-30, 60)
+		switch current, set := os.LookupEnv(v.name); {
+		case v.just:
+			t.Placeholder = "(justfile value)"
+		case set:
+			t.Placeholder = current
+		default:
+			t.Placeholder = "(unset)"
+		}
+		if i == 0 {
+			t.Focus()
+		}
+		e.inputs = append(e.inputs, t)
+	}
+	m.envEdit = e
+	m.state = viewEnvEditor
+	return textinput.Blink
+}
+
+// overrides collects the values typed in the editor, empty ones are left
+// alone.
+func (e *envEditor) overrides() *runEnv {
+	r := &runEnv{recipe: e.recipe}
+	for i, v := range e.vars {
+		value := e.inputs[i].Value()
+		switch {
+		case value == "":
+		case v.just:
+			r.set = append(r.set, "--set", v.name, value)
+		default:
+			r.env = append(r.env, v.name+"="+value)
+		}
+	}
+	return r
+}
+
+// recipeCommand is justRecipeCommand with the session's variable overrides
+// and the env editor's and directory picked, when they are for this recipe.
+// Recipes with [confirm] get --yes, guardRecipes has asked already.
+func (m model) recipeCommand(name string) []string {
+	command := justRecipeCommand(name)
+	if dir := m.recipeDir(name); dir != "" {
+		justfile, _ := currentJustfile()
+		command = withWorkingDir(command, justfile, dir)
+	}
+	var set []string
+	if len(confirmPrompts(m.recipes, name)) > 0 {
+		set = append(set, "--yes")
+	}
+	set = append(set, m.setArgs()...)
+	if m.runEnv != nil && m.runEnv.recipe == name {
+		set = append(set, m.runEnv.set...)
+	}
+	if len(set) == 0 {
+		return command
+	}
+	return append(append([]string{command[0]}, set...), command[1:]...)
+}
+
+// recipeEnv is the environment overrides for running recipe.
+func (m model) recipeEnv(recipe string) []string {
+	if m.runEnv == nil || m.runEnv.recipe != recipe {
+		return nil
+	}
+	return m.runEnv.env
+}
+
+// setEnv adds NAME=value pairs to this process's environment, which the
+// command it's replaced with inherits.
+func setEnv(env []string) {
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		os.Setenv(name, value)
+	}
+}
+
+func (m model) updateEnvEditor(msg tea.KeyMsg) (model, tea.Cmd) {
+	e := m.envEdit
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewList
+		m.envEdit = nil
+		return m, nil
+	case key.Matches(msg, m.keys.NextField, m.keys.PrevField):
+		e.inputs[e.focus].Blur()
+		if key.Matches(msg, m.keys.NextField) {
+			e.focus = (e.focus + 1) % len(e.inputs)
+		} else {
+			e.focus = (e.focus + len(e.inputs) - 1) % len(e.inputs)
+		}
+		e.inputs[e.focus].Focus()
+		return m, textinput.Blink
+	case key.Matches(msg, m.keys.Select):
+		m.runEnv = e.overrides()
+		m.envEdit = nil
+		m.state = viewList
+		return m, m.selectRecipe(e.recipe)
+	}
+	var cmd tea.Cmd
+	e.inputs[e.focus], cmd = e.inputs[e.focus].Update(msg)
+	return m, cmd
+}
+
+func (m model) envEditorView() string {
+	e := m.envEdit
+	var rows []string
+	for i, v := range e.vars {
+		rows = append(rows, e.inputs[i].View()+"  "+helpStyle.Render(v.source))
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("Environment: "+e.recipe),
+		"",
+		helpStyle.Render("Overrides apply to this run only, empty fields keep their value."),
+		"",
+		strings.Join(rows, "\n"),
+	))
+}
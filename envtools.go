@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// envTool is a per-project environment manager recipes can be run through,
+// so they see the same variables and tool versions as an interactive shell
+// in the project would.
+type envTool struct {
+	name    string
+	markers []string // Files that mean the project uses the tool
+	prefix  func(dir string) []string
+}
+
+var envTools = []envTool{
+	{
+		name:    "direnv",
+		markers: []string{".envrc"},
+		prefix:  func(dir string) []string { return []string{"direnv", "exec", dir} },
+	},
+	{
+		name:    "mise",
+		markers: []string{"mise.toml", ".mise.toml", ".tool-versions"},
+		prefix:  func(dir string) []string { return []string{"mise", "exec", "--"} },
+	},
+}
+
+// projectDir is the directory recipes run in: the one given with
+// --working-directory, else the one holding the justfile, or the working
+// directory if there is none.
+func projectDir() string {
+	if justFlags.workingDir != "" {
+		return justFlags.workingDir
+	}
+	if path, err := rootJustfile(); err == nil {
+		return filepath.Dir(path)
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		return cwd
+	}
+	return "."
+}
+
+// detectEnvTool picks the environment tool to run commands through. The
+// config can name one, or "none" to turn this off; by default the first
+// installed tool with a marker file in the project is used.
+func detectEnvTool(cfg *Config) (envTool, bool) {
+	want := ""
+	if cfg != nil {
+		want = cfg.Environment
+	}
+	if want == "none" {
+		return envTool{}, false
+	}
+
+	dir := projectDir()
+	for _, t := range envTools {
+		if want != "" && want != "auto" && want != t.name {
+			continue
+		}
+		if _, err := exec.LookPath(t.name); err != nil {
+			continue
+		}
+		if want == t.name {
+			return t, true
+		}
+		for _, marker := range t.markers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return t, true
+			}
+		}
+	}
+	return envTool{}, false
+}
+
+// wrapperPresets are execution wrappers for common dev environment tools.
+// "{dir}" is the project directory and "{cmd}" the command; without "{cmd}"
+// the command is appended.
+var wrapperPresets = map[string]string{
+	"nix":          "nix develop {dir} --command {cmd}",
+	"devbox":       "devbox run --config {dir} -- {cmd}",
+	"devcontainer": "devcontainer exec --workspace-folder {dir} {cmd}",
+}
+
+// wrapperNames is the order `ctrl+x w` cycles through, "" being no wrapper.
+var wrapperNames = []string{"", "nix", "devbox", "devcontainer"}
+
+// execWrapper returns the wrapper template for the project: its entry in
+// exec_wrappers, else the global exec_wrapper. Preset names are expanded.
+func execWrapper(cfg *Config, dir string) string {
+	if cfg == nil {
+		return ""
+	}
+	wrapper := cfg.ExecWrapper
+	if w, ok := cfg.ExecWrappers[dir]; ok {
+		wrapper = w
+	}
+	if preset, ok := wrapperPresets[wrapper]; ok {
+		return preset
+	}
+	return wrapper
+}
+
+// applyWrapper expands a wrapper template around the command.
+func applyWrapper(template, dir string, command []string) []string {
+	var out []string
+	placed := false
+	for _, field := range strings.Fields(template) {
+		if field == "{cmd}" {
+			out = append(out, command...)
+			placed = true
+			continue
+		}
+		out = append(out, strings.ReplaceAll(field, "{dir}", dir))
+	}
+	if !placed {
+		out = append(out, command...)
+	}
+	return out
+}
+
+// withEnvironment prefixes the command so it runs inside the project's
+// environment: through its execution wrapper if one is configured, which
+// then defines the environment on its own, otherwise through direnv or mise
+// if the project has them set up. With login_shell all of it runs in a
+// login shell.
+func withEnvironment(command []string) []string {
+	cfg, _ := LoadConfig()
+	dir := projectDir()
+	if wrapper := execWrapper(cfg, dir); wrapper != "" && wrapper != "none" {
+		command = applyWrapper(wrapper, dir, command)
+	} else if t, ok := detectEnvTool(cfg); ok {
+		command = append(t.prefix(dir), command...)
+	}
+	if cfg != nil && cfg.LoginShell {
+		return withLoginShell(command)
+	}
+	return command
+}
+
+// withLoginShell runs the command through a fresh login shell, so the PATH
+// changes profiles make (rbenv, nvm and the like) apply. $SHELL picks the
+// shell, without it the command is left as is.
+func withLoginShell(command []string) []string {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		return command
+	}
+	line := "exec " + shellJoin(command)
+	switch filepath.Base(shell) {
+	case "pwsh":
+		return []string{shell, "-Login", "-Command", "& " + shellJoin(command)}
+	case "nu":
+		return []string{shell, "--login", "-c", shellJoin(command)}
+	case "fish":
+		return []string{shell, "--login", "-c", line}
+	}
+	return []string{shell, "-l", "-c", line}
+}
+
+// cycleWrapper switches this project to the next wrapper preset and saves
+// the choice.
+func cycleWrapper() tea.Msg {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	dir := projectDir()
+
+	current := cfg.ExecWrappers[dir]
+	next := wrapperNames[0]
+	if current == "none" {
+		current = ""
+	}
+	for i, name := range wrapperNames {
+		if name == current {
+			next = wrapperNames[(i+1)%len(wrapperNames)]
+		}
+	}
+
+	if cfg.ExecWrappers == nil {
+		cfg.ExecWrappers = make(map[string]string)
+	}
+	if next == "" {
+		// Back to no wrapper, even if a global one is set
+		cfg.ExecWrappers[dir] = "none"
+	} else {
+		cfg.ExecWrappers[dir] = next
+	}
+	if err := SaveConfig(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %v", err)
+	}
+	if next == "" {
+		return statusMsg("Recipes run without a wrapper")
+	}
+	return statusMsg("Recipes run through " + next + ": " + wrapperPresets[next])
+}
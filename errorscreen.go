@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Errors are shown on their own screen until a key is pressed. When a
+// subprocess failed, the screen shows the command and what it wrote to
+// stderr, along with fixes for problems that are easy to recognize. r tries
+// again where that makes sense, y copies the error for an issue.
+
+// commandError is a subprocess that failed, with what it wrote to stderr.
+type commandError struct {
+	command []string
+	stderr  string
+	err     error
+}
+
+func (e *commandError) Error() string {
+	return fmt.Sprintf("%s: %v", strings.Join(e.command, " "), e.err)
+}
+
+func (e *commandError) Unwrap() error { return e.err }
+
+// newCommandError wraps the error of running the command, keeping the
+// stderr exec.Cmd.Output captured.
+func newCommandError(command []string, err error) error {
+	e := &commandError{command: command, err: err}
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		e.stderr = strings.TrimSpace(string(exit.Stderr))
+	}
+	return e
+}
+
+// retryable is an error that can be retried from the error screen.
+type retryable struct {
+	error
+	retry func(*model) tea.Cmd
+}
+
+func (r retryable) Unwrap() error { return r.error }
+
+// fail shows the error screen, with r running retry.
+func (m *model) fail(err error, retry func(*model) tea.Cmd) {
+	m.err = retryable{err, retry}
+	m.errNote = ""
+}
+
+// errorHints suggests fixes for errors that are easy to recognize.
+func errorHints(err error, keys keyMap) []string {
+	var hints []string
+	text := strings.ToLower(err.Error())
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) {
+		text += "\n" + strings.ToLower(cmdErr.stderr)
+	}
+	switch {
+	case errors.Is(err, exec.ErrNotFound) && cmdErr != nil && len(cmdErr.command) > 0 && cmdErr.command[0] == "just":
+		hints = append(hints, "just isn't installed or isn't on your PATH. Install it with your package manager (brew install just, cargo install just, apt install just, ...), see "+justManualURL+"packages.html")
+	case errors.Is(err, exec.ErrNotFound):
+		hints = append(hints, "The command isn't installed or isn't on your PATH.")
+	case strings.Contains(text, "no justfile found"):
+		hints = append(hints, "Run just-do-it from a project with a justfile, pass -f/--justfile, or create one with `just-do-it import`.")
+	case cmdErr != nil && len(cmdErr.command) > 0 && cmdErr.command[0] == "just" && strings.Contains(text, "error:"):
+		hints = append(hints, "just couldn't read the justfile. Fix the problem above and press "+keys.Retry.Help().Key+", it's also reloaded when saved.")
+	}
+	switch {
+	case strings.Contains(text, "401") || strings.Contains(text, "api key") || strings.Contains(text, "unauthorized"):
+		hints = append(hints, "The AI provider rejected the API key. Enter a new one in the AI settings ("+keys.AISettings.Help().Key+").")
+	case strings.Contains(text, "429") || strings.Contains(text, "rate limit") || strings.Contains(text, "quota"):
+		hints = append(hints, "The AI provider is rate limiting requests. Wait a moment and press "+keys.Retry.Help().Key+`, or set "fallback_providers" in the config.`)
+	case strings.Contains(text, "connection refused") || strings.Contains(text, "no such host") || strings.Contains(text, "deadline exceeded"):
+		hints = append(hints, "The server couldn't be reached. Check the network, or that a local server such as Ollama is running.")
+	}
+	return hints
+}
+
+// errorText is the error as plain text, for copying.
+func errorText(err error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Error: %v\n", err)
+	var cmdErr *commandError
+	if errors.As(err, &cmdErr) && cmdErr.stderr != "" {
+		fmt.Fprintf(&b, "\n%s\n", cmdErr.stderr)
+	}
+	return b.String()
+}
+
+func (m model) updateError(msg tea.KeyMsg) (model, tea.Cmd) {
+	var r retryable
+	canRetry := errors.As(m.err, &r)
+	switch {
+	case key.Matches(msg, m.keys.CopyError):
+		if err := copyToClipboard(errorText(m.err)); err != nil {
+			return m, m.showManualCopy(errorText(m.err), err)
+		}
+		m.errNote = "Copied to the clipboard"
+		return m, nil
+	case canRetry && key.Matches(msg, m.keys.Retry):
+		m.err, m.errNote = nil, ""
+		return m, r.retry(&m)
+	}
+	m.err, m.errNote = nil, ""
+	return m, nil
+}
+
+func (m model) errorView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Error"))
+	b.WriteString("\n\n")
+
+	width := max(m.terminalWidth-6, 20)
+	text := lipgloss.NewStyle().Width(width)
+	var cmdErr *commandError
+	if errors.As(m.err, &cmdErr) {
+		// The context the command ran in, without the command repeated
+		message := strings.TrimSuffix(strings.TrimSuffix(m.err.Error(), cmdErr.Error()), ": ")
+		if message != "" {
+			b.WriteString(text.Render(message) + "\n\n")
+		}
+		b.WriteString(helpStyle.Render("Command: ") + strings.Join(cmdErr.command, " ") + "\n")
+		b.WriteString(helpStyle.Render("Result:  ") + cmdErr.err.Error() + "\n")
+		if cmdErr.stderr != "" {
+			b.WriteString("\n" + lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(activeTheme.Border).
+				Padding(0, 1).
+				Width(width-2).
+				Render(cmdErr.stderr) + "\n")
+		}
+	} else {
+		b.WriteString(text.Render(m.err.Error()) + "\n")
+	}
+
+	if hints := errorHints(m.err, m.keys); len(hints) > 0 {
+		b.WriteString("\n" + helpStyle.Render("Suggestions:") + "\n")
+		for _, h := range hints {
+			b.WriteString(text.Render("• "+h) + "\n")
+		}
+	}
+	if m.errNote != "" {
+		b.WriteString("\n" + lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(m.errNote) + "\n")
+	}
+
+	k := m.keys
+	keys := []string{hint(k.CopyError)}
+	var r retryable
+	if errors.As(m.err, &r) {
+		keys = append([]string{hint(k.Retry)}, keys...)
+	}
+	keys = append(keys, "any other key: dismiss")
+	b.WriteString("\n" + helpStyle.Render(strings.Join(keys, " • ")))
+	return lipgloss.NewStyle().Margin(1, 2).Render(b.String())
+}
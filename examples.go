@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/adrg/xdg"
+)
+
+// AI commands the user ran are kept per project, and the ones for the most
+// similar earlier requests go into new prompts as examples, so generated
+// commands follow the project's tools and conventions.
+
+const (
+	maxExamples    = 50 // Kept per project, the oldest are dropped
+	promptExamples = 3  // Put into a prompt
+)
+
+// aiExample is an accepted generation: the request and the command that was
+// run for it, after any edits.
+type aiExample struct {
+	Request string    `json:"request"`
+	Command string    `json:"command"`
+	Shell   string    `json:"shell"`
+	At      time.Time `json:"at"`
+}
+
+func examplesPath() (string, error) {
+	h := sha256.Sum256([]byte(projectDir()))
+	return xdg.DataFile(filepath.Join("just-do-it", "examples", hex.EncodeToString(h[:])[:16]+".json"))
+}
+
+func loadExamples() []aiExample {
+	path, err := examplesPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var examples []aiExample
+	if err := json.Unmarshal(data, &examples); err != nil {
+		logDebug("Ignoring broken examples file %s: %v", path, err)
+		return nil
+	}
+	return examples
+}
+
+// rememberExample records an accepted generation, replacing an earlier one
+// for the same request.
+func rememberExample(ex aiExample) error {
+	examples := []aiExample{ex}
+	for _, old := range loadExamples() {
+		if old.Request != ex.Request || old.Shell != ex.Shell {
+			examples = append(examples, old)
+		}
+	}
+	if len(examples) > maxExamples {
+		examples = examples[:maxExamples]
+	}
+
+	path, err := examplesPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(examples, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// similarExamples returns the examples for the shell whose requests share
+// the most words with request, best first.
+func similarExamples(request, shell string) []aiExample {
+	words := requestWords(request)
+	type scored struct {
+		ex    aiExample
+		score float64
+	}
+	var candidates []scored
+	for _, ex := range loadExamples() {
+		if ex.Shell != shell {
+			continue
+		}
+		if s := wordOverlap(words, requestWords(ex.Request)); s > 0 {
+			candidates = append(candidates, scored{ex, s})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	var out []aiExample
+	for _, c := range candidates[:min(promptExamples, len(candidates))] {
+		out = append(out, c.ex)
+	}
+	return out
+}
+
+func requestWords(s string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		words[w] = true
+	}
+	return words
+}
+
+// wordOverlap is the Jaccard similarity of two word sets.
+func wordOverlap(a, b map[string]bool) float64 {
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	if union := len(a) + len(b) - shared; union > 0 {
+		return float64(shared) / float64(union)
+	}
+	return 0
+}
+
+// acceptGeneration remembers the AI command in the form as the answer to the
+// request it was generated for.
+func (m model) acceptGeneration() {
+	if m.selectedRecipe == nil || m.selectedRecipe.Name != "AI Command" || m.aiRequest == "" {
+		return
+	}
+	command := strings.TrimSpace(m.inputs[0].Value())
+	if command == "" {
+		return
+	}
+	ex := aiExample{Request: m.aiRequest, Command: command, Shell: *m.aiShell, At: time.Now()}
+	if err := rememberExample(ex); err != nil {
+		logDebug("Saving example failed: %v", err)
+	}
+}
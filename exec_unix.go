@@ -0,0 +1,28 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// replaceProcess replaces this process with the command, so it gets the
+// terminal, signals and exit status to itself. It only returns on failure.
+func replaceProcess(binary string, command []string) error {
+	return syscall.Exec(binary, command, os.Environ())
+}
+
+// killGroup runs the command in its own process group and has cancelling
+// it stop the whole group, so the processes a recipe starts under just are
+// stopped as well.
+func killGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM); err != syscall.ESRCH {
+			return err
+		}
+		return os.ErrProcessDone
+	}
+}
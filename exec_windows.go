@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// replaceProcess runs the command to completion and exits with its status,
+// Windows having no exec. Ctrl+C reaches every process on the console, so
+// this one ignores it and leaves the command to handle it.
+func replaceProcess(binary string, command []string) error {
+	cmd := exec.Command(binary, command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	signal.Ignore(os.Interrupt)
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
+
+// killGroup leaves cancelling the command to kill it alone, Windows having
+// no process groups to signal.
+func killGroup(cmd *exec.Cmd) {}
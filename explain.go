@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Explanations let the user check what a recipe or an AI command does
+// before running it, rather than trusting the name or the request.
+
+const explainPrompt = `Explain what this %s does, line by line, so someone can check it before running it.
+
+%s
+
+Answer with one line per line or part of it, formatted as "<part>: <what it does>".
+Point out anything destructive, irreversible or surprising.
+Plain text only, no markdown, no introduction.`
+
+// Msg with the explanation of a recipe or command
+type explainMsg struct {
+	recipe  string // Set when a recipe was explained
+	command string // Set when an AI command was explained
+	text    string
+	err     error
+}
+
+// explainRecipe asks the AI to explain the recipe's source.
+func explainRecipe(name string) tea.Cmd {
+	return func() tea.Msg {
+		source, err := recipeSource(name)
+		if err != nil {
+			return explainMsg{recipe: name, err: fmt.Errorf("reading the recipe: %v", err)}
+		}
+		text, err := complete(withMaxTokens(appContext(), explainMaxTokens), fmt.Sprintf(explainPrompt, "just recipe", strings.TrimSpace(string(source))), nil)
+		return explainMsg{recipe: name, text: text, err: err}
+	}
+}
+
+// explainCommand asks the AI to explain a command for the shell.
+func explainCommand(shell, command string) tea.Cmd {
+	return func() tea.Msg {
+		text, err := complete(withMaxTokens(appContext(), explainMaxTokens), fmt.Sprintf(explainPrompt, shell+" command", command), nil)
+		return explainMsg{command: command, text: text, err: err}
+	}
+}
+
+// startExplain explains the selected recipe in the preview, or the AI
+// command in the form.
+func (m *model) startExplain() tea.Cmd {
+	if err := checkProvider(); err != nil {
+		if errors.Is(err, errNoProvider) {
+			m.startProviderSetup()
+		} else {
+			m.err = err
+		}
+		return nil
+	}
+	if m.state == viewInput {
+		command := m.inputs[0].Value()
+		m.aiExplanation = []string{"Explaining..."}
+		return explainCommand(*m.aiShell, command)
+	}
+	i, ok := m.list.SelectedItem().(recipeItem)
+	if !ok {
+		return nil
+	}
+	m.setPreview(helpStyle.Render("Explaining " + i.name + "..."))
+	return explainRecipe(i.name)
+}
+
+// handleExplain shows the explanation, unless the user moved on meanwhile.
+// One cut off at the token limit is shown with a notice saying so.
+func (m *model) handleExplain(msg explainMsg) {
+	text := strings.TrimSpace(msg.text)
+	switch {
+	case errors.Is(msg.err, errTruncated) && text != "":
+		text += "\n" + helpStyle.Render(fmt.Sprintf("[truncated at %d tokens, the rest isn't explained]", explainMaxTokens))
+	case msg.err != nil:
+		text = "Could not explain: " + msg.err.Error()
+	}
+	if msg.recipe != "" {
+		if i, ok := m.list.SelectedItem().(recipeItem); ok && m.state == viewList && i.name == msg.recipe {
+			m.setPreview(titleStyle.Render("Explanation") + "\n\n" + text + "\n")
+		}
+		return
+	}
+	if m.isAICommand() && m.inputs[0].Value() == msg.command {
+		m.aiExplanation = strings.Split(text, "\n")
+	}
+}
+
+// isAICommand reports whether the form shows a generated command.
+func (m model) isAICommand() bool {
+	return m.state == viewInput && m.selectedRecipe != nil && m.selectedRecipe.Name == "AI Command"
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// When the provider fails, for example on a rate limit or an exhausted
+// quota, the request is retried with the fallback providers in the order
+// the config lists them. A provider that fails after it started streaming
+// isn't replaced, its partial answer has been shown already.
+
+type servedByKey struct{}
+
+// servedBy is told which provider answered a request that had to fall back,
+// and why the ones before it failed.
+type servedBy func(provider string, failures []string)
+
+// withServedBy has complete report fallbacks on ctx to report.
+func withServedBy(ctx context.Context, report servedBy) context.Context {
+	return context.WithValue(ctx, servedByKey{}, report)
+}
+
+// fallbackChain is the provider followed by the configured fallbacks that
+// can be used, each with its key.
+func fallbackChain(cfg *Config, provider aiProvider, key string) ([]aiProvider, []string, error) {
+	providers, keys := []aiProvider{provider}, []string{key}
+	for _, id := range cfg.FallbackProviders {
+		p, ok := findProvider(id)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown AI provider %q in fallback_providers", id)
+		}
+		k := cfg.apiKey(p)
+		if p.ID == provider.ID || p.KeyEnv != "" && k == "" {
+			continue
+		}
+		providers, keys = append(providers, p), append(keys, k)
+	}
+	return providers, keys, nil
+}
+
+// completeWithFallback sends the prompt to the provider, falling back to the
+// next in the chain while they fail before streaming anything.
+func completeWithFallback(ctx context.Context, cfg *Config, provider aiProvider, key, prompt string, onToken func(string)) (string, error) {
+	providers, keys, err := fallbackChain(cfg, provider, key)
+	if err != nil {
+		return "", err
+	}
+
+	var failures []string
+	for i, p := range providers {
+		streamed := false
+		text, err := completeWith(ctx, cfg, p, keys[i], prompt, func(s string) {
+			streamed = true
+			if onToken != nil {
+				onToken(s)
+			}
+		})
+		if err == nil {
+			if report, ok := ctx.Value(servedByKey{}).(servedBy); ok && i > 0 {
+				report(p.Label, failures)
+			}
+			return text, nil
+		}
+		if errors.Is(err, errTruncated) {
+			// Another provider wouldn't have a higher limit
+			return text, err
+		}
+		if streamed || ctx.Err() != nil || errors.Is(err, context.Canceled) || i == len(providers)-1 {
+			if len(failures) > 0 {
+				return "", fmt.Errorf("%s: %w (after %s)", p.Label, err, strings.Join(failures, "; "))
+			}
+			return "", err
+		}
+		logDebug("%s failed, falling back to %s: %v", p.Label, providers[i+1].Label, err)
+		failures = append(failures, fmt.Sprintf("%s failed: %v", p.Label, err))
+	}
+	return "", errors.New("no AI provider to use")
+}
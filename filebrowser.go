@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ctrl+f in the parameter form browses for a file to put in the field.
+// Parameters named like paths (file, dir, path, ...) or matched by
+// "path_params" in the config are path parameters: the form says so, the
+// pick replaces their value, and for directories only directories can be
+// picked. Typing narrows the files with a glob, and hidden files are shown
+// on request.
+
+// pathKind is what a path parameter takes.
+type pathKind int
+
+const (
+	notPath pathKind = iota
+	filePath
+	dirPath
+)
+
+// pathWords are the parts of parameter names that suggest a path.
+var (
+	dirWords  = []string{"dir", "directory", "folder"}
+	fileWords = []string{"file", "path", "src", "dest"}
+)
+
+// paramPathKind tells whether the parameter takes a path. "path_params" maps
+// parameter names, or globs over them, to "file" or "dir", and wins over
+// the name.
+func paramPathKind(p Parameter, cfg *Config) pathKind {
+	if cfg != nil {
+		for pattern, kind := range cfg.PathParams {
+			if ok, _ := path.Match(pattern, p.Name); !ok {
+				continue
+			}
+			switch kind {
+			case "dir":
+				return dirPath
+			case "file":
+				return filePath
+			}
+			return notPath
+		}
+	}
+	words := strings.FieldsFunc(strings.ToLower(p.Name), func(r rune) bool { return r == '_' || r == '-' })
+	for _, w := range words {
+		if containsString(dirWords, w) {
+			return dirPath
+		}
+	}
+	for _, w := range words {
+		if containsString(fileWords, w) {
+			return filePath
+		}
+	}
+	return notPath
+}
+
+// fileBrowser picks a path for the form field.
+type fileBrowser struct {
+	field   int
+	kind    pathKind
+	dir     string
+	glob    string // Typed to narrow the files
+	hidden  bool
+	entries []os.DirEntry // Matching the glob
+	cursor  int
+	err     error
+}
+
+func (m *model) startFileBrowser() tea.Cmd {
+	kind := notPath
+	if m.selectedRecipe != nil && m.focusIndex < len(m.selectedRecipe.Parameters) {
+		cfg, _ := LoadConfig()
+		kind = paramPathKind(m.selectedRecipe.Parameters[m.focusIndex], cfg)
+	}
+	dir, _ := os.Getwd()
+	// Start where the value typed so far points
+	if value := m.inputs[m.focusIndex].Value(); value != "" {
+		if info, err := os.Stat(value); err == nil && info.IsDir() {
+			dir = value
+		} else if info, err := os.Stat(filepath.Dir(value)); err == nil && info.IsDir() {
+			dir = filepath.Dir(value)
+		}
+	}
+	dir, _ = filepath.Abs(dir)
+	b := &fileBrowser{field: m.focusIndex, kind: kind, dir: dir}
+	b.read()
+	m.fileBrowser = b
+	m.state = viewFileBrowser
+	return nil
+}
+
+// read lists the directory, directories first, keeping those files that
+// match the glob.
+func (b *fileBrowser) read() {
+	b.cursor = 0
+	b.entries = nil
+	all, err := os.ReadDir(b.dir)
+	b.err = err
+	glob := b.glob
+	if glob != "" && !strings.ContainsAny(glob, "*?[") {
+		glob = "*" + glob + "*"
+	}
+	for _, e := range all {
+		if !b.hidden && strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if !b.isDir(e) && (b.kind == dirPath || !globMatch(glob, e.Name())) {
+			continue
+		}
+		b.entries = append(b.entries, e)
+	}
+	sort.SliceStable(b.entries, func(i, j int) bool {
+		return b.isDir(b.entries[i]) && !b.isDir(b.entries[j])
+	})
+}
+
+// globMatch matches names case-insensitively, everything for no glob.
+func globMatch(glob, name string) bool {
+	if glob == "" {
+		return true
+	}
+	ok, _ := path.Match(strings.ToLower(glob), strings.ToLower(name))
+	return ok
+}
+
+// isDir follows symlinks, so linked directories can be opened.
+func (b *fileBrowser) isDir(e os.DirEntry) bool {
+	if e.Type()&os.ModeSymlink != 0 {
+		info, err := os.Stat(filepath.Join(b.dir, e.Name()))
+		return err == nil && info.IsDir()
+	}
+	return e.IsDir()
+}
+
+// rows are the entries shown, after the directory itself when directories
+// are picked.
+func (b *fileBrowser) rows() int {
+	if b.kind == dirPath {
+		return len(b.entries) + 1
+	}
+	return len(b.entries)
+}
+
+func (m model) updateFileBrowser(msg tea.KeyMsg) (model, tea.Cmd) {
+	b := m.fileBrowser
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewInput
+		m.fileBrowser = nil
+	case key.Matches(msg, m.keys.ToggleHidden):
+		b.hidden = !b.hidden
+		b.read()
+	case msg.Type == tea.KeyUp:
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case msg.Type == tea.KeyDown:
+		if b.cursor < b.rows()-1 {
+			b.cursor++
+		}
+	case msg.Type == tea.KeyLeft || (msg.Type == tea.KeyBackspace && b.glob == ""):
+		b.dir = filepath.Dir(b.dir)
+		b.glob = ""
+		b.read()
+	case msg.Type == tea.KeyRight || key.Matches(msg, m.keys.Select):
+		i := b.cursor
+		if b.kind == dirPath {
+			if i == 0 {
+				if msg.Type == tea.KeyRight {
+					return m, nil
+				}
+				return m, m.pickPath(b.dir)
+			}
+			i--
+		}
+		if i >= len(b.entries) {
+			return m, nil
+		}
+		p := filepath.Join(b.dir, b.entries[i].Name())
+		if b.isDir(b.entries[i]) {
+			b.dir = p
+			b.glob = ""
+			b.read()
+		} else if msg.Type != tea.KeyRight {
+			return m, m.pickPath(p)
+		}
+	case msg.Type == tea.KeyBackspace:
+		runes := []rune(b.glob)
+		b.glob = string(runes[:len(runes)-1])
+		b.read()
+	case msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace:
+		b.glob += string(msg.Runes)
+		b.read()
+	}
+	return m, nil
+}
+
+// pickPath puts the path in the field, relative to the working directory
+// when it's below it. It replaces a path parameter's value and is inserted
+// into others.
+func (m *model) pickPath(p string) tea.Cmd {
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, p); err == nil && !strings.HasPrefix(rel, "..") {
+			p = rel
+		}
+	}
+	b := m.fileBrowser
+	m.state = viewInput
+	m.fileBrowser = nil
+	if b.kind == notPath {
+		return func() tea.Msg { return pasteMsg(p) }
+	}
+	m.inputs[b.field].SetValue(p)
+	m.inputs[b.field].CursorEnd()
+	return nil
+}
+
+func (m model) fileBrowserView() string {
+	b := m.fileBrowser
+	var s strings.Builder
+	title := "Pick a file"
+	if b.kind == dirPath {
+		title = "Pick a directory"
+	}
+	s.WriteString(titleStyle.Render(title))
+	s.WriteString("\n\n")
+	s.WriteString(helpStyle.Render(displayPath(b.dir)))
+	s.WriteString("\n")
+	if b.kind != dirPath {
+		s.WriteString("> " + b.glob + "\n")
+	}
+	s.WriteString("\n")
+	if b.err != nil {
+		s.WriteString(helpStyle.Render(fmt.Sprintf("Can't read the directory: %v", b.err)))
+	} else if b.rows() == 0 {
+		s.WriteString(helpStyle.Render("Nothing matches"))
+	}
+
+	accent := lipgloss.NewStyle().Foreground(activeTheme.Accent)
+	row := func(i int, name string) {
+		cursor := " "
+		if i == b.cursor {
+			name = accent.Render(name)
+			cursor = ">"
+		}
+		fmt.Fprintf(&s, "%s %s\n", cursor, name)
+	}
+	// Only as many as fit, scrolling with the cursor
+	shown := max(m.terminalHeight-12, 1)
+	offset := max(0, b.cursor-shown+1)
+	for i := offset; i < min(b.rows(), offset+shown); i++ {
+		j := i
+		if b.kind == dirPath {
+			if i == 0 {
+				row(i, ". (this directory)")
+				continue
+			}
+			j--
+		}
+		e := b.entries[j]
+		name := e.Name()
+		if b.isDir(e) {
+			name += "/"
+		}
+		row(i, name)
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(s.String())
+}
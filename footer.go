@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// footerSep separates the key hints in the footer.
+const footerSep = " • "
+
+// fitFooter lays the hints out in at most lines lines of width. When they
+// don't fit, navigation hints ("↑/↓: ...", "type: ...") go first, then the
+// others from the end, keeping the first hint and the last, which is
+// usually the way out. What still doesn't fit is cut off with an ellipsis.
+func fitFooter(keys []string, width, lines int) string {
+	if width <= 0 || len(keys) == 0 {
+		return strings.Join(keys, footerSep)
+	}
+
+	var drop []int
+	for i, k := range keys {
+		if isNavHint(k) {
+			drop = append(drop, i)
+		}
+	}
+	for i := len(keys) - 2; i > 0; i-- {
+		if !isNavHint(keys[i]) {
+			drop = append(drop, i)
+		}
+	}
+
+	dropped := map[int]bool{}
+	for n := 0; ; n++ {
+		var kept []string
+		for i, k := range keys {
+			if !dropped[i] {
+				kept = append(kept, k)
+			}
+		}
+		if rows, ok := wrapHints(kept, width, lines); ok || n == len(drop) {
+			rows = rows[:min(len(rows), lines)]
+			for i, row := range rows {
+				rows[i] = ansi.Truncate(row, width, "…")
+			}
+			return strings.Join(rows, "\n")
+		}
+		dropped[drop[n]] = true
+	}
+}
+
+func isNavHint(k string) bool {
+	return strings.HasPrefix(k, "↑") || strings.HasPrefix(k, "type:") || strings.HasPrefix(k, "tab/shift+tab")
+}
+
+// wrapHints fills up to lines rows of width with the hints, reporting
+// whether they all fit.
+func wrapHints(keys []string, width, lines int) ([]string, bool) {
+	var rows []string
+	row := ""
+	for _, k := range keys {
+		switch {
+		case row == "":
+			row = k
+		case ansi.StringWidth(row+footerSep+k) <= width:
+			row += footerSep + k
+		default:
+			rows = append(rows, row)
+			row = k
+		}
+	}
+	rows = append(rows, row)
+	if len(rows) > lines {
+		return rows, false
+	}
+	for _, r := range rows {
+		if ansi.StringWidth(r) > width {
+			return rows, false
+		}
+	}
+	return rows, true
+}
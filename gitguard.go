@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Git guards stop recipes such as release* from running by accident on a
+// dirty working tree or the wrong branch: they have to be confirmed by
+// typing the recipe name, or are refused outright.
+
+// GitGuard protects recipes matching Pattern, a glob such as "release*".
+type GitGuard struct {
+	Pattern string `json:"pattern"`
+
+	// Branches the recipe may run on without asking, ["main", "master"] by
+	// default.
+	Branches []string `json:"branches,omitempty"`
+
+	// Refuse stops the run instead of asking.
+	Refuse bool `json:"refuse,omitempty"`
+}
+
+// gitGuardFor returns the first guard matching the recipe.
+func (c *Config) gitGuardFor(recipe string) (GitGuard, bool) {
+	if c == nil {
+		return GitGuard{}, false
+	}
+	for _, g := range c.GitGuards {
+		if ok, _ := path.Match(g.Pattern, recipe); ok {
+			return g, true
+		}
+	}
+	return GitGuard{}, false
+}
+
+// gitGuardProblems checks the project's git state against the recipe's
+// guard, returning what's wrong and whether to refuse. Outside a git
+// repository there's nothing to check.
+func gitGuardProblems(cfg *Config, recipe string) (problems []string, refuse bool) {
+	g, ok := cfg.gitGuardFor(recipe)
+	if !ok {
+		return nil, false
+	}
+	dir := projectDir()
+	out, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	if err != nil {
+		return nil, false
+	}
+	if changes := strings.Count(string(out), "\n"); changes > 0 {
+		problems = append(problems, fmt.Sprintf("the working tree has %d uncommitted %s", changes, plural(changes, "change", "changes")))
+	}
+
+	branches := g.Branches
+	if len(branches) == 0 {
+		branches = []string{"main", "master"}
+	}
+	out, err = exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if branch := strings.TrimSpace(string(out)); err == nil && !containsString(branches, branch) {
+		if branch == "HEAD" {
+			branch = "a detached HEAD"
+		}
+		problems = append(problems, fmt.Sprintf("it's on %s, not %s", branch, strings.Join(branches, " or ")))
+	}
+	return problems, g.Refuse
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// guardRecipes has runs of guarded recipes confirmed by typing, or refuses
+// them, when the git state isn't as expected. Recipes with [confirm] are
+// confirmed too, with their question. It returns nil when run can go ahead
+// as usual.
+func guardRecipes(recipes map[string]Recipe, names []string, run tea.Cmd) tea.Cmd {
+	cfg, _ := LoadConfig()
+	var b strings.Builder
+	var prompts []string
+	for _, name := range names {
+		for _, p := range confirmPrompts(recipes, name) {
+			if !containsString(prompts, p) {
+				prompts = append(prompts, p)
+			}
+		}
+	}
+	for _, p := range prompts {
+		fmt.Fprintf(&b, "%s\n", p)
+	}
+	if len(prompts) > 0 {
+		b.WriteString("\n")
+	}
+
+	confirmWord := ""
+	for _, name := range names {
+		problems, refuse := gitGuardProblems(cfg, name)
+		if len(problems) == 0 {
+			continue
+		}
+		if refuse {
+			return func() tea.Msg {
+				return fmt.Errorf("not running %s: %s", name, strings.Join(problems, " and "))
+			}
+		}
+		if confirmWord == "" {
+			confirmWord = name
+		}
+		fmt.Fprintf(&b, "%s is guarded and %s.\n", name, strings.Join(problems, " and "))
+	}
+	if confirmWord == "" && len(prompts) == 0 {
+		return nil
+	}
+	if confirmWord == "" {
+		return func() tea.Msg {
+			return confirmMsg{
+				title: "Confirm",
+				body:  strings.TrimSpace(b.String()),
+				onYes: run,
+			}
+		}
+	}
+	return func() tea.Msg {
+		return confirmMsg{
+			title: "Run anyway?",
+			body:  b.String(),
+			onYes: run,
+			typed: confirmWord,
+		}
+	}
+}
+
+// confirmGitGuard is the terminal version of guardRecipes for `run`: it
+// returns an error unless the recipe may run.
+func confirmGitGuard(recipe string, in *os.File, out io.Writer) error {
+	cfg, _ := LoadConfig()
+	problems, refuse := gitGuardProblems(cfg, recipe)
+	if len(problems) == 0 {
+		return nil
+	}
+	reason := strings.Join(problems, " and ")
+	if refuse {
+		return fmt.Errorf("not running %s: %s", recipe, reason)
+	}
+	if info, err := in.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("not running %s: %s", recipe, reason)
+	}
+	fmt.Fprintf(out, "%s is guarded and %s.\nType %s to run anyway: ", recipe, reason, recipe)
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	if strings.TrimSpace(line) != recipe {
+		return fmt.Errorf("not running %s", recipe)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The dependency graph view expands on the tree under the preview: it
+// draws shared dependencies once, flags cycles and missing recipes, and
+// lists the order just runs everything in and what depends on the recipe.
+
+// dependencyGraph renders the graph of the recipe's dependencies.
+func dependencyGraph(recipes map[string]Recipe, name string) string {
+	var (
+		accent = lipgloss.NewStyle().Foreground(activeTheme.Accent)
+		muted  = helpStyle
+		b      strings.Builder
+	)
+
+	b.WriteString(muted.Render("Dependencies:") + "\n")
+	b.WriteString(name + "\n")
+	if len(recipes[name].Dependencies) == 0 {
+		b.WriteString(muted.Render("(none)") + "\n")
+	}
+	expanded := map[string]bool{}
+	var walk func(name, indent string, path map[string]bool)
+	walk = func(name, indent string, path map[string]bool) {
+		deps := recipes[name].Dependencies
+		for i, d := range deps {
+			branch, next := "├── ", "│   "
+			if i == len(deps)-1 {
+				branch, next = "└── ", "    "
+			}
+			label := d.Recipe
+			_, exists := recipes[d.Recipe]
+			switch {
+			case path[d.Recipe]:
+				label += accent.Render(" ↺ cycle")
+			case !exists:
+				label += accent.Render(" ✗ missing")
+			case expanded[d.Recipe] && len(recipes[d.Recipe].Dependencies) > 0:
+				label += muted.Render(" (see above)")
+			}
+			b.WriteString(indent + branch + label + "\n")
+			if path[d.Recipe] || !exists || expanded[d.Recipe] {
+				continue
+			}
+			expanded[d.Recipe] = true
+			path[d.Recipe] = true
+			walk(d.Recipe, indent+next, path)
+			delete(path, d.Recipe)
+		}
+	}
+	walk(name, "", map[string]bool{name: true})
+
+	order, cyclic := runOrder(recipes, name)
+	b.WriteString("\n" + muted.Render("Runs in this order:") + "\n")
+	for i, r := range order {
+		fmt.Fprintf(&b, "%2d. %s\n", i+1, r)
+	}
+	if cyclic {
+		b.WriteString(accent.Render("just refuses to run recipes with circular dependencies") + "\n")
+	}
+
+	b.WriteString("\n" + muted.Render("Needed by:") + "\n")
+	dependents := dependentsOf(recipes, name)
+	if len(dependents) == 0 {
+		b.WriteString(muted.Render("(nothing)") + "\n")
+	}
+	for _, r := range dependents {
+		b.WriteString(r + "\n")
+	}
+	return b.String()
+}
+
+// runOrder is the order just runs the recipe and its dependencies in,
+// dependencies first and each only once. It also reports whether there is a
+// cycle on the way.
+func runOrder(recipes map[string]Recipe, name string) ([]string, bool) {
+	var order []string
+	done := map[string]bool{}
+	visiting := map[string]bool{}
+	cyclic := false
+	var visit func(string)
+	visit = func(name string) {
+		if done[name] {
+			return
+		}
+		if visiting[name] {
+			cyclic = true
+			return
+		}
+		visiting[name] = true
+		for _, d := range recipes[name].Dependencies {
+			if _, ok := recipes[d.Recipe]; ok {
+				visit(d.Recipe)
+			}
+		}
+		visiting[name] = false
+		done[name] = true
+		order = append(order, name)
+	}
+	visit(name)
+	return order, cyclic
+}
+
+// dependentsOf returns the recipes that depend on the recipe directly.
+func dependentsOf(recipes map[string]Recipe, name string) []string {
+	var out []string
+	for other, r := range recipes {
+		for _, d := range r.Dependencies {
+			if d.Recipe == name {
+				out = append(out, other)
+				break
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (m *model) startDependencyGraph() {
+	i, ok := m.list.SelectedItem().(recipeItem)
+	if !ok {
+		return
+	}
+	m.graphRecipe = i.name
+	m.graph = viewport.New(m.terminalWidth-4, m.terminalHeight-5)
+	m.graph.SetContent(dependencyGraph(m.recipes, i.name))
+	m.state = viewDependencyGraph
+}
+
+func (m model) updateDependencyGraph(msg tea.KeyMsg) (model, tea.Cmd) {
+	if key.Matches(msg, m.keys.Cancel, m.keys.Quit) {
+		m.state = viewList
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.graph, cmd = m.graph.Update(msg)
+	return m, cmd
+}
+
+func (m model) dependencyGraphView() string {
+	return lipgloss.NewStyle().Margin(1, 2).Render(
+		lipgloss.JoinVertical(lipgloss.Left, titleStyle.Render("Dependency graph: "+m.graphRecipe), "", m.graph.View()),
+	)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Attribute is a recipe attribute from the dump. just writes attributes
+// without arguments as plain strings ("private") and the rest as single-key
+// objects ({"group": "ci"}).
+type Attribute struct {
+	Name  string
+	Value string
+}
+
+func (a *Attribute) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*a = Attribute{Name: name}
+		return nil
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	for name, raw := range obj {
+		a.Name = name
+		// The argument is a string, or a list of them in newer versions
+		var value string
+		var values []string
+		if json.Unmarshal(raw, &value) == nil {
+			a.Value = value
+		} else if json.Unmarshal(raw, &values) == nil {
+			a.Value = strings.Join(values, " ")
+		}
+	}
+	return nil
+}
+
+// groups returns the groups the recipe was put in with `[group(...)]`.
+func (r Recipe) groups() []string {
+	var out []string
+	for _, a := range r.Attributes {
+		if a.Name == "group" && a.Value != "" {
+			out = append(out, a.Value)
+		}
+	}
+	return out
+}
+
+func (r Recipe) inGroup(group string) bool {
+	for _, g := range r.groups() {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// groupItem is a group header in the recipe list. Selecting it collapses or
+// expands the recipes below it.
+type groupItem struct {
+	name      string
+	count     int
+	collapsed bool
+}
+
+func (g groupItem) Title() string {
+	arrow := "▾"
+	if g.collapsed {
+		arrow = "▸"
+	}
+	return fmt.Sprintf("%s %s (%d)", arrow, g.name, g.count)
+}
+func (g groupItem) Description() string { return "group" }
+func (g groupItem) FilterValue() string { return "" }
+
+// recipeGroups returns the names of all groups, sorted.
+func (m model) recipeGroups() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, r := range m.recipes {
+		for _, g := range r.groups() {
+			if !seen[g] {
+				seen[g] = true
+				out = append(out, g)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// groupedItems lays out the recipe items the way `just --list` does:
+// ungrouped recipes first, then each group under a header. A recipe in
+// several groups is listed under each of them. While a filter is active the
+// headers are left out and collapsed groups are included, so searching
+// still finds everything.
+func (m model) groupedItems(recipes []recipeItem) []list.Item {
+	var items []list.Item
+	grouped := map[string][]recipeItem{}
+	for _, item := range recipes {
+		groups := m.recipes[item.name].groups()
+		if len(groups) == 0 {
+			items = append(items, item)
+		}
+		for _, g := range groups {
+			grouped[g] = append(grouped[g], item)
+		}
+	}
+
+	for _, g := range m.recipeGroups() {
+		if len(grouped[g]) == 0 {
+			continue
+		}
+		collapsed := m.collapsed[g] && !m.itemsFiltering
+		if !m.itemsFiltering {
+			items = append(items, groupItem{name: g, count: len(grouped[g]), collapsed: collapsed})
+		}
+		if collapsed {
+			continue
+		}
+		for _, item := range grouped[g] {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// toggleGroup collapses or expands a group, keeping its header selected.
+func (m *model) toggleGroup(g groupItem) tea.Cmd {
+	m.collapsed[g.name] = !m.collapsed[g.name]
+	cmd := m.list.SetItems(m.buildItems())
+	for i, item := range m.list.Items() {
+		if h, ok := item.(groupItem); ok && h.name == g.name {
+			m.list.Select(i)
+			break
+		}
+	}
+	return cmd
+}
+
+// cycleGroupFilter narrows the list to the next group, and back to all
+// recipes after the last one.
+func (m *model) cycleGroupFilter() tea.Cmd {
+	groups := m.recipeGroups()
+	next := ""
+	if m.groupFilter == "" && len(groups) > 0 {
+		next = groups[0]
+	}
+	for i, g := range groups {
+		if g == m.groupFilter && i+1 < len(groups) {
+			next = groups[i+1]
+		}
+	}
+	m.groupFilter = next
+	m.list.Title = m.listTitle()
+	cmd := m.list.SetItems(m.buildItems())
+	m.list.Select(0)
+	return cmd
+}
+
+// syncFilterItems rebuilds the items when a filter starts or ends, so that
+// collapsed groups are searched too.
+func (m *model) syncFilterItems() tea.Cmd {
+	filtering := m.list.FilterState() != list.Unfiltered
+	if filtering == m.itemsFiltering {
+		return nil
+	}
+	m.itemsFiltering = filtering
+	if len(m.recipeGroups()) == 0 {
+		return nil
+	}
+	return m.list.SetItems(m.buildItems())
+}
+
+// groupPreview lists the recipes in a group for the preview pane.
+func (m model) groupPreview(group string) string {
+	var names []string
+	for name, r := range m.recipes {
+		if r.inGroup(group) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("[group(%q)]\n\n%s\n", group, strings.Join(names, "\n"))
+}
+
+// groupJump is the quick menu for jumping to a group's header, narrowed by
+// typing part of its name.
+type groupJump struct {
+	query  string
+	groups []string // Matching the query
+	cursor int
+}
+
+func (m *model) startGroupJump() tea.Cmd {
+	if len(m.recipeGroups()) == 0 {
+		return func() tea.Msg { return statusMsg("No recipe has a [group(...)] attribute") }
+	}
+	m.jump = &groupJump{}
+	m.jump.filter(m.recipeGroups())
+	m.state = viewGroupJump
+	return nil
+}
+
+func (j *groupJump) filter(all []string) {
+	j.cursor = 0
+	if j.query == "" {
+		j.groups = all
+		return
+	}
+	j.groups = nil
+	for _, match := range fuzzyMatch(j.query, all) {
+		j.groups = append(j.groups, all[match.Index])
+	}
+}
+
+func (m model) updateGroupJump(msg tea.KeyMsg) (model, tea.Cmd) {
+	j := m.jump
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewList
+		m.jump = nil
+	case msg.Type == tea.KeyUp || msg.Type == tea.KeyShiftTab:
+		if j.cursor > 0 {
+			j.cursor--
+		}
+	case msg.Type == tea.KeyDown || msg.Type == tea.KeyTab:
+		if j.cursor < len(j.groups)-1 {
+			j.cursor++
+		}
+	case key.Matches(msg, m.keys.Select):
+		if len(j.groups) == 0 {
+			return m, nil
+		}
+		m.state = viewList
+		m.jump = nil
+		return m, m.jumpToGroup(j.groups[j.cursor])
+	case msg.Type == tea.KeyBackspace:
+		if j.query != "" {
+			runes := []rune(j.query)
+			j.query = string(runes[:len(runes)-1])
+			j.filter(m.recipeGroups())
+		}
+	case msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace:
+		j.query += string(msg.Runes)
+		j.filter(m.recipeGroups())
+	}
+	return m, nil
+}
+
+// jumpToGroup selects the group's header, expanding the group and dropping
+// whatever filter would hide it.
+func (m *model) jumpToGroup(group string) tea.Cmd {
+	m.list.ResetFilter()
+	if m.groupFilter != "" && m.groupFilter != group {
+		m.groupFilter = ""
+	}
+	m.collapsed[group] = false
+	m.itemsFiltering = false
+	cmd := m.list.SetItems(m.buildItems())
+	m.list.Title = m.listTitle()
+	for i, item := range m.list.Items() {
+		if h, ok := item.(groupItem); ok && h.name == group {
+			m.list.Select(i)
+			break
+		}
+	}
+	return cmd
+}
+
+func (m model) groupJumpView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Jump to group"))
+	b.WriteString("\n\n")
+	b.WriteString("> " + m.jump.query + "\n\n")
+	if len(m.jump.groups) == 0 {
+		b.WriteString(helpStyle.Render("No group matches"))
+	}
+	for i, g := range m.jump.groups {
+		cursor := " "
+		if i == m.jump.cursor {
+			g = lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(g)
+			cursor = ">"
+		}
+		fmt.Fprintf(&b, "%s %s\n", cursor, g)
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(b.String())
+}
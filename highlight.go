@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The preview highlights recipes itself rather than showing just's colors,
+// which leave the body plain. Bodies are lexed with chroma, picking the
+// language from the shebang; linewise recipes are shell. chroma has no lexer
+// for just itself, so the recipe's header, attributes and {{interpolations}}
+// are colored here. Token types map to the theme's colors rather than a
+// chroma style, so the preview follows the theme.
+
+// shebangLexer picks the lexer for a shebang line, by the interpreter's name
+// or, failing that, chroma's guess from the line.
+func shebangLexer(line string) chroma.Lexer {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "#!"))
+	if len(fields) > 0 && path.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:] // env -S and such
+		}
+	}
+	if len(fields) > 0 {
+		if l := lexers.Get(path.Base(fields[0])); l != nil {
+			return l
+		}
+	}
+	if l := lexers.Analyse(line); l != nil {
+		return l
+	}
+	return lexers.Fallback
+}
+
+// highlightRecipe colors the output of `just --show`: doc comments,
+// attributes, the header with its dependencies, and the body.
+func highlightRecipe(source string) string {
+	var (
+		muted   = lipgloss.NewStyle().Foreground(activeTheme.Muted)
+		accent  = lipgloss.NewStyle().Foreground(activeTheme.Accent).Bold(true)
+		keyword = lipgloss.NewStyle().Foreground(activeTheme.Keyword)
+		lexer   = lexers.Get("bash")
+		inBody  = false
+	)
+
+	var b strings.Builder
+	var body []string
+	flush := func() {
+		if len(body) > 0 {
+			b.WriteString(strings.Join(highlightCode(body, lexer), "\n") + "\n")
+			body = nil
+		}
+	}
+	for _, line := range strings.Split(strings.TrimRight(source, "\n"), "\n") {
+		if isIndented(line) || inBody && line == "" {
+			if !inBody && strings.HasPrefix(strings.TrimSpace(line), "#!") {
+				lexer = shebangLexer(line)
+				b.WriteString(muted.Render(line) + "\n")
+			} else {
+				body = append(body, line)
+			}
+			inBody = true
+			continue
+		}
+		flush()
+		switch {
+		case strings.HasPrefix(line, "#"):
+			b.WriteString(muted.Render(line))
+		case strings.HasPrefix(line, "["):
+			b.WriteString(keyword.Render(line))
+		default:
+			// Header: name and parameters, then dependencies after the colon
+			head, deps, found := cutHeader(line)
+			name, params, _ := strings.Cut(head, " ")
+			b.WriteString(accent.Render(name))
+			if params != "" {
+				b.WriteString(" " + highlightParams(params))
+			}
+			if found {
+				b.WriteString(":")
+			}
+			if deps != "" {
+				b.WriteString(keyword.Render(deps))
+			}
+			inBody = false
+			lexer = lexers.Get("bash")
+		}
+		b.WriteString("\n")
+	}
+	flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// cutHeader splits a recipe header at the colon ending its parameters,
+// skipping colons inside quoted defaults.
+func cutHeader(line string) (head, deps string, found bool) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':' && (i+1 == len(line) || line[i+1] != '='):
+			return line[:i], line[i+1:], true
+		}
+	}
+	return line, "", false
+}
+
+// highlightCode colors lines of code with the lexer, and just's
+// {{interpolations}} in them. The lines are lexed together, so strings and
+// heredocs spanning lines are colored as such.
+func highlightCode(lines []string, lexer chroma.Lexer) []string {
+	text := strings.Join(lines, "\n")
+	tokens, err := chroma.Coalesce(lexer).Tokenise(nil, text)
+	if err != nil {
+		return lines
+	}
+	variable := lipgloss.NewStyle().Foreground(activeTheme.Variable)
+	interpolations := justInterpolation.FindAllStringIndex(text, -1)
+
+	var b strings.Builder
+	pos := 0
+	for _, tok := range tokens.Tokens() {
+		style := tokenStyle(tok.Type)
+		value := tok.Value
+		for value != "" && pos < len(text) {
+			// The part of the token up to the next line break or the next
+			// edge of an interpolation
+			n := len(value)
+			if i := strings.IndexByte(value, '\n'); i >= 0 {
+				n = max(i, 1)
+			}
+			inside := false
+			for _, r := range interpolations {
+				switch {
+				case pos >= r[0] && pos < r[1]:
+					inside, n = true, min(n, r[1]-pos)
+				case r[0] > pos:
+					n = min(n, r[0]-pos)
+				}
+			}
+			switch {
+			case value[:n] == "\n":
+				b.WriteString("\n")
+			case inside:
+				b.WriteString(variable.Render(value[:n]))
+			default:
+				b.WriteString(style.Render(value[:n]))
+			}
+			value, pos = value[n:], pos+n
+		}
+	}
+	return strings.Split(b.String(), "\n")
+}
+
+// justInterpolation matches {{...}} in a recipe body.
+var justInterpolation = regexp.MustCompile(`\{\{.*?\}\}`)
+
+// tokenStyle maps a chroma token type to the theme's colors.
+func tokenStyle(t chroma.TokenType) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	switch {
+	case t.InCategory(chroma.Comment):
+		return style.Foreground(activeTheme.Muted)
+	case t.InCategory(chroma.Keyword), t == chroma.NameBuiltin:
+		return style.Foreground(activeTheme.Keyword)
+	case t.InSubCategory(chroma.LiteralString):
+		return style.Foreground(activeTheme.String)
+	case t.InSubCategory(chroma.NameVariable):
+		return style.Foreground(activeTheme.Variable)
+	}
+	return style
+}
+
+// highlightParams colors the parameters in a recipe header: quoted defaults
+// and interpolations.
+func highlightParams(params string) string {
+	str := lipgloss.NewStyle().Foreground(activeTheme.String)
+	variable := lipgloss.NewStyle().Foreground(activeTheme.Variable)
+
+	var b strings.Builder
+	for i := 0; i < len(params); {
+		rest := params[i:]
+		switch {
+		case strings.HasPrefix(rest, "{{"):
+			end := strings.Index(rest, "}}")
+			if end < 0 {
+				end = len(rest) - 2
+			}
+			b.WriteString(variable.Render(rest[:end+2]))
+			i += end + 2
+		case rest[0] == '"' || rest[0] == '\'':
+			end := strings.IndexByte(rest[1:], rest[0])
+			if end < 0 {
+				end = len(rest) - 2
+			}
+			b.WriteString(str.Render(rest[:end+2]))
+			i += end + 2
+		default:
+			b.WriteByte(params[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+// dependencyTree draws what the recipe depends on, recursively, e.g.
+//
+//	deploy
+//	├── build
+//	│   └── generate
+//	└── test
+func dependencyTree(recipes map[string]Recipe, name string) string {
+	if len(recipes[name].Dependencies) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(name + "\n")
+	var walk func(name, indent string, seen map[string]bool)
+	walk = func(name, indent string, seen map[string]bool) {
+		deps := recipes[name].Dependencies
+		for i, d := range deps {
+			branch, next := "├── ", "│   "
+			if i == len(deps)-1 {
+				branch, next = "└── ", "    "
+			}
+			if seen[d.Recipe] {
+				fmt.Fprintf(&b, "%s%s%s (cycle)\n", indent, branch, d.Recipe)
+				continue
+			}
+			fmt.Fprintf(&b, "%s%s%s\n", indent, branch, d.Recipe)
+			seen[d.Recipe] = true
+			walk(d.Recipe, indent+next, seen)
+			delete(seen, d.Recipe)
+		}
+	}
+	walk(name, "", map[string]bool{name: true})
+	return b.String()
+}
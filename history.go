@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+)
+
+// Values entered in the parameter form are remembered per project, recipe
+// and parameter. The form offers them as completions while typing and
+// steps through them with ctrl+p and ctrl+n.
+
+const maxArgHistory = 20 // Kept per parameter, the oldest are dropped
+
+// argHistory holds the earlier values by recipe, then parameter, newest
+// first.
+type argHistory map[string]map[string][]string
+
+func argHistoryPath() (string, error) {
+	h := sha256.Sum256([]byte(projectDir()))
+	return xdg.DataFile(filepath.Join("just-do-it", "history", hex.EncodeToString(h[:])[:16]+".json"))
+}
+
+func loadArgHistory() argHistory {
+	path, err := argHistoryPath()
+	if err != nil {
+		return argHistory{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return argHistory{}
+	}
+	history := argHistory{}
+	if err := json.Unmarshal(data, &history); err != nil {
+		logDebug("Ignoring broken history file %s: %v", path, err)
+		return argHistory{}
+	}
+	return history
+}
+
+// rememberArgs records the values a recipe ran with, moving values used
+// before to the front. Empty values aren't recorded.
+func rememberArgs(recipe string, values map[string]string) error {
+	history := loadArgHistory()
+	params := history[recipe]
+	if params == nil {
+		params = map[string][]string{}
+		history[recipe] = params
+	}
+	for name, v := range values {
+		if v == "" {
+			continue
+		}
+		updated := []string{v}
+		for _, old := range params[name] {
+			if old != v {
+				updated = append(updated, old)
+			}
+		}
+		params[name] = updated[:min(maxArgHistory, len(updated))]
+	}
+
+	path, err := argHistoryPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// rememberFormArgs records the values in the parameter form as it runs.
+// Command lines being edited aren't recipe arguments and aren't kept.
+func (m model) rememberFormArgs() {
+	if m.selectedRecipe == nil || m.editsCommand() {
+		return
+	}
+	values := map[string]string{}
+	for i, p := range m.selectedRecipe.Parameters {
+		if i < len(m.inputs) && m.inputChoices(i) == nil {
+			values[p.Name] = m.inputs[i].Value()
+		}
+	}
+	if err := rememberArgs(m.selectedRecipe.Name, values); err != nil {
+		logDebug("Saving argument history failed: %v", err)
+	}
+}
+
+// formHistory looks up the earlier values for each parameter of the recipe.
+func formHistory(recipe Recipe) paramChoices {
+	params := loadArgHistory()[recipe.Name]
+	values := make([][]string, len(recipe.Parameters))
+	for i, p := range recipe.Parameters {
+		values[i] = params[p.Name]
+	}
+	return paramChoices{recipe: recipe.Name, values: values}
+}
+
+// inputHistory returns the earlier values for the form field i.
+func (m model) inputHistory(i int) []string {
+	if m.state != viewInput || m.selectedRecipe == nil || m.history.recipe != m.selectedRecipe.Name || i >= len(m.history.values) || m.editsCommand() {
+		return nil
+	}
+	return m.history.values[i]
+}
+
+// stepHistory replaces the focused field with an earlier (delta 1) or later
+// (delta -1) value than the one in it.
+func (m *model) stepHistory(delta int) {
+	values := m.inputHistory(m.focusIndex)
+	if len(values) == 0 {
+		return
+	}
+	current := -1
+	for i, v := range values {
+		if v == m.inputs[m.focusIndex].Value() {
+			current = i
+			break
+		}
+	}
+	next := min(max(current+delta, 0), len(values)-1)
+	m.inputs[m.focusIndex].SetValue(values[next])
+	m.inputs[m.focusIndex].CursorEnd()
+}
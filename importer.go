@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// `just-do-it import` proposes a justfile wrapping what a project already
+// has: the scripts in ./scripts, package.json scripts and Makefile targets.
+// The proposal is shown before anything is written, and with --ai the model
+// improves the docs and names first.
+
+// importedRecipe is a recipe proposed for an existing script or target.
+type importedRecipe struct {
+	name, params, doc, body string
+	source                  string // What it wraps, e.g. "package.json"
+}
+
+const importPrompt = `The justfile below was generated to wrap a project's existing scripts, package.json scripts and Makefile targets as just recipes. Improve it:
+
+- Rewrite each doc comment to say in a few words what the recipe does, based on the sources below.
+- Rename recipes whose names are unclear, keeping names short, lowercase and hyphenated.
+- Keep every recipe and keep the bodies as they are.
+
+Reply with only the justfile, no explanation and no code fences.
+
+Justfile:
+%s
+Sources:
+%s`
+
+// maxImportSource limits how much of each script goes into the AI prompt.
+const maxImportSource = 40
+
+var (
+	makeTarget  = regexp.MustCompile(`^([A-Za-z0-9_][A-Za-z0-9_.-]*)\s*:([^=].*)?$`)
+	nonNameChar = regexp.MustCompile(`[^a-z0-9_-]+`)
+)
+
+// runImport implements `just-do-it import [--ai] [--yes]`.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("just-do-it import", flag.ContinueOnError)
+	useAI := fs.Bool("ai", false, "let the AI provider improve the recipe docs and names")
+	yes := fs.Bool("yes", false, "write without asking")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	dir := justFlags.workingDir
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	path, existing := importTarget(dir)
+	// Read now, so a justfile changed while the proposal is shown isn't overwritten
+	doc, err := readJustfile(path)
+	if os.IsNotExist(err) {
+		doc, err = newJustfile(path), nil
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	recipes := scanImportSources(dir, existing)
+	if len(recipes) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing to import: no scripts/ directory, package.json scripts or Makefile targets found")
+		return 1
+	}
+	proposal := renderImport(recipes)
+
+	if *useAI {
+		fmt.Fprintln(os.Stderr, "Asking the AI provider to improve the recipes (ctrl+c to skip)...")
+		stop := cancelOnSignal()
+		improved, err := improveImport(dir, proposal, recipes)
+		stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Keeping the generated recipes: %v\n", err)
+		} else {
+			proposal = improved
+		}
+	}
+
+	verb := "Create"
+	if len(existing) > 0 {
+		verb = "Append to"
+	}
+	fmt.Printf("%s %s:\n\n%s\n", verb, path, proposal)
+	if !*yes {
+		ok, err := confirmImport(os.Stdin, os.Stderr, fmt.Sprintf("%s %s? [y/N] ", verb, filepath.Base(path)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if !ok {
+			return 0
+		}
+	}
+
+	if err := writeImport(doc, proposal); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	return 0
+}
+
+// importTarget is the justfile to write: the one given with --justfile, the
+// project's own, or a new one. It also returns the recipes already in it.
+func importTarget(dir string) (string, map[string]Recipe) {
+	path := justFlags.justfile
+	if path == "" {
+		path = filepath.Join(dir, justfileNames[0])
+		for _, name := range justfileNames {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				path = filepath.Join(dir, name)
+				break
+			}
+		}
+	}
+	if _, err := os.Stat(path); err != nil {
+		return path, nil
+	}
+	out, err := exec.Command("just", "--justfile", path, "--working-directory", dir, "--dump", "--dump-format", "json").Output()
+	if err != nil {
+		return path, nil
+	}
+	var dump JustDump
+	if err := json.Unmarshal(out, &dump); err != nil {
+		return path, nil
+	}
+	return path, dump.Recipes
+}
+
+// scanImportSources collects recipes for the project's scripts, skipping
+// names the justfile already has. Later sources get a prefix when a name
+// is taken, e.g. "npm-build".
+func scanImportSources(dir string, existing map[string]Recipe) []importedRecipe {
+	taken := map[string]bool{}
+	for name := range existing {
+		taken[name] = true
+	}
+	var out []importedRecipe
+	add := func(prefix string, recipes []importedRecipe) {
+		for _, r := range recipes {
+			name := r.name
+			if taken[name] {
+				name = prefix + "-" + name
+			}
+			if taken[name] {
+				continue
+			}
+			taken[name] = true
+			r.name = name
+			out = append(out, r)
+		}
+	}
+	add("script", scriptRecipes(dir))
+	add("npm", packageRecipes(dir))
+	add("make", makeRecipes(dir))
+	return out
+}
+
+// recipeName turns a script or target name into a recipe name.
+func recipeName(s string) string {
+	name := strings.Trim(nonNameChar.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if name == "" || name[0] >= '0' && name[0] <= '9' || name[0] == '-' {
+		name = "run-" + name
+	}
+	return name
+}
+
+// scriptInterpreters run scripts that aren't executable, by extension.
+var scriptInterpreters = map[string]string{
+	".sh":   "bash",
+	".bash": "bash",
+	".py":   "python3",
+	".js":   "node",
+	".mjs":  "node",
+	".ts":   "npx tsx",
+	".rb":   "ruby",
+	".pl":   "perl",
+	".ps1":  "pwsh -File",
+}
+
+// scriptRecipes wraps each script in ./scripts, passing arguments on.
+func scriptRecipes(dir string) []importedRecipe {
+	entries, err := os.ReadDir(filepath.Join(dir, "scripts"))
+	if err != nil {
+		return nil
+	}
+	var out []importedRecipe
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		rel := "scripts/" + e.Name()
+		ext := filepath.Ext(e.Name())
+		var command string
+		switch interpreter, ok := scriptInterpreters[ext]; {
+		case info.Mode()&0111 != 0:
+			command = "./" + rel
+		case ok:
+			command = interpreter + " " + rel
+		default:
+			continue // Not something to run
+		}
+		doc := scriptDoc(filepath.Join(dir, rel))
+		if doc == "" {
+			doc = "Run " + rel
+		}
+		out = append(out, importedRecipe{
+			name:   recipeName(strings.TrimSuffix(e.Name(), ext)),
+			params: "*args",
+			doc:    doc,
+			body:   command + " {{args}}",
+			source: rel,
+		})
+	}
+	return out
+}
+
+// scriptDoc is the first comment in a script, after the shebang.
+func scriptDoc(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for i := 0; scanner.Scan() && i < 10; i++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#!"):
+			continue
+		case strings.HasPrefix(line, "#"), strings.HasPrefix(line, "//"):
+			doc := strings.TrimSpace(strings.TrimLeft(line, "#/"))
+			if doc != "" && !strings.Contains(doc, "-*-") && !strings.HasPrefix(doc, "shellcheck") {
+				return doc
+			}
+		case strings.HasPrefix(line, "set "), strings.HasPrefix(line, "import "), strings.HasPrefix(line, "from "):
+			continue
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// packageRecipes wraps package.json scripts, run with the package manager
+// the lockfile points to. Pre and post hooks run with their script and
+// aren't listed.
+func packageRecipes(dir string) []importedRecipe {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		logDebug("Not importing package.json scripts: %v", err)
+		return nil
+	}
+
+	run := "npm run"
+	for lockfile, runner := range map[string]string{"pnpm-lock.yaml": "pnpm run", "yarn.lock": "yarn", "bun.lockb": "bun run", "bun.lock": "bun run"} {
+		if _, err := os.Stat(filepath.Join(dir, lockfile)); err == nil {
+			run = runner
+		}
+	}
+
+	var names []string
+	for name := range pkg.Scripts {
+		hook := strings.TrimPrefix(strings.TrimPrefix(name, "pre"), "post")
+		if _, ok := pkg.Scripts[hook]; ok && hook != name {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []importedRecipe
+	for _, name := range names {
+		out = append(out, importedRecipe{
+			name:   recipeName(name),
+			params: "*args",
+			doc:    ansi.Truncate(pkg.Scripts[name], 70, "…"),
+			body:   run + " " + name + " {{args}}",
+			source: "package.json",
+		})
+	}
+	return out
+}
+
+// makeRecipes wraps the Makefile's explicit targets. A `## comment` after
+// the target, or a comment line right above it, becomes the doc.
+func makeRecipes(dir string) []importedRecipe {
+	var data []byte
+	for _, name := range []string{"GNUmakefile", "makefile", "Makefile"} {
+		if b, err := os.ReadFile(filepath.Join(dir, name)); err == nil {
+			data = b
+		}
+	}
+	if data == nil {
+		return nil
+	}
+
+	var out []importedRecipe
+	seen := map[string]bool{}
+	previous := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		m := makeTarget.FindStringSubmatch(line)
+		comment := previous
+		previous = ""
+		if strings.HasPrefix(line, "#") {
+			previous = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		}
+		if m == nil || seen[m[1]] {
+			continue
+		}
+		target := m[1]
+		seen[target] = true
+		if strings.Contains(target, ".") {
+			continue // A file rule such as main.o, or .PHONY
+		}
+		if _, doc, ok := strings.Cut(m[2], "##"); ok {
+			comment = strings.TrimSpace(doc)
+		}
+		if comment == "" {
+			comment = "Run make " + target
+		}
+		out = append(out, importedRecipe{
+			name:   recipeName(target),
+			doc:    comment,
+			body:   "make " + target,
+			source: "Makefile",
+		})
+	}
+	return out
+}
+
+// renderImport writes the recipes as justfile source.
+func renderImport(recipes []importedRecipe) string {
+	var b strings.Builder
+	for i, r := range recipes {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		header := r.name
+		if r.params != "" {
+			header += " " + r.params
+		}
+		fmt.Fprintf(&b, "# %s\n%s:\n    %s\n", r.doc, header, r.body)
+	}
+	return b.String()
+}
+
+// improveImport has the AI rewrite the proposal, making sure just can still
+// parse the result and that no recipe went missing.
+func improveImport(dir, proposal string, recipes []importedRecipe) (string, error) {
+	var sources strings.Builder
+	seen := map[string]bool{}
+	for _, r := range recipes {
+		if seen[r.source] {
+			continue
+		}
+		seen[r.source] = true
+		data, err := os.ReadFile(filepath.Join(dir, r.source))
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(data), "\n")
+		fmt.Fprintf(&sources, "--- %s\n%s\n", r.source, strings.Join(lines[:min(maxImportSource, len(lines))], "\n"))
+	}
+
+	ctx := withMaxTokens(appContext(), importMaxTokens)
+	text, err := complete(ctx, fmt.Sprintf(importPrompt, proposal, sources.String()), nil)
+	if errors.Is(err, errTruncated) {
+		return "", fmt.Errorf("the AI's justfile was cut off at %d tokens", importMaxTokens)
+	}
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "```just"), "```")
+	text = strings.TrimSpace(strings.TrimSuffix(text, "```")) + "\n"
+
+	tmp, err := os.CreateTemp("", "just-do-it-import-*.just")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(text); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	tmp.Close()
+	out, err := exec.Command("just", "--justfile", tmp.Name(), "--working-directory", dir, "--summary").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("the AI's justfile doesn't parse: %s", strings.TrimSpace(string(out)))
+	}
+	if got := len(strings.Fields(string(out))); got < len(recipes) {
+		return "", fmt.Errorf("the AI's justfile has %d of the %d recipes", got, len(recipes))
+	}
+	return text, nil
+}
+
+// confirmImport asks a yes/no question on the terminal.
+func confirmImport(in *os.File, out io.Writer, question string) (bool, error) {
+	if info, err := in.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false, fmt.Errorf("not a terminal, pass --yes to write without asking")
+	}
+	fmt.Fprint(out, question)
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
+// writeImport creates the justfile or appends to it, formatting it after
+// when auto_format is set.
+func writeImport(d *justfileDoc, proposal string) error {
+	d.Append(strings.Split(strings.TrimRight(proposal, "\n"), "\n"))
+	if err := d.Save(); err != nil {
+		return err
+	}
+	if cfg, _ := LoadConfig(); cfg != nil && cfg.AutoFormat {
+		out, err := exec.Command("just", "--justfile", d.path, "--fmt", "--unstable").CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("just --fmt failed: %s", strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// job is a command run inside the app. Unlike the default exec hand-off, its
+// output is captured into the output pane and the TUI stays around.
+type job struct {
+	id       int
+	recipe   string
+	command  []string
+	dir      string // Where it was started, empty for the current directory
+	limits   string // Resource limits applied, if any
+	pid      int
+	usage    jobUsage
+	output   []string
+	dropped  int // Lines dropped from the start of output
+	events   chan jobEvent
+	cancel   context.CancelFunc
+	started  time.Time
+	finished bool
+	exitCode int
+	err      error
+	duration time.Duration
+	estimate time.Duration // From earlier runs, for the progress bar
+}
+
+// jobEvent is sent from the goroutine running the command.
+type jobEvent struct {
+	line     string
+	done     bool
+	exitCode int
+	err      error
+}
+
+// Msg carrying the job events that came since the last one to Update
+type jobMsg struct {
+	id     int
+	events []jobEvent
+}
+
+// maxOutputLines is how much output the pane keeps, the oldest lines are
+// dropped beyond it. The output log, with "log_output" set, has them all.
+const maxOutputLines = 10000
+
+var nextJobID int
+
+// startJob launches the command in the background, with the resource limits
+// configured for the recipe and env added to its environment. It starts in
+// dir, unless that's empty.
+func startJob(recipe string, command, env []string, dir string) *job {
+	nextJobID++
+	ctx, cancel := context.WithCancel(appContext())
+	j := &job{
+		id:      nextJobID,
+		recipe:  recipe,
+		command: command,
+		dir:     dir,
+		events:  make(chan jobEvent, 100),
+		cancel:  cancel,
+		started: time.Now(),
+	}
+
+	argv := withEnvironment(command)
+	cfg, _ := LoadConfig()
+	if cfg != nil {
+		if l, ok := cfg.resourceLimitFor(recipe); ok {
+			argv = l.wrap(argv)
+			j.limits = l.describe()
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	// Output isn't a terminal, ask tools to keep their colors anyway
+	cmd.Env = append(os.Environ(), "CLICOLOR_FORCE=1", "FORCE_COLOR=1")
+	cmd.Env = append(cmd.Env, env...)
+	cmd.Dir = dir
+	cmd.WaitDelay = 2 * time.Second
+	killGroup(cmd)
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	// Start right away so the pid is known for usage sampling
+	if err := cmd.Start(); err != nil {
+		pw.Close()
+		pr.Close()
+		j.events <- jobEvent{done: true, exitCode: -1, err: err}
+		close(j.events)
+		return j
+	}
+	j.pid = cmd.Process.Pid
+
+	var log *os.File
+	if cfg != nil && cfg.LogOutput {
+		var err error
+		if log, err = createOutputLog(recipe, command, j.started); err != nil {
+			logDebug("Output log: %v", err)
+		}
+	}
+
+	done := track() // Until the output log is closed
+	go func() {
+		defer recoverCrash()
+		defer done()
+		defer close(j.events)
+		waitErr := make(chan error, 1)
+		go func() {
+			defer recoverCrash()
+			waitErr <- cmd.Wait()
+			pw.Close()
+		}()
+
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if log != nil {
+				fmt.Fprintln(log, scanner.Text())
+			}
+			j.events <- jobEvent{line: scanner.Text()}
+		}
+		if err := scanner.Err(); err != nil {
+			// Such as a line over the buffer. The rest is dropped rather
+			// than leaving the command blocked on a full pipe.
+			line := fmt.Sprintf("just-do-it: output not shown from here on: %v", err)
+			if log != nil {
+				fmt.Fprintln(log, line)
+			}
+			j.events <- jobEvent{line: line}
+			io.Copy(io.Discard, pr)
+		}
+
+		err := <-waitErr
+		exitCode := cmd.ProcessState.ExitCode()
+		if _, ok := err.(*exec.ExitError); ok {
+			err = nil // The exit code says it all
+		}
+		if log != nil {
+			closeOutputLog(log, jobEvent{exitCode: exitCode, err: err}, time.Since(j.started))
+		}
+		j.events <- jobEvent{done: true, exitCode: exitCode, err: err}
+	}()
+	return j
+}
+
+// waitForJob waits for the job's next event and takes those that came
+// along with it, so a chatty command updates the pane once per batch
+// rather than once per line.
+func waitForJob(j *job) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-j.events
+		if !ok {
+			return nil
+		}
+		msg := jobMsg{id: j.id, events: []jobEvent{ev}}
+		for !ev.done {
+			select {
+			case ev, ok = <-j.events:
+				if !ok {
+					return msg
+				}
+				msg.events = append(msg.events, ev)
+			default:
+				return msg
+			}
+		}
+		return msg
+	}
+}
+
+// handle applies an event to the job, returning whether more are coming.
+func (j *job) handle(ev jobEvent) bool {
+	if ev.done {
+		j.finished = true
+		j.exitCode = ev.exitCode
+		j.err = ev.err
+		j.duration = time.Since(j.started)
+		return false
+	}
+	j.output = append(j.output, ev.line)
+	if len(j.output) > maxOutputLines {
+		// A tenth at a time, rather than copying on every line
+		n := len(j.output) - maxOutputLines + maxOutputLines/10
+		j.output = append(j.output[:0], j.output[n:]...)
+		j.dropped += n
+	}
+	return true
+}
+
+// content is the output as shown in the pane.
+func (j *job) content() string {
+	s := strings.Join(j.output, "\n")
+	if j.dropped > 0 {
+		s = helpStyle.Render(fmt.Sprintf("… %d earlier lines not kept", j.dropped)) + "\n" + s
+	}
+	return s
+}
+
+// status renders a one-line summary of the job.
+func (j *job) status(spin string) string {
+	command := strings.Join(j.command, " ")
+	if j.limits != "" {
+		command += " [" + j.limits + "]"
+	}
+	switch {
+	case !j.finished:
+		if j.estimate > 0 {
+			spin += " " + progressBar(time.Since(j.started), j.estimate, 20)
+		}
+		if usage := j.usageSummary(); usage != "" {
+			return fmt.Sprintf("%s Running: %s (%s)", spin, command, usage)
+		}
+		return fmt.Sprintf("%s Running: %s", spin, command)
+	case j.err != nil:
+		return fmt.Sprintf("✗ %s: %v", command, j.err)
+	case j.exitCode == 0:
+		return fmt.Sprintf("✓ %s (exit 0, %s)", command, j.duration.Round(time.Millisecond))
+	default:
+		return fmt.Sprintf("✗ %s (exit %d, %s)", command, j.exitCode, j.duration.Round(time.Millisecond))
+	}
+}
+
+// runInApp starts a job for the command and switches to the output pane.
+func (m *model) runInApp(recipe string, command []string) tea.Cmd {
+	j := startJob(recipe, command, m.recipeEnv(recipe), m.recipeDir(recipe))
+	j.estimate = m.durations.estimate(recipe)
+	m.runEnv = nil // Overrides are for a single run
+	m.job = j
+	m.matrix = nil
+	m.state = viewOutput
+	m.inputs = nil
+	m.inputSources = nil
+
+	m.output = viewport.New(m.terminalWidth-4, m.outputHeight())
+	m.usageSeq++
+	return tea.Batch(m.spinTick(), waitForJob(j), m.sampleUsage())
+}
+
+// running reports whether an in-app run is still going.
+func (m model) running() bool {
+	if m.matrix != nil {
+		return !m.matrix.done()
+	}
+	return m.job != nil && !m.job.finished
+}
+
+// stopRuns cancels whatever is still running.
+func (m model) stopRuns() {
+	if m.matrix != nil {
+		m.matrix.stop()
+	} else if m.job != nil && !m.job.finished {
+		m.job.cancel()
+	}
+}
+
+// outputHeight is the height left for the output pane below the header.
+func (m model) outputHeight() int {
+	h := m.terminalHeight - 5
+	if m.matrix != nil {
+		h -= len(m.matrix.values) + 1
+	}
+	if m.watch != nil {
+		h--
+	}
+	return max(h, 1)
+}
+
+func (m model) handleJobMsg(msg jobMsg) (model, tea.Cmd) {
+	j := m.job
+	if m.matrix != nil {
+		j = m.matrix.find(msg.id)
+	}
+	if j == nil || j.id != msg.id {
+		return m, nil
+	}
+	more := true
+	for _, ev := range msg.events {
+		more = j.handle(ev)
+	}
+
+	if j == m.job {
+		atBottom := m.output.AtBottom()
+		m.output.SetContent(j.content())
+		if atBottom {
+			m.output.GotoBottom()
+		}
+	}
+
+	if more {
+		return m, waitForJob(j)
+	}
+	if _, ok := m.recipes[j.recipe]; ok {
+		if j.exitCode == 0 && j.err == nil {
+			if err := m.durations.record(j.recipe, j.duration); err != nil {
+				logDebug("Saving run durations failed: %v", err)
+			}
+		}
+		if err := recordRun(j); err != nil {
+			logDebug("Saving run stats failed: %v", err)
+		}
+	}
+	if m.matrix != nil {
+		cmds := m.matrix.startPending()
+		if m.job == nil {
+			m.showJob(m.matrix.selected)
+		}
+		return m, tea.Batch(cmds...)
+	}
+	return m, nil
+}
+
+func (m model) updateOutput(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel, m.keys.Quit):
+		if m.watch != nil {
+			// Watching outlives single runs, stop it before anything else
+			m.watch.stop()
+			m.watch = nil
+			m.output.Height = m.outputHeight()
+			return m, nil
+		}
+		if m.running() {
+			// First press stops the run, the next one leaves
+			m.stopRuns()
+			return m, nil
+		}
+		m.state = viewList
+		return m, nil
+	case m.matrix == nil && key.Matches(msg, m.keys.Watch):
+		var cmd tea.Cmd
+		if m.watch != nil {
+			m.watch.stop()
+			m.watch = nil
+		} else {
+			cmd = m.startWatch()
+		}
+		m.output.Height = m.outputHeight()
+		return m, cmd
+	case key.Matches(msg, m.keys.Rerun):
+		if m.running() {
+			return m, nil
+		}
+		if m.matrix != nil {
+			r := m.matrix
+			return m, m.runMatrix(&matrixRun{
+				batch:    r.batch,
+				recipe:   r.recipe,
+				param:    r.param,
+				values:   r.values,
+				commands: r.commands,
+				jobs:     make([]*job, len(r.values)),
+				limit:    r.limit,
+			})
+		}
+		m.keepRunDir()
+		return m, m.runInApp(m.job.recipe, m.job.command)
+	case m.matrix != nil && key.Matches(msg, m.keys.NextRun):
+		m.showJob((m.matrix.selected + 1) % len(m.matrix.values))
+		return m, nil
+	case m.matrix != nil && key.Matches(msg, m.keys.PrevRun):
+		m.showJob((m.matrix.selected + len(m.matrix.values) - 1) % len(m.matrix.values))
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.output, cmd = m.output.Update(msg)
+	return m, cmd
+}
+
+func (m model) outputView() string {
+	var header string
+	switch {
+	case m.matrix != nil && m.matrix.batch:
+		header = titleStyle.Render("Batch") + "\n" + m.matrix.summaryView(m.spinnerView())
+	case m.matrix != nil:
+		header = titleStyle.Render("Matrix: "+m.matrix.recipe) + "\n" + m.matrix.summaryView(m.spinnerView())
+	default:
+		header = titleStyle.Render(m.job.status(m.spinnerView()))
+		if m.watch != nil {
+			header += "\n" + helpStyle.Render("👁 "+m.watch.status())
+		}
+	}
+	body := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(activeTheme.Border).
+		Render(m.output.View())
+	return lipgloss.JoinVertical(lipgloss.Left, header, body)
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Everything that writes to a justfile goes through justfileDoc: edits are
+// made on parsed line spans rather than by patching text with regexes, which
+// leaves comments and whitespace alone, and save refuses to overwrite a file
+// that changed since it was read.
+
+var errJustfileChanged = errors.New("justfile changed on disk since it was read, reload and try again")
+
+// justfileNames are the file names just looks for, in order.
+var justfileNames = []string{"justfile", "Justfile", ".justfile"}
+
+// findJustfile looks for a justfile in dir and its parents, like just does.
+func findJustfile(dir string) (string, error) {
+	for {
+		for _, name := range justfileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no justfile found")
+		}
+		dir = parent
+	}
+}
+
+// rootJustfile is the justfile just uses: the one given with --justfile, or
+// the first found from the working directory up.
+func rootJustfile() (string, error) {
+	if justFlags.justfile != "" {
+		return justFlags.justfile, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return findJustfile(cwd)
+}
+
+type justfileDoc struct {
+	path     string
+	lines    []string
+	original []string
+	hash     [32]byte
+	modTime  time.Time
+	created  bool // Not on disk yet, save creates it
+}
+
+// lineSpan is a range of lines [Start, End) in a justfile.
+type lineSpan struct {
+	Start, End int
+}
+
+func readJustfile(path string) (*justfileDoc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	return &justfileDoc{
+		path:     path,
+		lines:    lines,
+		original: append([]string(nil), lines...),
+		hash:     sha256.Sum256(data),
+		modTime:  info.ModTime(),
+	}, nil
+}
+
+// newJustfile is an empty document for a justfile that doesn't exist yet.
+// Save creates it, refusing if another one was created meanwhile.
+func newJustfile(path string) *justfileDoc {
+	return &justfileDoc{path: path, lines: []string{""}, original: []string{""}, created: true}
+}
+
+// Changed reports whether any edits were made since the file was read.
+func (d *justfileDoc) Changed() bool {
+	return strings.Join(d.lines, "\n") != strings.Join(d.original, "\n")
+}
+
+// CheckUnchanged returns errJustfileChanged if the file on disk is no longer
+// what was read. Save does this too; calling it first lets multi-file edits
+// bail out before writing anything.
+func (d *justfileDoc) CheckUnchanged() error {
+	if d.created {
+		if _, err := os.Stat(d.path); !os.IsNotExist(err) {
+			return errJustfileChanged
+		}
+		return nil
+	}
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return err
+	}
+	current, err := os.ReadFile(d.path)
+	if err != nil {
+		return err
+	}
+	if !info.ModTime().Equal(d.modTime) || sha256.Sum256(current) != d.hash {
+		return errJustfileChanged
+	}
+	return nil
+}
+
+// Save writes the edited file back, refusing if it changed on disk since it
+// was read. The file is replaced atomically and keeps its permissions.
+func (d *justfileDoc) Save() error {
+	if err := d.CheckUnchanged(); err != nil {
+		return err
+	}
+	perm := os.FileMode(0644)
+	if !d.created {
+		info, err := os.Stat(d.path)
+		if err != nil {
+			return err
+		}
+		perm = info.Mode().Perm()
+	}
+
+	data := []byte(strings.Join(d.lines, "\n"))
+	tmp, err := os.CreateTemp(filepath.Dir(d.path), ".justfile-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), d.path); err != nil {
+		return err
+	}
+
+	// The document is now in sync with the file on disk
+	d.hash = sha256.Sum256(data)
+	d.original = append([]string(nil), d.lines...)
+	d.created = false
+	if info, err := os.Stat(d.path); err == nil {
+		d.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// recipeHeader parses a line as a recipe header and returns the recipe name.
+// Assignments, aliases, settings, comments and attributes are not headers.
+func recipeHeader(line string) (string, bool) {
+	name, _, ok := parseRecipeHeader(line)
+	return name, ok
+}
+
+// parseRecipeHeader is recipeHeader that also returns the index of the colon
+// separating parameters from dependencies.
+func parseRecipeHeader(line string) (name string, colon int, ok bool) {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '[' {
+		return "", 0, false
+	}
+	for _, kw := range []string{"alias ", "set ", "export ", "import ", "import? ", "mod ", "mod? "} {
+		if strings.HasPrefix(line, kw) {
+			return "", 0, false
+		}
+	}
+
+	start := 0
+	if line[0] == '@' {
+		start = 1
+	}
+	end := start
+	for end < len(line) && isNameChar(line[end]) {
+		end++
+	}
+	if end == start {
+		return "", 0, false
+	}
+	name = line[start:end]
+
+	// Find the header colon, skipping anything quoted in parameter defaults
+	var quote byte
+	for i := end; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == ':':
+			if i+1 < len(line) && line[i+1] == '=' {
+				return "", 0, false // name := value
+			}
+			return name, i, true
+		case c == '=' && i+1 < len(line) && line[i+1] == '=':
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isIndented(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
+
+// recipeSpan returns the lines making up a recipe: its header and indented
+// body. The doc comment and attributes above it are not included, see
+// recipeBlock for that.
+func (d *justfileDoc) recipeSpan(name string) (lineSpan, bool) {
+	for i, line := range d.lines {
+		if n, ok := recipeHeader(line); ok && n == name {
+			end := i + 1
+			for j := i + 1; j < len(d.lines); j++ {
+				if isIndented(d.lines[j]) {
+					end = j + 1
+				} else if strings.TrimSpace(d.lines[j]) != "" {
+					break
+				}
+			}
+			return lineSpan{Start: i, End: end}, true
+		}
+	}
+	return lineSpan{}, false
+}
+
+// recipeBlock is recipeSpan extended upwards over the comments and
+// attributes directly above the recipe.
+func (d *justfileDoc) recipeBlock(name string) (lineSpan, bool) {
+	span, ok := d.recipeSpan(name)
+	if !ok {
+		return span, false
+	}
+	for span.Start > 0 {
+		prev := d.lines[span.Start-1]
+		if !strings.HasPrefix(prev, "#") && !strings.HasPrefix(prev, "[") {
+			break
+		}
+		span.Start--
+	}
+	return span, true
+}
+
+// Lines returns a copy of the lines in span.
+func (d *justfileDoc) Lines(span lineSpan) []string {
+	return append([]string(nil), d.lines[span.Start:span.End]...)
+}
+
+// Replace swaps the lines in span for the given ones.
+func (d *justfileDoc) Replace(span lineSpan, lines []string) {
+	out := append([]string(nil), d.lines[:span.Start]...)
+	out = append(out, lines...)
+	d.lines = append(out, d.lines[span.End:]...)
+}
+
+// Insert adds lines before line index at.
+func (d *justfileDoc) Insert(at int, lines []string) {
+	d.Replace(lineSpan{Start: at, End: at}, lines)
+}
+
+// Append adds a block at the end of the file, separated by a blank line.
+func (d *justfileDoc) Append(lines []string) {
+	// Keep the trailing newline (an empty last element) at the very end
+	at := len(d.lines)
+	if at > 0 && d.lines[at-1] == "" {
+		at--
+	}
+	if at > 0 && strings.TrimSpace(d.lines[at-1]) != "" {
+		lines = append([]string{""}, lines...)
+	}
+	d.Insert(at, lines)
+}
+
+// Diff renders the pending edits as a unified-style line diff.
+func (d *justfileDoc) Diff() string {
+	return lineDiff(d.original, d.lines)
+}
+
+// diffContext is how many unchanged lines are shown around each change.
+const diffContext = 2
+
+// lineDiff is a small LCS based diff, plenty for justfile sized inputs. Only
+// changed lines and a little context around them are included.
+func lineDiff(a, b []string) string {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type op struct {
+		kind byte // ' ', '+' or '-'
+		line string
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+
+	// Mark which lines are close enough to a change to be shown
+	show := make([]bool, len(ops))
+	for k, o := range ops {
+		if o.kind == ' ' {
+			continue
+		}
+		for c := max(0, k-diffContext); c <= min(len(ops)-1, k+diffContext); c++ {
+			show[c] = true
+		}
+	}
+
+	var buf bytes.Buffer
+	skipped := false
+	for k, o := range ops {
+		if !show[k] {
+			skipped = true
+			continue
+		}
+		if skipped && buf.Len() > 0 {
+			buf.WriteString("  …\n")
+		}
+		skipped = false
+		fmt.Fprintf(&buf, "%c %s\n", o.kind, o.line)
+	}
+	return buf.String()
+}
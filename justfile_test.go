@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRecipeHeader(t *testing.T) {
+	tests := []struct {
+		line  string
+		name  string
+		colon int
+		ok    bool
+	}{
+		{"build:", "build", 5, true},
+		{"@quiet:", "quiet", 6, true},
+		{"test-all: build lint", "test-all", 8, true},
+		{"deploy env target='a:b': build", "deploy", 23, true},
+		{`greet name="x:y":`, "greet", 16, true},
+		{"name := value", "", 0, false},
+		{"alias b := build", "", 0, false},
+		{"set shell := ['bash', '-c']", "", 0, false},
+		{"export PATH := 'x'", "", 0, false},
+		{"import 'other.just'", "", 0, false},
+		{"mod docs", "", 0, false},
+		{"# build:", "", 0, false},
+		{"[private]", "", 0, false},
+		{"    echo build:", "", 0, false},
+		{"", "", 0, false},
+		{"x == y:", "", 0, false},
+	}
+	for _, tt := range tests {
+		name, colon, ok := parseRecipeHeader(tt.line)
+		if name != tt.name || colon != tt.colon || ok != tt.ok {
+			t.Errorf("parseRecipeHeader(%q) = %q, %d, %v, want %q, %d, %v", tt.line, name, colon, ok, tt.name, tt.colon, tt.ok)
+		}
+	}
+}
+
+func TestRecipeSpan(t *testing.T) {
+	doc := &justfileDoc{lines: strings.Split(`# Builds it
+[group('dev')]
+build:
+    go build
+
+    go vet
+test: build
+    go test
+lint:`, "\n")}
+
+	tests := []struct {
+		name string
+		span lineSpan
+		ok   bool
+	}{
+		{"build", lineSpan{2, 6}, true},
+		{"test", lineSpan{6, 8}, true},
+		{"lint", lineSpan{8, 9}, true},
+		{"missing", lineSpan{}, false},
+	}
+	for _, tt := range tests {
+		span, ok := doc.recipeSpan(tt.name)
+		if span != tt.span || ok != tt.ok {
+			t.Errorf("recipeSpan(%q) = %v, %v, want %v, %v", tt.name, span, ok, tt.span, tt.ok)
+		}
+	}
+
+	if span, _ := doc.recipeBlock("build"); span != (lineSpan{0, 6}) {
+		t.Errorf("recipeBlock(build) = %v, want {0 6}", span)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want string
+	}{
+		{"same", []string{"a", "b"}, []string{"a", "b"}, ""},
+		{"added", []string{"a"}, []string{"a", "b"}, "  a\n+ b\n"},
+		{"removed", []string{"a", "b"}, []string{"b"}, "- a\n  b\n"},
+		{"changed", []string{"a", "b", "c"}, []string{"a", "x", "c"}, "  a\n- b\n+ x\n  c\n"},
+		{
+			"apart",
+			[]string{"1", "2", "3", "4", "5", "6", "7", "8"},
+			[]string{"x", "2", "3", "4", "5", "6", "7", "y"},
+			"- 1\n+ x\n  2\n  3\n  …\n  6\n  7\n- 8\n+ y\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := lineDiff(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: lineDiff() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
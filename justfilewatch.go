@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The justfile, its imports and module files are checked for changes while
+// the tool is open. When one changes, the recipes are reloaded and the
+// preview shown is fetched again, so what's read always matches what runs.
+
+const justfileCheckInterval = time.Second
+
+// justfileCheckMsg carries the modification times of the justfile's
+// sources.
+type justfileCheckMsg map[string]time.Time
+
+// checkJustfile looks at the justfile's sources after a while.
+func checkJustfile() tea.Cmd {
+	return tea.Tick(justfileCheckInterval, func(time.Time) tea.Msg {
+		if len(workspace) > 0 {
+			return justfileCheckMsg(workspaceSources())
+		}
+		dir, _ := os.Getwd()
+		return justfileCheckMsg(sourceFiles(daemonRequest{Dir: dir, Args: justArgs()}))
+	})
+}
+
+func (m model) handleJustfileCheck(msg justfileCheckMsg) (model, tea.Cmd) {
+	changed := m.sources != nil && !sameModTimes(m.sources, msg)
+	m.sources = msg
+	if !changed {
+		return m, checkJustfile()
+	}
+	logDebug("Justfile changed, reloading the recipes")
+	return m, tea.Batch(checkJustfile(), reloadRecipes)
+}
+
+func sameModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, mod := range a {
+		if other, ok := b[path]; !ok || !other.Equal(mod) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// checkFormat runs `just --fmt --check` and asks for confirmation with the
+// diff when the justfile isn't formatted yet.
+func checkFormat() tea.Msg {
+	path, err := rootJustfile()
+	if err != nil {
+		return err
+	}
+	doc, err := readJustfile(path)
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := justCommand("--color", "always", "--fmt", "--check", "--unstable")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err == nil {
+		return statusMsg("Justfile is already formatted")
+	}
+	if len(out) == 0 {
+		return fmt.Errorf("just --fmt failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return confirmMsg{
+		title: "Format justfile?",
+		body:  string(out),
+		onYes: applyFormat(doc),
+	}
+}
+
+// applyFormat formats the justfile, unless it changed since the diff that
+// was confirmed was made.
+func applyFormat(doc *justfileDoc) tea.Cmd {
+	return func() tea.Msg {
+		if err := doc.CheckUnchanged(); err != nil {
+			return err
+		}
+		if err := formatJustfile(); err != nil {
+			return err
+		}
+		return reloadRecipes()
+	}
+}
+
+func formatJustfile() error {
+	out, err := justCommand("--fmt", "--unstable").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("just --fmt failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// afterJustfileWrite runs after any change this tool makes to the justfile:
+// it formats the file if auto_format is enabled and reloads the recipes.
+func afterJustfileWrite() tea.Msg {
+	if cfg, _ := LoadConfig(); cfg != nil && cfg.AutoFormat {
+		if err := formatJustfile(); err != nil {
+			return err
+		}
+	}
+	return reloadRecipes()
+}
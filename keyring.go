@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"sync"
+)
+
+// API keys are kept in the OS keychain when there is one, under this
+// service name with the provider ID as the account. The config file is the
+// fallback.
+const keyringService = "just-do-it"
+
+var (
+	errNoKeyring   = errors.New("no keychain available")
+	errKeyNotFound = errors.New("key not found in keychain")
+)
+
+// keyringCache avoids asking the keychain tool again for every lookup.
+var keyringCache = struct {
+	sync.Mutex
+	keys map[string]string
+}{keys: map[string]string{}}
+
+func keyringGet(account string) (string, error) {
+	keyringCache.Lock()
+	defer keyringCache.Unlock()
+	if key, ok := keyringCache.keys[account]; ok {
+		return key, nil
+	}
+	key, err := keychainGet(account)
+	if errors.Is(err, errKeyNotFound) {
+		keyringCache.keys[account] = ""
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	keyringCache.keys[account] = key
+	return key, nil
+}
+
+func keyringSet(account, key string) error {
+	keyringCache.Lock()
+	defer keyringCache.Unlock()
+	if err := keychainSet(account, key); err != nil {
+		return err
+	}
+	keyringCache.keys[account] = key
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The macOS Keychain, through the security tool.
+
+func keychainGet(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", keyringService, "-a", account, "-w").Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 44 {
+		return "", errKeyNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errNoKeyring, err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func keychainSet(account, key string) error {
+	// Interactive mode reads the command from stdin, which keeps the key
+	// out of the process list
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		shellQuote(keyringService), shellQuote(account), shellQuote(key)))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %v: %s", errNoKeyring, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
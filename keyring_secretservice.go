@@ -0,0 +1,44 @@
+//go:build !darwin && !windows
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The Secret Service (GNOME Keyring, KWallet), through secret-tool.
+
+func keychainGet(account string) (string, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return "", errNoKeyring
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", keyringService, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && stderr.Len() == 0 {
+		return "", errKeyNotFound // Exits quietly when there's no such key
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errNoKeyring, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func keychainSet(account, key string) error {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return errNoKeyring
+	}
+	cmd := exec.Command("secret-tool", "store", "--label", "just-do-it "+account+" API key",
+		"service", keyringService, "account", account)
+	cmd.Stdin = strings.NewReader(key)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", errNoKeyring, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
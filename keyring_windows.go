@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/danieljoos/wincred"
+)
+
+// The Windows Credential Manager, as generic credentials named
+// "just-do-it:<provider>".
+
+func keychainGet(account string) (string, error) {
+	cred, err := wincred.GetGenericCredential(keyringService + ":" + account)
+	if errors.Is(err, wincred.ErrElementNotFound) {
+		return "", errKeyNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errNoKeyring, err)
+	}
+	return string(cred.CredentialBlob), nil
+}
+
+func keychainSet(account, key string) error {
+	cred := wincred.NewGenericCredential(keyringService + ":" + account)
+	cred.UserName = account
+	cred.CredentialBlob = []byte(key)
+	cred.Persist = wincred.PersistLocalMachine
+	if err := cred.Write(); err != nil {
+		return fmt.Errorf("%w: %v", errNoKeyring, err)
+	}
+	return nil
+}
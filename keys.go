@@ -0,0 +1,473 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyMap holds every binding the app responds to. The footer help is built
+// from these, so a binding's help text is what the user sees.
+type keyMap struct {
+	// Global
+	ForceQuit key.Binding
+
+	// List view
+	Up         key.Binding
+	Down       key.Binding
+	Select     key.Binding
+	Search     key.Binding
+	AISettings key.Binding
+	RunInApp   key.Binding
+	Explain    key.Binding
+	Projects   key.Binding
+	FocusPane  key.Binding
+	GrowList   key.Binding
+	ShrinkList key.Binding
+	Mark       key.Binding
+	Quit       key.Binding
+	Cancel     key.Binding
+
+	// Parameter form
+	NextField  key.Binding
+	PrevField  key.Binding
+	FindFile   key.Binding
+	PrevChoice key.Binding
+	NextChoice key.Binding
+	Refine     key.Binding
+	PrevValue  key.Binding
+	NextValue  key.Binding
+
+	// Preview visual-select
+	Copy key.Binding
+
+	// Output pane
+	Rerun   key.Binding
+	NextRun key.Binding
+	PrevRun key.Binding
+	Watch   key.Binding
+
+	// Confirmation screens
+	Yes key.Binding
+	No  key.Binding
+
+	// Error screen
+	Retry     key.Binding
+	CopyError key.Binding
+
+	// Prompt template editor
+	SavePrompt  key.Binding
+	ResetPrompt key.Binding
+
+	// File browser
+	ToggleHidden key.Binding
+
+	// Output logs
+	Duplicate key.Binding
+
+	// Leader starts a multi-key sequence, the bindings below are only
+	// matched on the key following it.
+	Leader       key.Binding
+	Reload       key.Binding
+	LineNumbers  key.Binding
+	VisualSelect key.Binding
+	Format       key.Binding
+	Rename       key.Binding
+	Diagnostics  key.Binding
+	Matrix       key.Binding
+	GroupFilter  key.Binding
+	SendTmux     key.Binding
+	Wrapper      key.Binding
+	Shell        key.Binding
+	Semantic     key.Binding
+	AILog        key.Binding
+	Theme        key.Binding
+	Parallel     key.Binding
+	NewRecipe    key.Binding
+	GroupJump    key.Binding
+	Graph        key.Binding
+	RecipeDiff   key.Binding
+	EnvEditor    key.Binding
+	VarEditor    key.Binding
+	OpenPane     key.Binding
+	OutputLogs   key.Binding
+	ShowPrivate  key.Binding
+	Zen          key.Binding
+	EditPrompt   key.Binding
+	RunDir       key.Binding
+	Stats        key.Binding
+	UpdateCheck  key.Binding
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		ForceQuit: key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+
+		Up:         key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:       key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		Select:     key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "select")),
+		Search:     key.NewBinding(key.WithKeys("/"), key.WithHelp("type", "search")),
+		AISettings: key.NewBinding(key.WithKeys("ctrl+p"), key.WithHelp("ctrl+p", "ai settings")),
+		RunInApp:   key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", "run here")),
+		Explain:    key.NewBinding(key.WithKeys("ctrl+e"), key.WithHelp("ctrl+e", "explain")),
+		Projects:   key.NewBinding(key.WithKeys("ctrl+o"), key.WithHelp("ctrl+o", "projects")),
+		FocusPane:  key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "scroll preview")),
+		GrowList:   key.NewBinding(key.WithKeys("ctrl+right"), key.WithHelp("ctrl+→", "wider list")),
+		ShrinkList: key.NewBinding(key.WithKeys("ctrl+left"), key.WithHelp("ctrl+←", "narrower list")),
+		Mark:       key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "mark for batch")),
+		Quit:       key.NewBinding(key.WithKeys("q"), key.WithHelp("q", "quit")),
+		Cancel:     key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+
+		NextField: key.NewBinding(key.WithKeys("tab", "down"), key.WithHelp("tab", "next field")),
+		PrevField: key.NewBinding(key.WithKeys("shift+tab", "up"), key.WithHelp("shift+tab", "prev field")),
+		FindFile:  key.NewBinding(key.WithKeys("ctrl+f"), key.WithHelp("ctrl+f", "find file")),
+
+		PrevChoice: key.NewBinding(key.WithKeys("left"), key.WithHelp("←", "prev value")),
+		NextChoice: key.NewBinding(key.WithKeys("right"), key.WithHelp("→", "next value")),
+		Refine:     key.NewBinding(key.WithKeys("ctrl+t"), key.WithHelp("ctrl+t", "refine")),
+		PrevValue:  key.NewBinding(key.WithKeys("ctrl+p"), key.WithHelp("ctrl+p", "earlier value")),
+		NextValue:  key.NewBinding(key.WithKeys("ctrl+n"), key.WithHelp("ctrl+n", "later value")),
+
+		Copy: key.NewBinding(key.WithKeys("y", "enter"), key.WithHelp("y", "copy")),
+
+		Rerun:   key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "re-run")),
+		NextRun: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next value")),
+		PrevRun: key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "prev value")),
+		Watch:   key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "watch")),
+
+		Yes: key.NewBinding(key.WithKeys("y", "enter"), key.WithHelp("y", "confirm")),
+		No:  key.NewBinding(key.WithKeys("n"), key.WithHelp("n/esc", "cancel")),
+
+		Retry:     key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "retry")),
+		CopyError: key.NewBinding(key.WithKeys("y"), key.WithHelp("y", "copy error")),
+
+		SavePrompt:  key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "save")),
+		ResetPrompt: key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", "reset to default")),
+
+		ToggleHidden: key.NewBinding(key.WithKeys("ctrl+a"), key.WithHelp("ctrl+a", "hidden files")),
+
+		Duplicate: key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "edit & run")),
+
+		Leader:       key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("ctrl+x", "more")),
+		Reload:       key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "reload recipes")),
+		LineNumbers:  key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "line numbers")),
+		VisualSelect: key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "select lines to copy")),
+		Format:       key.NewBinding(key.WithKeys("f"), key.WithHelp("f", "format justfile")),
+		Rename:       key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "rename recipe")),
+		Diagnostics:  key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "diagnostics")),
+		Matrix:       key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "matrix run over field")),
+		GroupFilter:  key.NewBinding(key.WithKeys("g"), key.WithHelp("g", "next group filter")),
+		SendTmux:     key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "send to tmux pane")),
+		Wrapper:      key.NewBinding(key.WithKeys("w"), key.WithHelp("w", "next exec wrapper")),
+		Shell:        key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "next ai shell")),
+		Semantic:     key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search by intent")),
+		AILog:        key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "ai request log")),
+		Theme:        key.NewBinding(key.WithKeys("T"), key.WithHelp("T", "theme")),
+		Parallel:     key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "toggle parallel batch")),
+		NewRecipe:    key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "add starter recipes")),
+		GroupJump:    key.NewBinding(key.WithKeys("G"), key.WithHelp("G", "jump to group")),
+		Graph:        key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "dependency graph")),
+		RecipeDiff:   key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "changes since git ref")),
+		EnvEditor:    key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "run with env overrides")),
+		VarEditor:    key.NewBinding(key.WithKeys("V"), key.WithHelp("V", "override variables")),
+		OpenPane:     key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "open in new pane")),
+		OutputLogs:   key.NewBinding(key.WithKeys("L"), key.WithHelp("L", "output logs")),
+		ShowPrivate:  key.NewBinding(key.WithKeys("h"), key.WithHelp("h", "show private recipes")),
+		Zen:          key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "zen mode")),
+		EditPrompt:   key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "ai prompt template")),
+		RunDir:       key.NewBinding(key.WithKeys("W"), key.WithHelp("W", "run in directory")),
+		Stats:        key.NewBinding(key.WithKeys("S"), key.WithHelp("S", "run stats")),
+		UpdateCheck:  key.NewBinding(key.WithKeys("U"), key.WithHelp("U", "check for updates")),
+	}
+}
+
+// bindings names every binding as it is referred to in the config's
+// keybindings section.
+func (k *keyMap) bindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"force_quit":    &k.ForceQuit,
+		"up":            &k.Up,
+		"down":          &k.Down,
+		"select":        &k.Select,
+		"search":        &k.Search,
+		"ai_settings":   &k.AISettings,
+		"run_in_app":    &k.RunInApp,
+		"explain":       &k.Explain,
+		"projects":      &k.Projects,
+		"focus_pane":    &k.FocusPane,
+		"grow_list":     &k.GrowList,
+		"shrink_list":   &k.ShrinkList,
+		"refine":        &k.Refine,
+		"prev_value":    &k.PrevValue,
+		"next_value":    &k.NextValue,
+		"mark":          &k.Mark,
+		"quit":          &k.Quit,
+		"cancel":        &k.Cancel,
+		"next_field":    &k.NextField,
+		"prev_field":    &k.PrevField,
+		"find_file":     &k.FindFile,
+		"prev_choice":   &k.PrevChoice,
+		"next_choice":   &k.NextChoice,
+		"copy":          &k.Copy,
+		"rerun":         &k.Rerun,
+		"next_run":      &k.NextRun,
+		"prev_run":      &k.PrevRun,
+		"watch":         &k.Watch,
+		"yes":           &k.Yes,
+		"no":            &k.No,
+		"retry":         &k.Retry,
+		"copy_error":    &k.CopyError,
+		"save_prompt":   &k.SavePrompt,
+		"reset_prompt":  &k.ResetPrompt,
+		"toggle_hidden": &k.ToggleHidden,
+		"duplicate":     &k.Duplicate,
+		"leader":        &k.Leader,
+		"reload":        &k.Reload,
+		"line_numbers":  &k.LineNumbers,
+		"visual_select": &k.VisualSelect,
+		"format":        &k.Format,
+		"rename":        &k.Rename,
+		"diagnostics":   &k.Diagnostics,
+		"matrix":        &k.Matrix,
+		"group_filter":  &k.GroupFilter,
+		"send_tmux":     &k.SendTmux,
+		"wrapper":       &k.Wrapper,
+		"shell":         &k.Shell,
+		"semantic":      &k.Semantic,
+		"ai_log":        &k.AILog,
+		"theme":         &k.Theme,
+		"parallel":      &k.Parallel,
+		"new_recipe":    &k.NewRecipe,
+		"group_jump":    &k.GroupJump,
+		"graph":         &k.Graph,
+		"recipe_diff":   &k.RecipeDiff,
+		"env_editor":    &k.EnvEditor,
+		"var_editor":    &k.VarEditor,
+		"open_pane":     &k.OpenPane,
+		"output_logs":   &k.OutputLogs,
+		"show_private":  &k.ShowPrivate,
+		"zen":           &k.Zen,
+		"edit_prompt":   &k.EditPrompt,
+		"run_dir":       &k.RunDir,
+		"stats":         &k.Stats,
+		"update_check":  &k.UpdateCheck,
+	}
+}
+
+// keyContexts are the bindings that are active at the same time; a key may
+// only be bound once within each.
+var keyContexts = map[string][]string{
+	"list":           {"force_quit", "up", "down", "select", "search", "ai_settings", "run_in_app", "explain", "projects", "focus_pane", "grow_list", "shrink_list", "mark", "quit", "cancel", "leader"},
+	"parameter form": {"force_quit", "next_field", "prev_field", "find_file", "prev_choice", "next_choice", "select", "run_in_app", "explain", "refine", "prev_value", "next_value", "cancel", "leader"},
+	"output pane":    {"force_quit", "cancel", "quit", "rerun", "next_run", "prev_run", "watch"},
+	"preview select": {"force_quit", "up", "down", "copy", "cancel"},
+	"confirmation":   {"force_quit", "yes", "no", "cancel"},
+	"error screen":   {"force_quit", "retry", "copy_error"},
+	"prompt editor":  {"force_quit", "save_prompt", "reset_prompt", "cancel"},
+	"file browser":   {"force_quit", "select", "toggle_hidden", "cancel"},
+	"output logs":    {"force_quit", "up", "down", "select", "duplicate", "cancel", "quit"},
+	"leader":         {"reload", "line_numbers", "visual_select", "format", "rename", "diagnostics", "matrix", "group_filter", "send_tmux", "wrapper", "shell", "semantic", "ai_log", "theme", "parallel", "new_recipe", "group_jump", "graph", "recipe_diff", "env_editor", "var_editor", "open_pane", "output_logs", "show_private", "zen", "edit_prompt", "run_dir", "stats", "update_check"},
+}
+
+// loadKeyMap applies the config's keybindings over the defaults. Unknown
+// actions and keys bound twice in the same context are reported as errors.
+func loadKeyMap(cfg *Config) (keyMap, error) {
+	k := defaultKeyMap()
+	if cfg == nil || len(cfg.KeyBindings) == 0 {
+		return k, nil
+	}
+
+	var errs []error
+	bindings := k.bindings()
+	for action, keys := range cfg.KeyBindings {
+		b, ok := bindings[action]
+		if !ok {
+			errs = append(errs, fmt.Errorf("keybindings: unknown action %q", action))
+			continue
+		}
+		if len(keys) == 0 {
+			errs = append(errs, fmt.Errorf("keybindings: no keys for %q", action))
+			continue
+		}
+		b.SetKeys(keys...)
+		b.SetHelp(strings.Join(keys, "/"), b.Help().Desc)
+	}
+
+	var contexts []string
+	for name := range keyContexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	for _, context := range contexts {
+		owner := map[string]string{}
+		for _, action := range keyContexts[context] {
+			for _, kb := range bindings[action].Keys() {
+				if other, taken := owner[kb]; taken {
+					errs = append(errs, fmt.Errorf("keybindings: %q is bound to both %s and %s in the %s", kb, other, action, context))
+					continue
+				}
+				owner[kb] = action
+			}
+		}
+	}
+	return k, errors.Join(errs...)
+}
+
+// leaderBindings are the actions reachable after pressing the leader key.
+func (k keyMap) leaderBindings() []key.Binding {
+	return []key.Binding{k.Reload, k.LineNumbers, k.VisualSelect, k.Format, k.Rename, k.Diagnostics, k.Matrix, k.GroupFilter, k.SendTmux, k.Wrapper, k.Shell, k.Semantic, k.AILog, k.Theme, k.Parallel, k.NewRecipe, k.GroupJump, k.Graph, k.RecipeDiff, k.EnvEditor, k.VarEditor, k.OpenPane, k.OutputLogs, k.ShowPrivate, k.Zen, k.EditPrompt, k.RunDir, k.Stats, k.UpdateCheck}
+}
+
+// hint renders a binding as "key: description", with an optional override
+// for the description so hints can adapt to context.
+func hint(b key.Binding, desc ...string) string {
+	h := b.Help()
+	if len(desc) > 0 {
+		h.Desc = desc[0]
+	}
+	return fmt.Sprintf("%s: %s", h.Key, h.Desc)
+}
+
+// handleLeader resolves the key pressed after the leader. It returns false if
+// the key isn't bound, in which case the sequence is simply dropped.
+func (m *model) handleLeader(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, m.keys.Reload):
+		return reloadRecipes, true
+	case key.Matches(msg, m.keys.LineNumbers):
+		m.lineNumbers = !m.lineNumbers
+		m.renderPreview()
+		return nil, true
+	case key.Matches(msg, m.keys.VisualSelect):
+		if m.state == viewList {
+			m.startPreviewSelect()
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.Format):
+		return checkFormat, true
+	case key.Matches(msg, m.keys.Diagnostics):
+		if m.state == viewList {
+			m.state = viewDiagnostics
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.Matrix):
+		if m.state == viewInput {
+			return m.startMatrix(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.GroupFilter):
+		if m.state == viewList {
+			return m.cycleGroupFilter(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.SendTmux):
+		m.startTmuxPicker()
+		return nil, true
+	case key.Matches(msg, m.keys.Shell):
+		// Only for this session, the config sets the default
+		*m.aiShell = nextShell(*m.aiShell)
+		if m.state == viewInput && m.selectedRecipe.Name == "AI Command" {
+			m.aiProblem = ""
+			return checkShellSyntax(*m.aiShell, m.inputs[0].Value()), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.Wrapper):
+		return cycleWrapper, true
+	case key.Matches(msg, m.keys.AILog):
+		if m.state == viewList {
+			m.startAILog()
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.Graph):
+		if m.state == viewList {
+			m.startDependencyGraph()
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.RecipeDiff):
+		if m.state == viewList {
+			return m.startRecipeDiff(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.EnvEditor):
+		if m.state == viewList {
+			return m.startEnvEditor(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.OpenPane):
+		return m.startOpenPane(), true
+	case key.Matches(msg, m.keys.ShowPrivate):
+		if m.state == viewList {
+			return m.toggleShowPrivate(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.RunDir):
+		if m.state == viewList {
+			return m.startDirPicker(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.EditPrompt):
+		if m.state == viewList {
+			return m.startPromptEditor(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.Zen):
+		if m.state == viewList {
+			return m.toggleZen(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.UpdateCheck):
+		if m.state == viewList {
+			return m.startUpdateCheck(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.Stats):
+		if m.state == viewList {
+			return m.startStats(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.OutputLogs):
+		if m.state == viewList {
+			return m.startOutputLogs(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.VarEditor):
+		if m.state == viewList {
+			return m.startVarEditor(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.GroupJump):
+		if m.state == viewList {
+			return m.startGroupJump(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.NewRecipe):
+		if m.state == viewList {
+			return addStarterRecipes(m.recipes), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.Parallel):
+		m.batchParallel = !m.batchParallel
+		help := batchHelp(m.batchParallel)
+		return func() tea.Msg { return statusMsg(help) }, true
+	case key.Matches(msg, m.keys.Theme):
+		if m.state == viewList {
+			m.startThemePicker()
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.Semantic):
+		if m.state == viewList {
+			return m.startSemantic(), true
+		}
+		return nil, true
+	case key.Matches(msg, m.keys.Rename):
+		if m.state == viewList {
+			return m.startRename(), true
+		}
+		return nil, true
+	}
+	return nil, false
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The list and the preview split the screen side by side. ctrl+left and
+// ctrl+right move the split, which is saved as "split_ratio"; tab moves the
+// keys over to the preview to scroll it, and ctrl+x z hides the preview.
+
+const (
+	defaultSplit = 0.35
+	minSplit     = 0.2
+	maxSplit     = 0.8
+	splitStep    = 0.05
+)
+
+// splitRatio is the share of the width the list takes, as configured.
+func splitRatio(cfg *Config) float64 {
+	if cfg == nil || cfg.SplitRatio == 0 {
+		return defaultSplit
+	}
+	return min(max(cfg.SplitRatio, minSplit), maxSplit)
+}
+
+// layout sizes the list and the preview to the terminal.
+func (m *model) layout() {
+	listWidth := int(float64(m.terminalWidth) * m.split)
+	if m.zen {
+		listWidth = m.terminalWidth - 2
+	}
+	viewportWidth := max(m.terminalWidth-listWidth-8, 0)
+
+	headerHeight := lipgloss.Height(m.list.Title)
+	listFooterHeight := 2
+	globalFooterHeight := 1
+
+	m.list.SetSize(listWidth, m.terminalHeight-headerHeight-listFooterHeight-globalFooterHeight)
+	m.viewport.Width = viewportWidth
+	m.viewport.Height = m.terminalHeight - 2 - globalFooterHeight
+}
+
+// relayout applies a change to the layout, rendering the preview again for
+// the new width.
+func (m *model) relayout() tea.Cmd {
+	m.layout()
+	if i, ok := m.list.SelectedItem().(recipeItem); ok {
+		return m.updateViewportContent(i.name)
+	}
+	m.renderPreview()
+	return nil
+}
+
+// resizeSplit moves the split by delta and saves it.
+func (m *model) resizeSplit(delta float64) tea.Cmd {
+	if m.zen {
+		return nil
+	}
+	m.split = min(max(m.split+delta, minSplit), maxSplit)
+	cmd := m.relayout()
+
+	status := fmt.Sprintf("List %d%% of the width", int(m.split*100+0.5))
+	cfg, _ := LoadConfig()
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	cfg.SplitRatio = m.split
+	if err := SaveConfig(cfg); err != nil {
+		status = fmt.Sprintf("Saving the split failed: %v", err)
+	}
+	return tea.Batch(cmd, func() tea.Msg { return statusMsg(status) })
+}
+
+// toggleZen hides the preview, or shows it again.
+func (m *model) toggleZen() tea.Cmd {
+	m.zen = !m.zen
+	m.previewFocus = false
+	return m.relayout()
+}
+
+// updatePreviewFocus scrolls the preview while it has the keys, until tab
+// or esc gives them back to the list.
+func (m model) updatePreviewFocus(msg tea.KeyMsg) (model, tea.Cmd) {
+	if key.Matches(msg, m.keys.FocusPane, m.keys.Cancel) {
+		m.previewFocus = false
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Reading hundreds of recipes with `just --dump` takes a while. From
+// lazyLoadMin recipes on, the list starts from the names `just --summary`
+// prints and the dump, with docs, parameters and groups, follows in the
+// background; running a recipe waits for it. While the cursor moves, the
+// preview is only fetched once it rests on a recipe.
+
+const (
+	lazyLoadMin     = 200
+	previewDebounce = 80 * time.Millisecond
+)
+
+// justSummary lists the recipe names, those of the workspace's projects
+// included.
+func justSummary() ([]string, error) {
+	var names []string
+	if _, err := rootJustfile(); err == nil || len(workspace) == 0 {
+		output, err := justOutput("--summary")
+		if err != nil {
+			return nil, err
+		}
+		names = strings.Fields(string(output))
+	}
+	for _, p := range workspace {
+		output, err := justOutputArgs([]string{"--justfile", p.justfile, "--working-directory", p.dir, "--summary"})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		for _, name := range strings.Fields(string(output)) {
+			names = append(names, p.name+moduleSep+name)
+		}
+	}
+	return names, nil
+}
+
+// loadInitialDump reads the recipes at start. partial reports that only
+// their names are known, with the dump still to be loaded.
+func loadInitialDump() (dump *JustDump, partial bool, err error) {
+	if names, err := justSummary(); err == nil && len(names) >= lazyLoadMin {
+		dump := &JustDump{Recipes: map[string]Recipe{}, Aliases: map[string]Alias{}}
+		for _, name := range names {
+			dump.Recipes[name] = Recipe{Name: name}
+		}
+		return dump, true, nil
+	}
+	dump, err = getJustDump()
+	return dump, false, err
+}
+
+// previewDueMsg asks for the preview of the recipe, if it's still the one
+// selected.
+type previewDueMsg string
+
+func schedulePreview(name string) tea.Cmd {
+	return tea.Tick(previewDebounce, func(time.Time) tea.Msg { return previewDueMsg(name) })
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SIGINT and SIGTERM quit the TUI the way ctrl+c does, and either way
+// shutdown runs on the way out: AI requests and in-app runs get their
+// context from appContext, which it cancels, so requests are dropped and
+// recipes are stopped along with the processes they started. Work that
+// writes on its way out, such as an output log being closed, is waited for
+// briefly. A second signal exits at once.
+
+const flushTimeout = 3 * time.Second // Longer than a stopped run's WaitDelay
+
+var lifecycle struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pending sync.WaitGroup
+
+	mu     sync.Mutex
+	signal os.Signal // The one that ended the TUI, if any
+}
+
+func init() {
+	lifecycle.ctx, lifecycle.cancel = context.WithCancel(context.Background())
+}
+
+// appContext is cancelled when the app shuts down.
+func appContext() context.Context {
+	return lifecycle.ctx
+}
+
+// track marks work that shutdown waits for, until the returned func is
+// called. It's called before the work's goroutine is started, from Update.
+func track() func() {
+	lifecycle.pending.Add(1)
+	return lifecycle.pending.Done
+}
+
+// handleSignals quits the program on SIGINT or SIGTERM, cancelling what's
+// in flight first. On a second one it restores the terminal and exits.
+func handleSignals(p *tea.Program) {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer recoverCrash()
+		sig := <-sigs
+		logDebug("Received %v, shutting down", sig)
+		lifecycle.mu.Lock()
+		lifecycle.signal = sig
+		lifecycle.mu.Unlock()
+		lifecycle.cancel()
+		p.Quit()
+
+		<-sigs
+		restoreTerminal()
+		popTerminalTitle()
+		os.Exit(signalExitCode(sig))
+	}()
+}
+
+// cancelOnSignal cancels appContext on SIGINT or SIGTERM until the returned
+// func is called, for commands outside the TUI that wait on a request.
+func cancelOnSignal() func() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+			lifecycle.cancel()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+// shutdown cancels what's in flight and waits, flushTimeout at most, for
+// the tracked work to finish. It returns the signal that ended the TUI, nil
+// for a normal exit.
+func shutdown() os.Signal {
+	lifecycle.cancel()
+	done := make(chan struct{})
+	go func() {
+		lifecycle.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(flushTimeout):
+		logDebug("Gave up waiting for runs to finish after %s", flushTimeout)
+	}
+	lifecycle.mu.Lock()
+	defer lifecycle.mu.Unlock()
+	return lifecycle.signal
+}
+
+// signalExitCode is the shell's exit status for a process ended by sig.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
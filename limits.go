@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ResourceLimit throttles in-app runs of the recipes matching Pattern, so a
+// heavy build doesn't take the whole machine down with it.
+type ResourceLimit struct {
+	// Pattern is a glob matched against the recipe name, e.g. "build*".
+	Pattern string `json:"pattern"`
+
+	// Nice is the niceness the recipe runs with (1-19).
+	Nice int `json:"nice,omitempty"`
+
+	// IONice is "idle", or a best-effort priority from 0 (high) to 7 (low).
+	IONice string `json:"ionice,omitempty"`
+
+	// CPUQuota and MemoryMax are systemd resource controls, e.g. "200%" and
+	// "4G". They need systemd-run; without it MemoryMax falls back to
+	// `ulimit -v` and CPUQuota is ignored.
+	CPUQuota  string `json:"cpu_quota,omitempty"`
+	MemoryMax string `json:"memory_max,omitempty"`
+}
+
+// resourceLimitFor returns the first limit whose pattern matches the recipe.
+func (c *Config) resourceLimitFor(recipe string) (ResourceLimit, bool) {
+	for _, l := range c.ResourceLimits {
+		if ok, _ := path.Match(l.Pattern, recipe); ok {
+			return l, true
+		}
+	}
+	return ResourceLimit{}, false
+}
+
+// wrap prefixes the command with whatever applies the limits. Tools that
+// aren't installed are skipped.
+func (l ResourceLimit) wrap(command []string) []string {
+	var prefix []string
+
+	cgroup := l.CPUQuota != "" || l.MemoryMax != ""
+	if _, err := exec.LookPath("systemd-run"); cgroup && err == nil {
+		prefix = append(prefix, "systemd-run", "--user", "--scope", "--quiet")
+		if l.CPUQuota != "" {
+			prefix = append(prefix, "-p", "CPUQuota="+l.CPUQuota)
+		}
+		if l.MemoryMax != "" {
+			prefix = append(prefix, "-p", "MemoryMax="+l.MemoryMax)
+		}
+		prefix = append(prefix, "--")
+	} else if kb, ok := kilobytes(l.MemoryMax); ok {
+		prefix = append(prefix, "sh", "-c", `ulimit -v "$0" && exec "$@"`, strconv.Itoa(kb))
+	}
+
+	if _, err := exec.LookPath("nice"); l.Nice != 0 && err == nil {
+		prefix = append(prefix, "nice", "-n", strconv.Itoa(l.Nice))
+	}
+	if _, err := exec.LookPath("ionice"); l.IONice != "" && err == nil {
+		if l.IONice == "idle" {
+			prefix = append(prefix, "ionice", "-c", "3")
+		} else {
+			prefix = append(prefix, "ionice", "-c", "2", "-n", l.IONice)
+		}
+	}
+	return append(prefix, command...)
+}
+
+// describe summarizes the limits for the output pane header.
+func (l ResourceLimit) describe() string {
+	var parts []string
+	if l.Nice != 0 {
+		parts = append(parts, fmt.Sprintf("nice %d", l.Nice))
+	}
+	if l.IONice != "" {
+		parts = append(parts, "ionice "+l.IONice)
+	}
+	if l.CPUQuota != "" {
+		parts = append(parts, "cpu "+l.CPUQuota)
+	}
+	if l.MemoryMax != "" {
+		parts = append(parts, "mem "+l.MemoryMax)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// kilobytes parses sizes like "512M" or "4G" into KiB for ulimit.
+func kilobytes(size string) (int, bool) {
+	if size == "" {
+		return 0, false
+	}
+	unit := 1
+	switch strings.ToUpper(size[len(size)-1:]) {
+	case "K":
+		unit = 1
+	case "M":
+		unit = 1024
+	case "G":
+		unit = 1024 * 1024
+	case "T":
+		unit = 1024 * 1024 * 1024
+	default:
+		n, err := strconv.Atoi(size)
+		return n / 1024, err == nil
+	}
+	n, err := strconv.Atoi(size[:len(size)-1])
+	return n * unit, err == nil
+}
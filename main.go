@@ -1,17 +1,17 @@
 package main
 
 import (
-	"bytes"
-	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"sort"
 	"strings"
-	"syscall"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -24,17 +24,10 @@ import (
 var (
 	appStyle = lipgloss.NewStyle().Padding(1, 2)
 
-	titleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#FFFDF5")).
-			Background(lipgloss.Color("#25A065")).
-			Padding(0, 1)
-
-	statusMessageStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"}).
-				Render
-
-	helpStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241"))
+	// Set from the theme by applyTheme
+	titleStyle         lipgloss.Style
+	statusMessageStyle func(...string) string
+	helpStyle          lipgloss.Style
 )
 
 type state int
@@ -47,11 +40,52 @@ const (
 	viewProviderSelect
 	viewModelInput
 	viewModelSelect
+	viewPreviewSelect
+	viewConfirm
+	viewRename
+	viewDiagnostics
+	viewOutput
+	viewTmuxPanes
+	viewSemantic
+	viewAILog
+	viewThemePicker
+	viewGroupJump
+	viewDependencyGraph
+	viewRefine
+	viewAIPrompt
+	viewRecipeDiff
+	viewEnvEditor
+	viewVarEditor
+	viewProjectSwitcher
+	viewOutputLogs
+	viewPromptEditor
+	viewDirPicker
+	viewStats
+	viewReleaseNotes
+	viewFileBrowser
 )
 
 // Data structures for parsing 'just --dump --dump-format json'
 type JustDump struct {
-	Recipes map[string]Recipe `json:"recipes"`
+	Recipes     map[string]Recipe     `json:"recipes"`
+	Aliases     map[string]Alias      `json:"aliases"`
+	Modules     map[string]JustDump   `json:"modules"`
+	Assignments map[string]Assignment `json:"assignments"`
+	Settings    Settings              `json:"settings"`
+}
+
+type Assignment struct {
+	Name   string `json:"name"`
+	Export bool   `json:"export"`
+}
+
+type Settings struct {
+	Export bool `json:"export"` // set export, all variables are exported
+}
+
+type Alias struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
 }
 
 type Recipe struct {
@@ -59,6 +93,8 @@ type Recipe struct {
 	Doc          *string      `json:"doc"` // Use pointer for nullable
 	Dependencies []Dependency `json:"dependencies"`
 	Parameters   []Parameter  `json:"parameters"`
+	Attributes   []Attribute  `json:"attributes"`
+	Private      bool         `json:"private"`
 	// We ignore Body for now as it's complex AST
 }
 
@@ -67,41 +103,85 @@ type Dependency struct {
 }
 
 type Parameter struct {
-	Name    string  `json:"name"`
-	Default *string `json:"default"`
-	Kind    string  `json:"kind"`
+	Name        string  `json:"name"`
+	Default     *string `json:"default"`
+	DefaultExpr bool    `json:"-"` // Default is an expression in just syntax, not a value
+	Kind        string  `json:"kind"`
+	Export      bool    `json:"export"` // $name, also set as an environment variable
+	Help        *string `json:"help"`   // From [arg(..., help="...")], in newer versions of just
 }
 
 // recipeItem implements list.Item
 type recipeItem struct {
 	name, desc string
+	marked     *[]string   // Shared with the model, the recipes marked for a batch
+	durations  durationLog // Shared with the model, for the estimate
+	search     *recipeSearch
+}
+
+func (i recipeItem) Title() string {
+	// After the name, so filter match highlighting still lines up
+	if n := markIndex(i.marked, i.name); n > 0 {
+		return fmt.Sprintf("%s [%d]", i.name, n)
+	}
+	return i.name
 }
 
-func (i recipeItem) Title() string       { return i.name }
-func (i recipeItem) Description() string { return i.desc }
+func (i recipeItem) Description() string {
+	if why := i.search.match(i.name); why != "" {
+		return why
+	}
+	d := i.durations.estimate(i.name)
+	switch {
+	case d == 0:
+		return i.desc
+	case i.desc == "":
+		return formatEstimate(d)
+	}
+	return i.desc + " · " + formatEstimate(d)
+}
 func (i recipeItem) FilterValue() string { return i.name }
 
 type aiItem struct {
 	prompt *string
+	shell  *string
+	label  string // Replaces "Generate command with AI"
+	mode   string // Where the request comes from, see aiPromptFilter
+}
+
+func (a aiItem) labelText() string {
+	if a.label != "" {
+		return a.label
+	}
+	return "Generate command with AI"
 }
 
 func (a aiItem) Title() string {
-	if a.prompt == nil || *a.prompt == "" {
-		return "✨ Generate command with AI"
+	if a.prompt == nil || *a.prompt == "" || a.mode == aiPromptEmpty {
+		return "✨ " + a.labelText()
+	}
+	if a.label != "" {
+		return fmt.Sprintf("✨ %s: %s", a.label, *a.prompt)
 	}
 	return fmt.Sprintf("✨ Generate command for: %s", *a.prompt)
 }
-func (a aiItem) Description() string { return "Use AI to generate a bash command" }
+func (a aiItem) Description() string { return fmt.Sprintf("Use AI to generate a %s command", *a.shell) }
 func (a aiItem) FilterValue() string { return "" }
 
 type model struct {
 	list           list.Model
 	viewport       viewport.Model
 	inputs         []textinput.Model
+	inputSources   []string   // Where a pre-filled input value came from, e.g. "$DEPLOY_ENV"
 	modelList      list.Model // New list for models
 	spinner        spinner.Model
+	reduceMotion   bool    // Spinner standing still
+	split          float64 // Share of the width the list takes
+	zen            bool    // Preview hidden
+	previewFocus   bool    // Keys scroll the preview instead of the list
 	focusIndex     int
-	providerIndex  int // Track selected provider
+	providerIndex  int    // Track selected provider
+	keyStore       string // Where the API key being entered will be saved
 	state          state
 	recipes        map[string]Recipe
 	selectedRecipe *Recipe
@@ -113,12 +193,77 @@ type model struct {
 	aiPrompt       *string // Shared pointer for AI item title
 	streamContent  string
 	streamChan     chan streamResult
+	aiServedBy     string // Which fallback provider answered, if one did
+	keys           keyMap
+	pendingKeys    string // Leader key pressed, waiting for the rest of the sequence
+	previewContent string // Raw recipe preview, before line numbers and wrapping
+	lineNumbers    bool
+	selectAnchor   int // Visual-select range in the preview, in lines
+	selectCursor   int
+	confirm        *confirmation
+	renameFrom     string
+	aliases        map[string]Alias
+	diagnostics    []diagnostic
+	job            *job            // In-app run shown in the output pane
+	matrix         *matrixRun      // Set when the output pane shows a matrix run
+	output         viewport.Model  // Output pane for the job
+	usageSeq       int             // Identifies the current usage sampling loop
+	collapsed      map[string]bool // Groups folded away in the list
+	groupFilter    string          // Only list recipes in this group
+	itemsFiltering bool            // Whether the items were built for an active filter
+	project        string          // Shown in the terminal title while browsing
+	title          string          // Terminal title last set
+	tmux           *tmuxPicker
+	aiShell        *string  // Shared pointer for the AI item, the shell AI commands are for
+	aiProblem      string   // Syntax problem the shell found in the AI command
+	aiExplanation  []string // Breakdown of the AI command, one part per line
+	semanticQuery  string   // Set while the list shows semantic search results
+	aiRequest      string   // What the AI command in the form was generated for
+	aiTurns        []aiTurn // Requests and commands so far, the last one being refined
+	refineInput    textinput.Model
+	aiItem         aiItem // Listed as configured by aiPlacement
+	aiPlacement    string
+	aiPromptInput  textinput.Model
+	choices        paramChoices
+	history        paramChoices // Earlier values per form field, newest first
+	hints          paramHints
+	aiLog          viewport.Model // AI request log viewer
+	themes         *themePicker
+	stateChanged   time.Time // When the view last changed, see actionDebounce
+	marked         *[]string // Recipes marked for a batch run, in order
+	batchParallel  bool
+	watch          *watcher // Set while the output pane re-runs on file changes
+	jump           *groupJump
+	graph          viewport.Model // Dependency graph of graphRecipe
+	graphRecipe    string
+	rdiff          *recipeDiff
+	envEdit        *envEditor
+	runEnv         *runEnv // Environment overrides for the next run
+	vars           *varEditor
+	varOverrides   map[string]string // Passed to recipes as NAME=value
+	durations      durationLog       // Of earlier in-app runs, by recipe
+	search         *recipeSearch     // Doc and body matches for the filter
+	switcher       *projectSwitcher
+	outputLogs     *outputLogBrowser
+	promptEditor   *promptEditor
+	dirPicker      *dirPicker
+	stats          *statsView
+	manualCopy     *manualCopy
+	releaseNotes   *releaseNotes
+	fileBrowser    *fileBrowser
+	sources        justfileCheckMsg // Of the justfile, when last checked
+	errNote        string           // Shown on the error screen, e.g. after copying
+	showPrivate    bool             // List [private] and _recipes too
+	partial        bool             // Recipes known by name only, the dump is loading
+	previews       *previewCache
+	evaluated      map[int]string // Expression defaults of the form, by field
 }
 
 type streamResult struct {
-	chunk string
-	err   error
-	done  bool
+	chunk    string
+	err      error
+	done     bool
+	servedBy string // Set when a fallback provider answered, and why
 }
 
 // modelItem implements list.Item for model selection
@@ -140,109 +285,333 @@ func logDebug(format string, args ...interface{}) {
 
 func main() {
 	logDebug("Application started")
+	args, err := splitJustFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if runSubcommand(args) {
+		return
+	}
+
+	opts, err := parseFlags(args)
+	if err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Pick colors before the TUI takes over the terminal
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in config: %v\n", err)
+		os.Exit(2)
+	}
+	lipgloss.SetColorProfile(detectColorProfile(cfg))
+	t, err := loadTheme(cfg, detectBackground(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in config: %v\n", err)
+		os.Exit(2)
+	}
+	applyTheme(t)
+
+	keys, err := loadKeyMap(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in config: %v\n", err)
+		os.Exit(2)
+	}
+
 	s := spinner.New()
 	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	s.Style = lipgloss.NewStyle().Foreground(activeTheme.Accent)
 
 	m := model{
-		recipes:  make(map[string]Recipe),
-		state:    viewList,
-		spinner:  s,
-		aiPrompt: new(string),
+		recipes:   make(map[string]Recipe),
+		state:     viewList,
+		spinner:   s,
+		aiPrompt:  new(string),
+		keys:      keys,
+		collapsed: make(map[string]bool),
+		project:   projectName(),
+		aiShell:   new(string),
+		marked:    new([]string),
+		durations: loadDurations(),
+		previews:  newPreviewCache(),
+	}
+	*m.aiShell = detectShell(cfg)
+	m.aiItem = newAIItem(cfg, m.aiPrompt, m.aiShell)
+	m.aiPlacement = aiItemPlacement(cfg)
+	m.reduceMotion = cfg != nil && cfg.ReduceMotion
+	m.split = splitRatio(cfg)
+
+	if workspace, err = loadWorkspace(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error in config: %v\n", err)
+		os.Exit(2)
+	}
+	if opts.recursive {
+		workspace = discoverJustfiles(projectDir(), opts.depth, append(defaultWatchIgnore, opts.ignore...))
 	}
 
-	// Fetch recipes
-	dump, err := getJustDump()
+	// Fetch recipes. Starting at a recipe needs all of them loaded
+	var dump *JustDump
+	if opts.recipe != "" || opts.select1 {
+		dump, err = getJustDump()
+	} else {
+		dump, m.partial, err = loadInitialDump()
+	}
 	if err != nil {
 		fmt.Printf("Error fetching recipes: %v\n", err)
+		if cmdErr := (*commandError)(nil); errors.As(err, &cmdErr) && cmdErr.stderr != "" {
+			fmt.Println(cmdErr.stderr)
+		}
+		if _, err := rootJustfile(); err != nil && len(workspace) == 0 {
+			fmt.Println("Run `just-do-it import` to create one from scripts/, package.json or a Makefile.")
+		}
 		os.Exit(1)
 	}
 	m.recipes = dump.Recipes
+	m.aliases = dump.Aliases
+	m.search = newRecipeSearch(m.recipes)
+	rememberCurrentProject()
 
 	// Prepare list items
-	items := []list.Item{}
-	for _, r := range m.recipes {
-		desc := ""
-		if r.Doc != nil {
-			desc = *r.Doc
-		}
-		items = append(items, recipeItem{name: r.Name, desc: desc})
-	}
-
-	// Sort items by name
-	sort.Slice(items, func(i, j int) bool {
-		return items[i].(recipeItem).name < items[j].(recipeItem).name
-	})
-
-	// Append AI item
-	items = append(items, aiItem{prompt: m.aiPrompt})
+	items := m.buildItems()
 
 	// Setup list
-	delegate := list.NewDefaultDelegate()
-	m.list = list.New(items, delegate, 0, 0)
-	m.list.Title = "Just Tasks"
+	m.list = list.New(items, list.NewDefaultDelegate(), 0, 0)
+	styleList(&m.list)
+	m.list.Title = m.listTitle()
 	m.list.SetShowHelp(false)
 
 	// Custom filter to always include AI item
+	placement := m.aiPlacement
+	search := m.search
 	m.list.Filter = func(term string, targets []string) []list.Rank {
 		// If targets is empty, return nil
 		if len(targets) == 0 {
 			return nil
 		}
 
-		// Real targets are all except the last one (AI item)
-		realTargets := targets[:len(targets)-1]
-		// Normalize term for better fuzzy matching (e.g., "start all" matches "start-all")
-		replacer := strings.NewReplacer(" ", "", "-", "", "_", "")
-		normalizedTerm := replacer.Replace(term)
-		matches := fuzzy.Find(normalizedTerm, realTargets)
+		// Real targets are all except the AI item
+		ai := aiItemIndex(placement, len(targets))
+		realTargets, offset := targets, 0
+		switch ai {
+		case 0:
+			realTargets, offset = targets[1:], 1
+		case len(targets) - 1:
+			realTargets = targets[:ai]
+		}
+		ranks := matchRecipes(term, realTargets, search)
+		for i := range ranks {
+			ranks[i].Index += offset
+		}
 
-		ranks := make([]list.Rank, len(matches))
-		for i, match := range matches {
-			ranks[i] = list.Rank{
-				Index:          match.Index,
-				MatchedIndexes: match.MatchedIndexes,
+		// Always list the AI item after the matches, even when it's at the
+		// top, so enter picks the best match
+		if ai >= 0 {
+			ranks = append(ranks, list.Rank{Index: ai})
+		}
+
+		return ranks
+	}
+
+	// Pre-populate the filter, fzf style
+	if opts.query != "" || opts.select1 || opts.exit0 {
+		// The recipes the list shows, matched the way its filter does
+		var names []string
+		for name, r := range m.recipes {
+			if m.listed(r) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		matched := names
+		if opts.query != "" {
+			matched = nil
+			for _, rank := range matchRecipes(opts.query, names, m.search) {
+				matched = append(matched, names[rank.Index])
 			}
 		}
 
-		// Always append AI item (last item)
-		ranks = append(ranks, list.Rank{
-			Index: len(targets) - 1,
-		})
+		if opts.exit0 && len(matched) == 0 {
+			fmt.Fprintln(os.Stderr, "No matching recipes")
+			os.Exit(exitNoMatch)
+		}
 
-		return ranks
+		if opts.select1 && len(matched) == 1 && opts.recipe == "" {
+			opts.recipe = matched[0]
+			// Nothing to fill in, run it right away, unless a git guard
+			// needs a word in the TUI first
+			if problems, _ := gitGuardProblems(cfg, opts.recipe); len(m.recipes[opts.recipe].Parameters) == 0 && len(problems) == 0 {
+				execCommand(justRecipeCommand(opts.recipe))
+			}
+		} else if opts.query != "" {
+			m.list.SetFilterText(opts.query)
+			m.list.SetFilterState(list.Filtering)
+			*m.aiPrompt = opts.query
+		}
 	}
 
-	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	// Jump straight to a recipe if requested on the command line
+	if opts.recipe != "" {
+		recipe, ok := m.recipes[opts.recipe]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown recipe %q\n", opts.recipe)
+			os.Exit(1)
+		}
+		for name := range opts.params {
+			if !recipeHasParam(recipe, name) {
+				fmt.Fprintf(os.Stderr, "Error: recipe %q has no parameter %q\n", recipe.Name, name)
+				os.Exit(1)
+			}
+		}
+		if len(recipe.Parameters) > 0 {
+			m.startParamInput(recipe, opts.params)
+		} else {
+			for i, item := range m.list.Items() {
+				if r, ok := item.(recipeItem); ok && r.name == recipe.Name {
+					m.list.Select(i)
+					break
+				}
+			}
+		}
+	}
+
+	var script []scriptStep
+	programOpts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion(), tea.WithoutSignalHandler()}
+	if opts.script != "" {
+		if script, err = loadScript(opts.script); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		// Only the script's keys, so it plays the same every time
+		programOpts = append(programOpts, tea.WithInput(nil))
+	}
+
+	pushTerminalTitle()
+	saveTerminal()
+	p := tea.NewProgram(crashGuard{m}, programOpts...)
+	handleSignals(p)
+	if script != nil {
+		go playScript(p, script)
+	}
 	finalModel, err := p.Run()
+	sig := shutdown()
+	popTerminalTitle()
+	if path := crashReport(); path != "" {
+		fmt.Fprintf(os.Stderr, "A crash report was written to %s, please attach it to an issue.\n", path)
+		os.Exit(2)
+	}
 	if err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
 	}
+	if sig != nil {
+		os.Exit(signalExitCode(sig))
+	}
+
+	if opts.script != "" {
+		m := finalModel.(crashGuard).Model.(model)
+		m.stopRuns()
+		writeStateDump(os.Stdout, m)
+		return
+	}
 
 	// Handle execution after TUI exit
-	if m, ok := finalModel.(model); ok && len(m.finalCmd) > 0 {
-		// Use syscall.Exec to replace the process
-		binary, lookErr := exec.LookPath(m.finalCmd[0])
-		if lookErr != nil {
-			fmt.Fprintf(os.Stderr, "Error finding command %s: %v\n", m.finalCmd[0], lookErr)
-			os.Exit(1)
+	if m, ok := finalModel.(crashGuard).Model.(model); ok && len(m.finalCmd) > 0 {
+		if m.selectedRecipe != nil {
+			setEnv(m.recipeEnv(m.selectedRecipe.Name))
+			// Where [no-cd] recipes run
+			if dir := m.recipeDir(m.selectedRecipe.Name); dir != "" {
+				if err := os.Chdir(dir); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
 		}
+		execCommand(m.finalCmd)
+	}
+}
 
-		// syscall.Exec requires the full path as the first argument,
-		// and the slice of arguments (including the command name) as the second.
-		// Environment variables are passed as the third argument.
-		execErr := syscall.Exec(binary, m.finalCmd, os.Environ())
-		if execErr != nil {
-			fmt.Fprintf(os.Stderr, "Error executing command: %v\n", execErr)
-			os.Exit(1)
+// execCommand replaces the current process with the given command, run
+// inside the project's environment.
+func execCommand(command []string) {
+	command = withEnvironment(command)
+
+	// The process is replaced with the full path, PATH isn't searched
+	binary, lookErr := exec.LookPath(command[0])
+	if lookErr != nil {
+		fmt.Fprintf(os.Stderr, "Error finding command %s: %v\n", command[0], lookErr)
+		os.Exit(1)
+	}
+
+	execErr := replaceProcess(binary, command)
+	if execErr != nil {
+		fmt.Fprintf(os.Stderr, "Error executing command: %v\n", execErr)
+		os.Exit(1)
+	}
+}
+
+// buildItems turns the loaded recipes into list items, sorted by name and
+// laid out by group, followed by the AI item.
+func (m model) buildItems() []list.Item {
+	recipes := []recipeItem{}
+	for _, r := range m.recipes {
+		if !m.listed(r) {
+			continue
 		}
+		desc := ""
+		if r.Doc != nil {
+			desc = *r.Doc
+		}
+		recipes = append(recipes, recipeItem{name: r.Name, desc: desc, marked: m.marked, durations: m.durations, search: m.search})
 	}
+
+	// Sort items by name
+	sort.Slice(recipes, func(i, j int) bool {
+		return recipes[i].name < recipes[j].name
+	})
+
+	return m.withAIItem(m.groupedItems(recipes))
+}
+
+// matchRecipes ranks the recipe names matching term: fuzzy matches on the
+// name first, then those whose doc or body contain it. Recipes in several
+// groups are listed more than once, they're matched once.
+func matchRecipes(term string, names []string, search *recipeSearch) []list.Rank {
+	var ranks []list.Rank
+	seen := map[string]bool{}
+	for _, match := range fuzzyMatch(term, names) {
+		if seen[match.Str] {
+			continue
+		}
+		seen[match.Str] = true
+		ranks = append(ranks, list.Rank{
+			Index:          match.Index,
+			MatchedIndexes: match.MatchedIndexes,
+		})
+	}
+	for _, i := range search.find(term, names, seen) {
+		seen[names[i]] = true
+		ranks = append(ranks, list.Rank{Index: i})
+	}
+	return ranks
+}
+
+// fuzzyMatch runs the fuzzy finder with a normalized term for better matching
+// (e.g., "start all" matches "start-all").
+func fuzzyMatch(term string, targets []string) fuzzy.Matches {
+	replacer := strings.NewReplacer(" ", "", "-", "", "_", "")
+	return fuzzy.Find(replacer.Replace(term), targets)
 }
 
 func getJustDump() (*JustDump, error) {
-	cmd := exec.Command("just", "--dump", "--dump-format", "json")
-	output, err := cmd.Output()
+	if len(workspace) > 0 {
+		return workspaceDump()
+	}
+	output, err := justOutput("--dump", "--dump-format", "json")
 	if err != nil {
 		return nil, err
 	}
@@ -251,6 +620,7 @@ func getJustDump() (*JustDump, error) {
 	if err := json.Unmarshal(output, &dump); err != nil {
 		return nil, err
 	}
+	flattenModules(&dump)
 	return &dump, nil
 }
 
@@ -258,7 +628,16 @@ func getJustDump() (*JustDump, error) {
 type recipeContentMsg string
 
 func (m model) Init() tea.Cmd {
-	return tea.EnterAltScreen
+	cmds := []tea.Cmd{tea.EnterAltScreen, checkJustfile()}
+	if m.partial {
+		cmds = append(cmds, reloadRecipes)
+	} else {
+		cmds = append(cmds, checkDiagnostics(m.recipes, m.aliases))
+	}
+	if m.state == viewInput {
+		cmds = append(cmds, textinput.Blink)
+	}
+	return tea.Batch(cmds...)
 }
 
 // Msg to paste text into input
@@ -270,92 +649,268 @@ type aiCompletionMsg string
 // Msg when models are fetched
 type modelsFetchedMsg []string
 
+// Msg to flash a short status line under the list
+type statusMsg string
+
+// Msg when recipes have been (re)loaded from just
+type recipesLoadedMsg struct {
+	dump *JustDump
+	err  error
+}
+
+func reloadRecipes() tea.Msg {
+	dump, err := getJustDump()
+	return recipesLoadedMsg{dump: dump, err: err}
+}
+
+// actionDebounce is how long action keys are ignored after the view
+// changes. A quick second enter was meant for the view that was just left,
+// not for whatever took its place.
+const actionDebounce = 300 * time.Millisecond
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if k, ok := msg.(tea.KeyMsg); ok && m.dropKey(k) {
+		logDebug("Dropped %q pressed during a transition", k.String())
+		return m, nil
+	}
+	before := m.state
+	next, cmd := m.update(msg)
+	m = next.(model)
+	if m.list.FilterState() == list.Unfiltered {
+		m.search.clear()
+	}
+	if m.state != before {
+		m.stateChanged = time.Now()
+	}
+	return m, tea.Batch(cmd, m.syncTitle())
+}
+
+// dropKey reports whether a key press should be ignored: any key once a
+// command is about to take over the terminal, and action keys right after
+// the view changed.
+func (m model) dropKey(k tea.KeyMsg) bool {
+	if key.Matches(k, m.keys.ForceQuit) {
+		return false
+	}
+	if m.finalCmd != nil {
+		return true
+	}
+	if time.Since(m.stateChanged) >= actionDebounce {
+		return false
+	}
+	switch m.state {
+	case viewConfirm:
+		if m.confirm != nil && m.confirm.typed != "" {
+			return key.Matches(k, m.keys.Select)
+		}
+		return key.Matches(k, m.keys.Yes, m.keys.No)
+	case viewOutput:
+		return key.Matches(k, m.keys.Rerun)
+	}
+	return key.Matches(k, m.keys.Select, m.keys.RunInApp)
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var (
 		cmds []tea.Cmd
 	)
 
-	// Reset error on key press
-	if _, ok := msg.(tea.KeyMsg); ok {
-		m.err = nil
+	// Text to copy by hand is shown over everything else, the error screen too
+	if k, ok := msg.(tea.KeyMsg); ok && m.manualCopy != nil {
+		return m.updateManualCopy(k)
+	}
+
+	// Keys go to the error screen while it's shown
+	if k, ok := msg.(tea.KeyMsg); ok && m.err != nil && !key.Matches(k, m.keys.ForceQuit) {
+		return m.updateError(k)
 	}
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
+		if key.Matches(msg, m.keys.ForceQuit) {
+			m.stopRuns()
 			return m, tea.Quit
 		}
 
+		// Recipes known by name only can't be run yet, their parameters
+		// are still loading
+		if _, ok := m.list.SelectedItem().(recipeItem); ok && m.partial && m.state == viewList && m.pendingKeys == "" &&
+			key.Matches(msg, m.keys.Select, m.keys.RunInApp, m.keys.Explain, m.keys.Leader) {
+			return m, m.list.NewStatusMessage(statusMessageStyle("Still loading the recipes' details..."))
+		}
+
+		// Finish a pending leader sequence
+		if m.pendingKeys != "" {
+			m.pendingKeys = ""
+			cmd, _ := m.handleLeader(msg)
+			return m, cmd
+		}
+
+		if m.state == viewPreviewSelect {
+			return m.updatePreviewSelect(msg)
+		}
+
+		if m.state == viewConfirm {
+			return m.updateConfirm(msg)
+		}
+
+		if m.state == viewRename {
+			return m.updateRename(msg)
+		}
+
+		if m.state == viewOutput {
+			return m.updateOutput(msg)
+		}
+
+		if m.state == viewTmuxPanes {
+			return m.updateTmuxPicker(msg)
+		}
+
+		if m.state == viewSemantic {
+			return m.updateSemantic(msg)
+		}
+
+		if m.state == viewAILog {
+			return m.updateAILog(msg)
+		}
+
+		if m.state == viewThemePicker {
+			return m.updateThemePicker(msg)
+		}
+
+		if m.state == viewGroupJump {
+			return m.updateGroupJump(msg)
+		}
+
+		if m.state == viewDependencyGraph {
+			return m.updateDependencyGraph(msg)
+		}
+
+		if m.state == viewRecipeDiff {
+			return m.updateRecipeDiff(msg)
+		}
+
+		if m.state == viewEnvEditor {
+			return m.updateEnvEditor(msg)
+		}
+
+		if m.state == viewVarEditor {
+			return m.updateVarEditor(msg)
+		}
+
+		if m.state == viewProjectSwitcher {
+			return m.updateProjectSwitcher(msg)
+		}
+
+		if m.state == viewOutputLogs {
+			return m.updateOutputLogs(msg)
+		}
+
+		if m.state == viewPromptEditor {
+			return m.updatePromptEditor(msg)
+		}
+
+		if m.state == viewDirPicker {
+			return m.updateDirPicker(msg)
+		}
+		if m.state == viewStats {
+			return m.updateStats(msg)
+		}
+
+		if m.state == viewReleaseNotes {
+			return m.updateReleaseNotes(msg)
+		}
+
+		if m.state == viewFileBrowser {
+			return m.updateFileBrowser(msg)
+		}
+
+		if m.state == viewRefine {
+			return m.updateRefine(msg)
+		}
+
+		if m.state == viewAIPrompt {
+			return m.updateAIPrompt(msg)
+		}
+
+		if m.state == viewDiagnostics {
+			if key.Matches(msg, m.keys.Cancel, m.keys.Quit) {
+				m.state = viewList
+			}
+			return m, nil
+		}
+
 		if m.state == viewList {
-			switch msg.String() {
-			case "ctrl+p":
-				m.state = viewProviderSelect
-				m.providerIndex = 0
+			switch {
+			case key.Matches(msg, m.keys.Leader):
+				m.pendingKeys = msg.String()
+				return m, nil
+			case m.previewFocus:
+				return m.updatePreviewFocus(msg)
+			case key.Matches(msg, m.keys.FocusPane) && !m.zen:
+				m.previewFocus = true
 				return m, nil
-			case "enter":
+			case key.Matches(msg, m.keys.GrowList):
+				return m, m.resizeSplit(splitStep)
+			case key.Matches(msg, m.keys.ShrinkList):
+				return m, m.resizeSplit(-splitStep)
+			case len(*m.marked) > 0 && !m.list.SettingFilter() && key.Matches(msg, m.keys.Select, m.keys.RunInApp):
+				return m, m.startBatch(false)
+			case key.Matches(msg, m.keys.RunInApp):
+				if i, ok := m.list.SelectedItem().(recipeItem); ok {
+					recipe := m.recipes[i.name]
+					if len(recipe.Parameters) > 0 {
+						return m, m.startParamInput(recipe, nil)
+					}
+					command := m.recipeCommand(i.name)
+					if guard := guardRecipes(m.recipes, []string{i.name}, func() tea.Msg { return runInAppMsg{i.name, command} }); guard != nil {
+						return m, guard
+					}
+					return m, m.runInApp(i.name, command)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.AISettings):
+				m.startProviderSetup()
+				return m, nil
+			case key.Matches(msg, m.keys.Explain):
+				return m, m.startExplain()
+			case key.Matches(msg, m.keys.Projects):
+				return m, m.startProjectSwitcher()
+			case !m.list.SettingFilter() && key.Matches(msg, m.keys.Mark):
+				if i, ok := m.list.SelectedItem().(recipeItem); ok {
+					return m, m.toggleMark(i.name)
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.Select):
 				// Check if AI item selected
 				if item, ok := m.list.SelectedItem().(aiItem); ok {
-					m.state = viewGenerating
-					prompt := *item.prompt
-					m.streamContent = ""
-					ch := make(chan streamResult, 100)
-					m.streamChan = ch
-
-					go func() {
-						defer close(ch)
-						ctx := context.Background()
-						_, err := GenerateCommand(ctx, prompt, func(s string) {
-							ch <- streamResult{chunk: s}
-						})
-						if err != nil {
-							ch <- streamResult{err: err}
-						}
-						ch <- streamResult{done: true}
-					}()
+					return m, m.requestAI(*item.prompt)
+				}
 
-					return m, tea.Batch(
-						m.spinner.Tick,
-						waitForStream(ch),
-					)
+				if g, ok := m.list.SelectedItem().(groupItem); ok {
+					return m, m.toggleGroup(g)
 				}
 
 				// Select task
 				if i, ok := m.list.SelectedItem().(recipeItem); ok {
-					recipe := m.recipes[i.name]
-					m.selectedRecipe = &recipe
-
-					if len(recipe.Parameters) > 0 {
-						m.state = viewInput
-						m.inputs = make([]textinput.Model, len(recipe.Parameters))
-						for i, p := range recipe.Parameters {
-							t := textinput.New()
-							t.Prompt = fmt.Sprintf("%s: ", p.Name)
-							t.Width = 50
-							if p.Default != nil {
-								t.Placeholder = fmt.Sprintf("%s (default)", *p.Default)
-							}
-							if i == 0 {
-								t.Focus()
-							}
-							m.inputs[i] = t
-						}
-						m.focusIndex = 0
-						return m, textinput.Blink
-					} else {
-						m.finalCmd = []string{"just", i.name}
-						return m, tea.Quit
-					}
+					return m, m.selectRecipe(i.name)
 				}
-			case "q":
+			case key.Matches(msg, m.keys.Quit):
 				if !m.list.SettingFilter() {
 					return m, tea.Quit
 				}
-			case "esc":
+			case key.Matches(msg, m.keys.Cancel):
 				if m.list.SettingFilter() {
 					m.list.ResetFilter()
 					return m, nil
 				}
+				if len(*m.marked) > 0 {
+					*m.marked = nil
+					return m, nil
+				}
+				if m.semanticQuery != "" {
+					return m, m.clearSemantic()
+				}
 				return m, tea.Quit
 			}
 
@@ -366,13 +921,71 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		} else if m.state == viewInput || m.state == viewApiKeyInput || m.state == viewProviderSelect || m.state == viewModelInput {
-			switch msg.String() {
-			case "esc":
+			if m.state == viewInput && key.Matches(msg, m.keys.Leader) {
+				m.pendingKeys = msg.String()
+				return m, nil
+			}
+
+			if m.isAICommand() && key.Matches(msg, m.keys.Explain) {
+				return m, m.startExplain()
+			}
+
+			if m.isAICommand() && key.Matches(msg, m.keys.Refine) {
+				return m, m.startRefine()
+			}
+
+			if m.state == viewInput && key.Matches(msg, m.keys.RunInApp) {
+				command := m.formCommand()
+				run := func() tea.Msg { return runInAppMsg{m.selectedRecipe.Name, command} }
+				if guard := m.guardAICommand(run); guard != nil {
+					return m, guard
+				}
+				if !m.editsCommand() {
+					if guard := guardRecipes(m.recipes, []string{m.selectedRecipe.Name}, run); guard != nil {
+						return m, guard
+					}
+				}
+				m.acceptGeneration()
+				m.rememberFormArgs()
+				return m, m.runInApp(m.selectedRecipe.Name, command)
+			}
+
+			if m.state == viewProviderSelect && key.Matches(msg, m.keys.Theme) {
+				m.startThemePicker()
+				return m, nil
+			}
+
+			if m.state == viewProviderSelect && key.Matches(msg, m.keys.EditPrompt) {
+				return m, m.startPromptEditor()
+			}
+
+			if m.inputChoices(m.focusIndex) != nil && key.Matches(msg, m.keys.PrevChoice, m.keys.NextChoice) {
+				if key.Matches(msg, m.keys.NextChoice) {
+					m.cycleChoice(1)
+				} else {
+					m.cycleChoice(-1)
+				}
+				return m, nil
+			}
+
+			if m.inputHistory(m.focusIndex) != nil && key.Matches(msg, m.keys.PrevValue, m.keys.NextValue) {
+				if key.Matches(msg, m.keys.PrevValue) {
+					m.stepHistory(1)
+				} else {
+					m.stepHistory(-1)
+				}
+				return m, nil
+			}
+
+			switch {
+			case key.Matches(msg, m.keys.Cancel):
 				m.state = viewList
 				m.inputs = nil
+				m.inputSources = nil
+				m.runEnv = nil
 				return m, nil
 
-			case "tab", "shift+tab", "up", "down":
+			case key.Matches(msg, m.keys.NextField, m.keys.PrevField):
 				if m.state == viewApiKeyInput || m.state == viewModelInput {
 					return m, nil
 				}
@@ -382,15 +995,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							m.providerIndex--
 						}
 					} else if msg.String() == "down" {
-						if m.providerIndex < 1 {
+						if m.providerIndex < len(aiProviders)-1 {
 							m.providerIndex++
 						}
 					}
 					return m, nil
 				}
 
-				s := msg.String()
-				if s == "up" || s == "shift+tab" {
+				if key.Matches(msg, m.keys.PrevField) {
 					m.focusIndex--
 				} else {
 					m.focusIndex++
@@ -412,7 +1024,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return m, tea.Batch(cmds...)
 
-			case "enter":
+			case key.Matches(msg, m.keys.Select):
 				if m.state == viewProviderSelect {
 					// Check for existing key first?
 					// Flow: Select Provider -> Check Config/Env -> If missing ask Key -> Fetch Models -> Select Model
@@ -422,42 +1034,30 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						cfg = &Config{}
 					}
 
-					var key string
-					if m.providerIndex == 0 {
-						key = cfg.GoogleAPIKey
-						if key == "" {
-							key = os.Getenv("GOOGLE_API_KEY")
-						}
-					} else {
-						key = cfg.OpenAIAPIKey
-						if key == "" {
-							key = os.Getenv("OPENAI_API_KEY")
-						}
-					}
+					provider := aiProviders[m.providerIndex]
+					key := cfg.apiKey(provider)
 
-					if key == "" {
+					if provider.KeyEnv != "" && key == "" {
 						m.state = viewApiKeyInput
+						m.keyStore = cfg.keyStore(provider)
 						t := textinput.New()
 						t.Placeholder = "Key..."
 						t.EchoMode = textinput.EchoPassword
 						t.Width = 50
 						t.Focus()
 						m.inputs = []textinput.Model{t}
+						m.inputSources = nil
 						m.focusIndex = 0
 						return m, nil
 					}
 
 					// Have key, fetch models
 					m.state = viewGenerating // Reuse loading state
-					provider := "google"
-					if m.providerIndex == 1 {
-						provider = "openai"
-					}
 
 					return m, tea.Batch(
-						m.spinner.Tick,
+						m.spinTick(),
 						func() tea.Msg {
-							models, err := ListModels(provider, key)
+							models, err := ListModels(provider.ID, key)
 							if err != nil {
 								// Fallback to manual input if list fails
 								return fmt.Errorf("list_models_failed")
@@ -474,11 +1074,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						if cfg == nil {
 							cfg = &Config{}
 						}
-						if m.providerIndex == 0 {
-							cfg.GoogleAPIKey = key
-						} else {
-							cfg.OpenAIAPIKey = key
-						}
+						provider := aiProviders[m.providerIndex]
+						cfg.setAPIKey(provider, key)
 						if err := SaveConfig(cfg); err != nil {
 							m.err = fmt.Errorf("failed to save config: %v", err)
 							return m, nil
@@ -486,15 +1083,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 						// Now fetch models
 						m.state = viewGenerating
-						provider := "google"
-						if m.providerIndex == 1 {
-							provider = "openai"
-						}
 
 						return m, tea.Batch(
-							m.spinner.Tick,
+							m.spinTick(),
 							func() tea.Msg {
-								models, err := ListModels(provider, key)
+								models, err := ListModels(provider.ID, key)
 								if err != nil {
 									return fmt.Errorf("list_models_failed")
 								}
@@ -513,16 +1106,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						cfg = &Config{}
 					}
 
+					provider := aiProviders[m.providerIndex]
 					if model != "" {
-						if m.providerIndex == 0 {
-							cfg.GoogleModel = model
-						} else {
-							cfg.OpenAIModel = model
-						}
-						SaveConfig(cfg)
+						cfg.setModel(provider, model)
 					}
+					cfg.Provider = provider.ID
+					SaveConfig(cfg)
 					m.state = viewList
 					m.inputs = nil
+					m.inputSources = nil
 					return m, nil
 				}
 
@@ -534,44 +1126,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, textinput.Blink
 				}
 
-				args := []string{}
-				for i, input := range m.inputs {
-					val := input.Value()
-					if val == "" && m.selectedRecipe.Parameters[i].Default != nil {
-						val = *m.selectedRecipe.Parameters[i].Default
-					}
-					if m.selectedRecipe.Parameters[i].Kind == "plus" || m.selectedRecipe.Parameters[i].Kind == "star" {
-						args = append(args, strings.Fields(val)...)
-					} else {
-						args = append(args, val)
-					}
-				}
-
-				if m.selectedRecipe.Name == "AI Command" {
-					m.finalCmd = []string{"sh", "-c", args[0]}
-				} else {
-					cmdSlice := append([]string{"just", m.selectedRecipe.Name}, args...)
-					m.finalCmd = cmdSlice
+				command := m.formCommand()
+				if guard := m.guardAICommand(func() tea.Msg { return execMsg(command) }); guard != nil {
+					return m, guard
 				}
-				return m, tea.Quit
+				m.acceptGeneration()
+				m.rememberFormArgs()
+				return m, m.runCommand(command)
 
-			case "ctrl+f":
-				c := exec.Command("fzf")
-				var out bytes.Buffer
-				c.Stdout = &out
-				c.Stdin = os.Stdin
-				c.Stderr = os.Stderr
-				return m, tea.ExecProcess(c, func(err error) tea.Msg {
-					if err != nil {
-						return nil
-					}
-					return pasteMsg(strings.TrimSpace(out.String()))
-				})
+			case m.state == viewInput && key.Matches(msg, m.keys.FindFile):
+				return m, m.startFileBrowser()
 			}
 		}
 
 	case pasteMsg:
-		if (m.state == viewInput || m.state == viewApiKeyInput || m.state == viewModelInput) && len(msg) > 0 {
+		if (m.state == viewInput || m.state == viewApiKeyInput || m.state == viewModelInput) && len(msg) > 0 && m.inputChoices(m.focusIndex) == nil {
 			input := m.inputs[m.focusIndex]
 			val := input.Value()
 			cursor := input.Position()
@@ -589,23 +1158,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case streamResult:
 		logDebug("Update received streamResult: chunk=%q done=%v err=%v", msg.chunk, msg.done, msg.err)
 		if msg.err != nil {
-			if msg.err.Error() == "MISSING_API_KEY" {
-				m.state = viewProviderSelect
-				m.err = nil
-				m.providerIndex = 0
+			if errors.Is(msg.err, errNoProvider) {
+				m.startProviderSetup()
 				return m, nil
 			}
-			m.err = fmt.Errorf("AI Error: %v", msg.err)
+			m.fail(fmt.Errorf("AI Error: %w", msg.err), (*model).streamGeneration)
 			m.state = viewList
 			return m, nil
 		}
 		if msg.done {
 			return m, func() tea.Msg { return aiCompletionMsg(m.streamContent) }
 		}
+		if msg.servedBy != "" {
+			m.aiServedBy = msg.servedBy
+			return m, waitForStream(m.streamChan)
+		}
 		m.streamContent += msg.chunk
 		return m, waitForStream(m.streamChan)
 
 	case aiCompletionMsg:
+		command, explanation := parseGeneration(string(msg))
+		if len(m.aiTurns) > 0 {
+			m.aiTurns[len(m.aiTurns)-1].Command = command
+		}
 		m.state = viewInput
 		m.selectedRecipe = &Recipe{
 			Name:       "AI Command",
@@ -614,14 +1189,43 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		t := textinput.New()
 		t.Prompt = "Run: "
 		t.Width = m.terminalWidth - 10
-		t.SetValue(string(msg))
+		t.SetValue(command)
 		t.Focus()
 		m.inputs = []textinput.Model{t}
+		m.inputSources = nil
 		m.focusIndex = 0
-		return m, textinput.Blink
+		m.aiProblem = ""
+		m.aiExplanation = explanation
+		return m, tea.Batch(textinput.Blink, checkShellSyntax(*m.aiShell, command))
+
+	case explainMsg:
+		m.handleExplain(msg)
+
+	case routeMsg:
+		return m, m.handleRoute(msg)
+
+	case selectRecipeMsg:
+		return m, m.selectRecipe(string(msg))
+
+	case generateMsg:
+		return m, m.startGeneration(string(msg))
+
+	case shellCheckMsg:
+		if m.state == viewInput && m.selectedRecipe.Name == "AI Command" && msg.shell == *m.aiShell && msg.command == m.inputs[0].Value() {
+			m.aiProblem = msg.problem
+		}
+
+	case jobMsg:
+		return m.handleJobMsg(msg)
+
+	case usageMsg:
+		return m.handleUsageMsg(msg)
+
+	case watchMsg:
+		return m.handleWatchMsg(msg)
 
 	case spinner.TickMsg:
-		if m.state == viewGenerating {
+		if m.state == viewGenerating || (m.state == viewOutput && m.running()) {
 			var cmd tea.Cmd
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
@@ -631,23 +1235,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.terminalWidth = msg.Width
 		m.terminalHeight = msg.Height
 
-		listWidth := int(float64(msg.Width) * 0.35)
-		viewportWidth := msg.Width - listWidth - 8
-
-		headerHeight := lipgloss.Height(m.list.Title)
-		listFooterHeight := 2
-		globalFooterHeight := 1
-
-		m.list.SetSize(listWidth, msg.Height-headerHeight-listFooterHeight-globalFooterHeight)
-
 		if !m.ready {
-			m.viewport = viewport.New(viewportWidth, msg.Height-2-globalFooterHeight)
+			m.viewport = viewport.New(0, 0)
 			m.viewport.HighPerformanceRendering = false
 			m.ready = true
-		} else {
-			m.viewport.Width = viewportWidth
-			m.viewport.Height = msg.Height - 2 - globalFooterHeight
 		}
+		m.layout()
+
+		m.output.Width = msg.Width - 4
+		m.output.Height = m.outputHeight()
 
 		if m.list.SelectedItem() != nil {
 			if i, ok := m.list.SelectedItem().(recipeItem); ok {
@@ -655,12 +1251,74 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case recipesLoadedMsg:
+		if msg.err != nil {
+			m.fail(fmt.Errorf("failed to reload recipes: %w", msg.err), func(*model) tea.Cmd { return reloadRecipes })
+			return m, nil
+		}
+		m.recipes = msg.dump.Recipes
+		m.aliases = msg.dump.Aliases
+		m.partial = false
+		m.previews.clear()
+		m.search.reset(m.recipes)
+		cmds = append(cmds, m.list.SetItems(m.buildItems()), checkDiagnostics(m.recipes, m.aliases))
+		m.list.Title = m.listTitle()
+		// The recipe shown may have changed too
+		if i, ok := m.list.SelectedItem().(recipeItem); ok {
+			cmds = append(cmds, m.updateViewportContent(i.name))
+		}
+
+	case justfileCheckMsg:
+		return m.handleJustfileCheck(msg)
+
+	case diagnosticsMsg:
+		m.diagnostics = msg
+		m.list.Title = m.listTitle()
+
+	case statusMsg:
+		return m, m.list.NewStatusMessage(statusMessageStyle(string(msg)))
+
+	case confirmMsg:
+		m.startConfirm(msg)
+		return m, nil
+
+	case execMsg:
+		m.finalCmd = msg
+		return m, tea.Quit
+
+	case matrixConfirmedMsg:
+		return m, m.runMatrix(msg.run)
+
+	case runInAppMsg:
+		return m, m.runInApp(msg.recipe, msg.command)
+
+	case batchConfirmedMsg:
+		return m, m.startBatch(true)
+
+	case recipeDiffMsg:
+		m.handleRecipeDiff(msg)
+
+	case varValueMsg:
+		m.handleVarValue(msg)
+
+	case openPaneMsg:
+		return m, m.openPane(msg.name, msg.command)
+
 	case recipeContentMsg:
-		content := string(msg)
-		if m.viewport.Width > 0 {
-			content = lipgloss.NewStyle().Width(m.viewport.Width).Render(content)
+		m.setPreview(string(msg))
+
+	case defaultValueMsg:
+		m.handleDefaultValue(msg)
+		return m, nil
+
+	case releaseMsg:
+		return m, m.handleRelease(msg)
+
+	case previewDueMsg:
+		if i, ok := m.list.SelectedItem().(recipeItem); ok && i.name == string(msg) {
+			return m, m.updateViewportContent(i.name)
 		}
-		m.viewport.SetContent(content)
+		return m, nil
 
 	case modelsFetchedMsg:
 		m.state = viewModelSelect
@@ -670,8 +1328,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// Setup model list
-		delegate := list.NewDefaultDelegate()
-		m.modelList = list.New(items, delegate, 0, 0)
+		m.modelList = list.New(items, list.NewDefaultDelegate(), 0, 0)
+		styleList(&m.modelList)
 		m.modelList.Title = "Select Model"
 		m.modelList.SetShowHelp(false)
 
@@ -680,10 +1338,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(targets) == 0 {
 				return nil
 			}
-			// Normalize term for better fuzzy matching
-			replacer := strings.NewReplacer(" ", "", "-", "", "_", "")
-			normalizedTerm := replacer.Replace(term)
-			matches := fuzzy.Find(normalizedTerm, targets)
+			matches := fuzzyMatch(term, targets)
 			ranks := make([]list.Rank, len(matches))
 			for i, match := range matches {
 				ranks[i] = list.Rank{
@@ -700,11 +1355,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.modelList.SetSize(m.terminalWidth, m.terminalHeight-headerHeight-2)
 		return m, nil
 
+	case semanticResultsMsg:
+		return m, m.showSemanticResults(msg)
+
 	case error:
-		if msg.Error() == "MISSING_API_KEY" {
-			m.state = viewProviderSelect
-			m.err = nil
-			m.providerIndex = 0
+		if errors.Is(msg, errNoProvider) {
+			m.startProviderSetup()
 			return m, nil
 		}
 		if msg.Error() == "list_models_failed" {
@@ -715,6 +1371,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			t.Width = 50
 			t.Focus()
 			m.inputs = []textinput.Model{t}
+			m.inputSources = nil
 			m.focusIndex = 0
 			m.err = nil // Clear error
 			return m, nil
@@ -745,19 +1402,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		*m.aiPrompt = m.list.FilterValue()
+		cmds = append(cmds, m.syncFilterItems())
+		m.list.Title = m.listTitle()
 
 		currItem := m.list.SelectedItem()
 		if currItem != nil {
 			if i, ok := currItem.(recipeItem); ok {
 				if prevItem == nil || prevItem.FilterValue() != i.name {
-					cmds = append(cmds, m.updateViewportContent(i.name))
+					// Cached previews are cheap, the others wait for the cursor to rest
+					if m.previews.has(i.name) {
+						cmds = append(cmds, m.updateViewportContent(i.name))
+					} else {
+						cmds = append(cmds, schedulePreview(i.name))
+					}
 				}
 				if _, ok := msg.(tea.WindowSizeMsg); ok {
 					cmds = append(cmds, m.updateViewportContent(i.name))
 				}
+			} else if g, ok := currItem.(groupItem); ok {
+				m.setPreview(m.groupPreview(g.name))
+			} else if _, ok := currItem.(aiItem); ok && m.listIsEmpty() {
+				m.previewContent = ""
+				m.viewport.SetContent(m.emptyStateView())
 			} else if _, ok := currItem.(aiItem); ok {
-				m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render("Select to generate a command using AI based on your search text."))
+				m.previewContent = ""
+				m.viewport.SetContent(lipgloss.NewStyle().Width(m.viewport.Width).Render(m.aiItem.previewText()))
 			}
+		} else if m.listIsEmpty() {
+			// Only when the AI item is hidden
+			m.previewContent = ""
+			m.viewport.SetContent(m.emptyStateView())
 		}
 
 		var vpCmd tea.Cmd
@@ -801,11 +1475,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					cfg = &Config{}
 				}
 
-				if m.providerIndex == 0 {
-					cfg.GoogleModel = string(i)
-				} else {
-					cfg.OpenAIModel = string(i)
-				}
+				provider := aiProviders[m.providerIndex]
+				cfg.setModel(provider, string(i))
+				cfg.Provider = provider.ID
 				SaveConfig(cfg)
 				m.state = viewList
 				return m, nil
@@ -819,6 +1491,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	} else {
 		for i := range m.inputs {
+			if _, ok := msg.(tea.KeyMsg); ok && m.inputChoices(i) != nil {
+				continue // Only changed with the choice keys
+			}
 			var cmd tea.Cmd
 			m.inputs[i], cmd = m.inputs[i].Update(msg)
 			cmds = append(cmds, cmd)
@@ -829,19 +1504,31 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) updateViewportContent(recipeName string) tea.Cmd {
+	recipes := m.recipes
+	previews := m.previews
 	return func() tea.Msg {
-		cmd := exec.Command("just", "--color", "always", "--show", recipeName)
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			return recipeContentMsg(fmt.Sprintf("Error fetching details: %v", err))
+		output, gen, ok := previews.get(recipeName)
+		if !ok {
+			var err error
+			if output, err = recipeSource(recipeName); err != nil {
+				return recipeContentMsg(fmt.Sprintf("Error fetching details: %v", err))
+			}
+			previews.put(recipeName, gen, output)
+		}
+		content := highlightRecipe(string(output)) + "\n"
+		if tree := dependencyTree(recipes, recipeName); tree != "" {
+			content += "\n" + helpStyle.Render("Dependencies:") + "\n" + tree
 		}
-		return recipeContentMsg(string(output))
+		return recipeContentMsg(content)
 	}
 }
 
 func (m model) View() string {
+	if m.manualCopy != nil {
+		return m.manualCopyView()
+	}
 	if m.err != nil {
-		return fmt.Sprintf("\nError: %v\nPress any key to dismiss.", m.err)
+		return m.errorView()
 	}
 	if !m.ready {
 		return "\n  Initializing..."
@@ -850,39 +1537,189 @@ func (m model) View() string {
 	var content string
 	if m.state == viewInput || m.state == viewApiKeyInput || m.state == viewProviderSelect || m.state == viewModelInput {
 		content = m.inputView()
+	} else if m.state == viewConfirm {
+		content = m.confirmView()
+	} else if m.state == viewRename {
+		content = m.renameView()
+	} else if m.state == viewDiagnostics {
+		content = m.diagnosticsView()
+	} else if m.state == viewOutput {
+		content = m.outputView()
+	} else if m.state == viewTmuxPanes {
+		content = m.tmuxPickerView()
+	} else if m.state == viewSemantic {
+		content = m.semanticView()
+	} else if m.state == viewAILog {
+		content = m.aiLogView()
+	} else if m.state == viewThemePicker {
+		content = m.themePickerView()
+	} else if m.state == viewGroupJump {
+		content = m.groupJumpView()
+	} else if m.state == viewDependencyGraph {
+		content = m.dependencyGraphView()
+	} else if m.state == viewRecipeDiff {
+		content = m.recipeDiffView()
+	} else if m.state == viewEnvEditor {
+		content = m.envEditorView()
+	} else if m.state == viewVarEditor {
+		content = m.varEditorView()
+	} else if m.state == viewProjectSwitcher {
+		content = m.projectSwitcherView()
+	} else if m.state == viewOutputLogs {
+		content = m.outputLogsView()
+	} else if m.state == viewPromptEditor {
+		content = m.promptEditorView()
+	} else if m.state == viewDirPicker {
+		content = m.dirPickerView()
+	} else if m.state == viewStats {
+		content = m.statsView()
+	} else if m.state == viewReleaseNotes {
+		content = m.releaseNotesView()
+	} else if m.state == viewFileBrowser {
+		content = m.fileBrowserView()
+	} else if m.state == viewRefine {
+		content = m.refineView()
+	} else if m.state == viewAIPrompt {
+		content = m.aiPromptView()
 	} else if m.state == viewModelSelect {
 		listStyle := lipgloss.NewStyle().Margin(1, 2)
 		content = listStyle.Render(m.modelList.View())
 	} else if m.state == viewGenerating {
-		header := fmt.Sprintf("\n\n   %s Generating command...", m.spinner.View())
+		header := fmt.Sprintf("\n\n   %s Generating command...", m.spinnerView())
 
 		var output string
 		if m.streamContent != "" {
+			// Long answers keep their end in view
+			width := min(m.terminalWidth-10, 100)
+			lines := strings.Split(renderMarkdown(m.streamContent, width), "\n")
+			if height := m.terminalHeight - 10; height > 0 && len(lines) > height {
+				lines = lines[len(lines)-height:]
+			}
 			output = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("205")).
+				Foreground(activeTheme.Accent).
 				Padding(1, 2).
 				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("62")).
-				Render(m.streamContent)
+				BorderForeground(activeTheme.Border).
+				Render(strings.Join(lines, "\n"))
 		}
 
 		content = lipgloss.JoinVertical(lipgloss.Center, header, output)
 		content = lipgloss.Place(m.terminalWidth, m.terminalHeight-1, lipgloss.Center, lipgloss.Center, content)
 	} else {
 		listStyle := lipgloss.NewStyle().MarginRight(2)
+		border := activeTheme.Border
+		if m.previewFocus {
+			border = activeTheme.Accent
+		}
 		viewportStyle := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("62")).
+			BorderForeground(border).
 			Padding(0, 1)
 
-		content = lipgloss.JoinHorizontal(
-			lipgloss.Top,
-			listStyle.Render(m.list.View()),
-			viewportStyle.Width(m.viewport.Width).Height(m.viewport.Height).Render(m.viewport.View()),
-		)
+		if m.zen {
+			content = listStyle.Render(m.list.View())
+		} else {
+			content = lipgloss.JoinHorizontal(
+				lipgloss.Top,
+				listStyle.Render(m.list.View()),
+				viewportStyle.Width(m.viewport.Width).Height(m.viewport.Height).Render(m.viewport.View()),
+			)
+		}
+	}
+
+	// The footer may wrap onto a second line when the view leaves room
+	lines := 1
+	if m.terminalHeight-lipgloss.Height(content) >= 2 {
+		lines = 2
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, m.frame(content, lines), m.footerView(lines))
+}
+
+// selectRecipe runs the recipe, asking for its parameters first if it has any.
+func (m *model) selectRecipe(name string) tea.Cmd {
+	recipe := m.recipes[name]
+	m.selectedRecipe = &recipe
+
+	if len(recipe.Parameters) > 0 {
+		return m.startParamInput(recipe, nil)
 	}
+	if cfg, _ := LoadConfig(); cfg != nil && cfg.enterActionFor(name) == enterEdit {
+		return m.startCommandEdit(m.recipeCommand(name))
+	}
+	return m.runCommand(m.recipeCommand(name))
+}
+
+// checkProvider checks that AI can be used before starting an AI feature,
+// so a missing provider goes straight to the setup instead of failing later.
+func checkProvider() error {
+	cfg, _ := LoadConfig()
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	_, _, err := EnsureProvider(cfg)
+	return err
+}
+
+// startProviderSetup shows the AI provider selection, the first step of
+// picking a provider, entering its key and choosing a model.
+func (m *model) startProviderSetup() {
+	m.state = viewProviderSelect
+	m.providerIndex = 0
+	m.err = nil
+}
 
-	return lipgloss.JoinVertical(lipgloss.Left, content, m.footerView())
+// startGeneration streams an AI command for the prompt.
+func (m *model) startGeneration(prompt string) tea.Cmd {
+	m.aiRequest = prompt
+	m.aiTurns = []aiTurn{{Request: prompt}}
+	return m.streamGeneration()
+}
+
+// streamGeneration streams a command for the last turn of the conversation,
+// revising the earlier turns' command when there are any.
+func (m *model) streamGeneration() tea.Cmd {
+	m.state = viewGenerating
+	m.streamContent = ""
+	m.aiServedBy = ""
+	ch := make(chan streamResult, 100)
+	m.streamChan = ch
+	recipes, shell, request := m.recipes, *m.aiShell, m.aiRequest
+	turns := append([]aiTurn(nil), m.aiTurns...)
+
+	done := track() // Until the AI log is written
+	go func() {
+		defer recoverCrash()
+		defer done()
+		defer close(ch)
+		ctx := withServedBy(withMaxTokens(appContext(), generateMaxTokens), func(provider string, failures []string) {
+			ch <- streamResult{servedBy: fmt.Sprintf("answered by %s, %s", provider, strings.Join(failures, "; "))}
+		})
+		project := gatherProjectContext(recipes, shell)
+		project.Examples = similarExamples(request, shell)
+		onToken := func(s string) {
+			ch <- streamResult{chunk: s}
+		}
+		var response string
+		var err error
+		if len(turns) > 1 {
+			response, err = RefineCommand(ctx, turns, project, onToken)
+		} else {
+			response, err = GenerateCommand(ctx, turns[0].Request, project, onToken)
+		}
+		if errors.Is(err, errTruncated) && commandComplete(response) {
+			logDebug("Using the cut-off answer, its command is complete")
+			err = nil
+		}
+		if err != nil {
+			ch <- streamResult{err: err}
+		}
+		ch <- streamResult{done: true}
+	}()
+
+	return tea.Batch(
+		m.spinTick(),
+		waitForStream(ch),
+	)
 }
 
 func waitForStream(ch <-chan streamResult) tea.Cmd {
@@ -895,23 +1732,166 @@ func waitForStream(ch <-chan streamResult) tea.Cmd {
 	}
 }
 
-func (m model) footerView() string {
+// footerView renders the key hints in at most lines lines, see fitFooter.
+func (m model) footerView(lines int) string {
+	return helpStyle.Render(fitFooter(m.footerKeys(), m.terminalWidth, lines))
+}
+
+// footerKeys are the key hints for the current view, the most important
+// first with the way out last.
+func (m model) footerKeys() []string {
 	var keys []string
-	if m.state == viewList {
-		keys = []string{"↑/↓/j/k: navigate", "enter: select", "type: search", "ctrl+p: ai settings", "q: quit"}
+	k := m.keys
+
+	if m.pendingKeys != "" {
+		// Mid-sequence: show what can follow the leader
+		keys = append(keys, m.pendingKeys+" …")
+		for _, b := range k.leaderBindings() {
+			keys = append(keys, hint(b))
+		}
+		return keys
+	}
+
+	if m.state == viewList && m.previewFocus {
+		keys = []string{"↑/↓/pgup/pgdown: scroll preview", hint(k.FocusPane, "back to list"), hint(k.Leader)}
+	} else if m.state == viewList && len(*m.marked) > 0 && !m.list.SettingFilter() {
+		keys = []string{
+			"↑/↓/j/k: navigate",
+			hint(k.Select, fmt.Sprintf("run %d marked (%s)", len(*m.marked), strings.TrimPrefix(batchHelp(m.batchParallel), "batch runs "))),
+			hint(k.Mark),
+			hint(k.Cancel, "clear marks"),
+			hint(k.Leader),
+		}
+	} else if m.state == viewList {
+		keys = []string{"↑/↓/j/k: navigate"}
+		switch item := m.list.SelectedItem().(type) {
+		case aiItem:
+			keys = append(keys, hint(k.Select, "generate"))
+		case groupItem:
+			if item.collapsed {
+				keys = append(keys, hint(k.Select, "expand"))
+			} else {
+				keys = append(keys, hint(k.Select, "collapse"))
+			}
+		case recipeItem:
+			if len(m.recipes[item.name].Parameters) > 0 {
+				keys = append(keys, hint(k.Select, "fill params"))
+			} else {
+				keys = append(keys, hint(k.Select, "run"))
+			}
+		}
+		if m.list.SettingFilter() {
+			keys = append(keys, hint(k.Cancel, "clear filter"))
+		} else {
+			keys = append(keys, hint(k.Search), hint(k.Quit))
+		}
+		if _, ok := m.list.SelectedItem().(recipeItem); ok {
+			keys = append(keys, hint(k.RunInApp), hint(k.Explain), hint(k.Mark))
+		}
+		if !m.zen {
+			keys = append(keys, hint(k.FocusPane))
+		}
+		keys = append(keys, hint(k.Projects), hint(k.AISettings), hint(k.Leader))
 	} else if m.state == viewInput {
-		keys = []string{"tab/shift+tab: nav fields", "ctrl+f: find file", "enter: run", "esc: cancel"}
+		if len(m.inputs) > 1 {
+			keys = append(keys, "tab/shift+tab: nav fields")
+		}
+		if m.inputChoices(m.focusIndex) != nil {
+			keys = append(keys, hint(k.PrevChoice), hint(k.NextChoice))
+		} else {
+			keys = append(keys, hint(k.FindFile))
+		}
+		if m.inputHistory(m.focusIndex) != nil {
+			keys = append(keys, hint(k.PrevValue), hint(k.NextValue))
+		}
+		if m.focusIndex < len(m.inputs)-1 {
+			keys = append(keys, hint(k.Select, "next"))
+		} else {
+			keys = append(keys, hint(k.Select, "run"))
+		}
+		keys = append(keys, hint(k.RunInApp))
+		if m.isAICommand() {
+			keys = append(keys, hint(k.Explain), hint(k.Refine))
+		}
+		keys = append(keys, hint(k.Cancel))
 	} else if m.state == viewApiKeyInput {
-		keys = []string{"enter: next", "esc: cancel"}
+		keys = []string{hint(k.Select, "next"), hint(k.Cancel)}
 	} else if m.state == viewProviderSelect {
-		keys = []string{"↑/↓: select provider", "enter: next", "esc: cancel"}
+		keys = []string{"↑/↓: select provider", hint(k.Select, "next"), hint(k.Theme), hint(k.EditPrompt), hint(k.Cancel)}
 	} else if m.state == viewModelInput {
-		keys = []string{"enter: save", "esc: cancel"}
+		keys = []string{hint(k.Select, "save"), hint(k.Cancel)}
 	} else if m.state == viewModelSelect {
-		keys = []string{"↑/↓: navigate", "enter: select", "type: filter", "esc: cancel"}
+		keys = []string{"↑/↓: navigate", hint(k.Select), "type: filter", hint(k.Cancel)}
+	} else if m.state == viewConfirm {
+		keys = []string{"↑/↓: scroll", hint(k.Yes), hint(k.No)}
+		if m.confirm.typed != "" {
+			keys = []string{"↑/↓: scroll", hint(k.Select, "run once typed"), hint(k.Cancel)}
+		} else if m.confirm.onNo != nil {
+			no := fmt.Sprintf("%s: %s", strings.Join(k.No.Keys(), "/"), m.confirm.noHelp)
+			keys = []string{"↑/↓: scroll", hint(k.Yes), no, hint(k.Cancel)}
+		}
+	} else if m.state == viewRename {
+		keys = []string{hint(k.Select, "preview changes"), hint(k.Cancel)}
+	} else if m.state == viewSemantic {
+		keys = []string{hint(k.Select, "search"), hint(k.Cancel)}
+	} else if m.state == viewAILog {
+		keys = []string{"↑/↓: scroll", hint(k.Cancel, "back")}
+	} else if m.state == viewThemePicker {
+		keys = []string{"↑/↓: preview", hint(k.Select, "save"), hint(k.Cancel)}
+	} else if m.state == viewDependencyGraph {
+		keys = []string{"↑/↓: scroll", hint(k.Cancel, "back")}
+	} else if m.state == viewRecipeDiff {
+		keys = []string{"type: git ref", hint(k.Select, "compare"), "↑/↓: scroll", hint(k.Cancel, "back")}
+	} else if m.state == viewEnvEditor {
+		keys = []string{hint(k.NextField), hint(k.Select, "run"), hint(k.Cancel, "back")}
+	} else if m.state == viewVarEditor {
+		keys = []string{hint(k.NextField), hint(k.Select, "apply"), hint(k.Cancel, "back")}
+	} else if m.state == viewProjectSwitcher {
+		keys = []string{"type: narrow", "↑/↓: choose project", hint(k.Select, "switch"), hint(k.Cancel)}
+	} else if m.state == viewDirPicker {
+		keys = []string{"type: narrow or a path", "↑/↓: choose directory", hint(k.Select, "run there"), hint(k.Cancel)}
+	} else if m.state == viewStats {
+		keys = []string{"tab: sort order", "↑/↓: scroll", hint(k.Cancel, "back")}
+	} else if m.state == viewReleaseNotes {
+		keys = []string{"↑/↓: scroll", hint(k.Cancel, "back")}
+	} else if m.state == viewFileBrowser {
+		keys = []string{"type: glob", "↑/↓: choose", "←/→: up/into directory", hint(k.Select, "pick"), hint(k.ToggleHidden), hint(k.Cancel)}
+	} else if m.state == viewOutputLogs && m.outputLogs.open {
+		keys = []string{"↑/↓: scroll", hint(k.Cancel, "back")}
+	} else if m.state == viewOutputLogs {
+		keys = []string{"↑/↓: choose log", hint(k.Select, "open"), hint(k.Duplicate), hint(k.Cancel, "back")}
+	} else if m.state == viewPromptEditor {
+		keys = []string{"type: edit", hint(k.SavePrompt), hint(k.ResetPrompt), hint(k.Cancel)}
+	} else if m.state == viewRefine {
+		keys = []string{hint(k.Select, "revise command"), hint(k.Cancel, "back")}
+	} else if m.state == viewAIPrompt {
+		keys = []string{hint(k.Select, "generate"), hint(k.Cancel, "back")}
+	} else if m.state == viewGroupJump {
+		keys = []string{"type: narrow", "↑/↓: choose group", hint(k.Select, "jump"), hint(k.Cancel)}
+	} else if m.state == viewDiagnostics {
+		keys = []string{hint(k.Cancel, "back")}
+	} else if m.state == viewOutput {
+		keys = []string{"↑/↓: scroll"}
+		if m.matrix != nil {
+			keys = append(keys, "tab/shift+tab: switch value")
+		}
+		if m.watch != nil {
+			keys = append(keys, hint(k.Watch, "stop watching"))
+		} else if m.matrix == nil {
+			keys = append(keys, hint(k.Watch, "re-run on file changes"))
+		}
+		if m.running() || m.watch != nil {
+			keys = append(keys, hint(k.Cancel, "stop"))
+		} else {
+			keys = append(keys, hint(k.Rerun), hint(k.Cancel, "back"))
+		}
+	} else if m.state == viewTmuxPanes {
+		keys = []string{"↑/↓: choose pane", hint(k.Select, "send"), hint(k.Cancel)}
+	} else if m.state == viewPreviewSelect {
+		lo, hi := m.selectionRange()
+		keys = []string{"↑/↓/j/k: extend selection", hint(k.Copy, fmt.Sprintf("copy (%d)", hi-lo+1)), hint(k.Cancel)}
 	}
-	// Join with some spacing and styling. Ensure it spans full width or looks good.
-	return helpStyle.Render(strings.Join(keys, " • "))
+	return keys
 }
 
 func (m model) inputView() string {
@@ -921,17 +1901,16 @@ func (m model) inputView() string {
 		b.WriteString(titleStyle.Render("Select AI Provider"))
 		b.WriteString("\n\n")
 
-		providers := []string{"Google Gemini", "OpenAI"}
-		for i, p := range providers {
+		for i, p := range aiProviders {
 			cursor := " "
 			if m.providerIndex == i {
 				cursor = ">"
 			}
 			// Simple highlighting
 			if m.providerIndex == i {
-				b.WriteString(fmt.Sprintf("%s %s\n", cursor, lipgloss.NewStyle().Foreground(lipgloss.Color("205")).Render(p)))
+				b.WriteString(fmt.Sprintf("%s %s\n", cursor, lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(p.Label)))
 			} else {
-				b.WriteString(fmt.Sprintf("%s %s\n", cursor, p))
+				b.WriteString(fmt.Sprintf("%s %s\n", cursor, p.Label))
 			}
 		}
 
@@ -947,7 +1926,7 @@ func (m model) inputView() string {
 	if m.state == viewApiKeyInput {
 		b.WriteString(titleStyle.Render("Enter API Key"))
 		b.WriteString("\n\n")
-		b.WriteString("Please enter your Google (Gemini) or OpenAI API Key.\nIt will be saved to your config file.\n\n")
+		b.WriteString(fmt.Sprintf("Please enter your %s API Key.\nIt will be saved to %s.\n\n", aiProviders[m.providerIndex].Label, m.keyStore))
 		b.WriteString(m.inputs[0].View())
 
 		return lipgloss.Place(
@@ -963,10 +1942,7 @@ func (m model) inputView() string {
 		b.WriteString(titleStyle.Render("Enter Model Name"))
 		b.WriteString("\n\n")
 
-		defaultModel := "gemini-2.0-flash"
-		if m.providerIndex == 1 {
-			defaultModel = "gpt-4o"
-		}
+		defaultModel := aiProviders[m.providerIndex].DefaultModel
 
 		b.WriteString(fmt.Sprintf("Enter the model ID to use (default: %s).\nLeave empty to use default.\n\n", defaultModel))
 		b.WriteString(m.inputs[0].View())
@@ -988,8 +1964,44 @@ func (m model) inputView() string {
 	for i, input := range m.inputs {
 		// Highlight the focused input prompt maybe?
 		// textinput handles its own focus styling if Focus() is called.
-		b.WriteString(input.View())
+		if m.inputChoices(i) != nil {
+			b.WriteString(m.choiceView(i))
+		} else {
+			b.WriteString(input.View())
+		}
 		b.WriteString("\n")
+		if hint := m.inputHint(i); hint != "" {
+			b.WriteString(helpStyle.Render("  " + hint))
+			b.WriteString("\n")
+		}
+		if i < len(m.inputSources) && m.inputSources[i] != "" {
+			b.WriteString(helpStyle.Render("  ↳ from " + m.inputSources[i]))
+			b.WriteString("\n")
+		}
+		if m.editsCommand() {
+			b.WriteString(helpStyle.Render("  ↳ runs with " + *m.aiShell))
+			b.WriteString("\n")
+		}
+		if m.selectedRecipe.Name == "AI Command" {
+			if m.aiProblem != "" {
+				b.WriteString(lipgloss.NewStyle().Foreground(activeTheme.Accent).Render("  ⚠ " + *m.aiShell + ": " + m.aiProblem))
+				b.WriteString("\n")
+			}
+			if m.aiServedBy != "" {
+				b.WriteString(helpStyle.Render("  ↳ " + m.aiServedBy))
+				b.WriteString("\n")
+			}
+			if len(m.aiExplanation) > 0 {
+				b.WriteString("\n")
+				b.WriteString(lipgloss.NewStyle().
+					Border(lipgloss.RoundedBorder()).
+					BorderForeground(activeTheme.Border).
+					Padding(0, 1).
+					Width(min(m.terminalWidth-10, 100)).
+					Render(strings.Join(m.aiExplanation, "\n")))
+				b.WriteString("\n")
+			}
+		}
 		// Add some spacing between inputs if needed
 		if i < len(m.inputs)-1 {
 			b.WriteString("\n")
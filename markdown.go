@@ -0,0 +1,122 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	gansi "github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Models asked for a bare command sometimes answer in markdown anyway. The
+// generating view renders it with glamour rather than showing the raw
+// markup, and parseGeneration digs the command out of it. Release notes are
+// rendered the same way. The style is built from the theme rather than one
+// of glamour's, so answers are in the same colors as the rest of the UI.
+
+// renderMarkdown renders text for the terminal, wrapped to width. A code
+// block still being streamed is rendered as far as it goes.
+func renderMarkdown(text string, width int) string {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStyles(markdownStyle()),
+		glamour.WithColorProfile(lipgloss.ColorProfile()),
+		glamour.WithWordWrap(width),
+	)
+	if err == nil {
+		var out string
+		if out, err = r.Render(text); err == nil {
+			// Without the blank lines glamour puts around the document
+			lines := strings.Split(out, "\n")
+			for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[0])) == "" {
+				lines = lines[1:]
+			}
+			for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[len(lines)-1])) == "" {
+				lines = lines[:len(lines)-1]
+			}
+			return strings.Join(lines, "\n")
+		}
+	}
+	logDebug("Rendering markdown failed: %v", err)
+	return lipgloss.NewStyle().Width(width).Render(text)
+}
+
+// markdownStyle is the glamour style for the active theme.
+func markdownStyle() gansi.StyleConfig {
+	color := func(c lipgloss.CompleteColor) *string { return &c.TrueColor }
+	muted := gansi.StylePrimitive{Color: color(activeTheme.Muted)}
+	keyword := gansi.StylePrimitive{Color: color(activeTheme.Keyword)}
+	on, indent := true, uint(1)
+	bar, column, row, center := "│ ", "│", "─", "┼"
+
+	return gansi.StyleConfig{
+		Heading:        gansi.StyleBlock{StylePrimitive: gansi.StylePrimitive{Color: color(activeTheme.Accent), Bold: &on}},
+		Strong:         gansi.StylePrimitive{Bold: &on},
+		Emph:           gansi.StylePrimitive{Italic: &on},
+		Strikethrough:  gansi.StylePrimitive{CrossedOut: &on},
+		HorizontalRule: gansi.StylePrimitive{Color: color(activeTheme.Muted), Format: "\n────────\n"},
+		BlockQuote:     gansi.StyleBlock{StylePrimitive: muted, Indent: &indent, IndentToken: &bar},
+		List:           gansi.StyleList{LevelIndent: 2},
+		Item:           gansi.StylePrimitive{BlockPrefix: "• "},
+		Enumeration:    gansi.StylePrimitive{BlockPrefix: ". "},
+		Task:           gansi.StyleTask{Ticked: "[✓] ", Unticked: "[ ] "},
+		Link:           muted,
+		LinkText:       gansi.StylePrimitive{Bold: &on},
+		Code:           gansi.StyleBlock{StylePrimitive: gansi.StylePrimitive{Color: color(activeTheme.Variable)}},
+		CodeBlock: gansi.StyleCodeBlock{
+			StyleBlock: gansi.StyleBlock{StylePrimitive: muted, Indent: &indent, IndentToken: &bar},
+			Chroma: &gansi.Chroma{
+				Comment:         muted,
+				CommentPreproc:  muted,
+				Keyword:         keyword,
+				KeywordReserved: keyword,
+				KeywordType:     keyword,
+				NameBuiltin:     keyword,
+				LiteralString:   gansi.StylePrimitive{Color: color(activeTheme.String)},
+				GenericInserted: gansi.StylePrimitive{Color: color(activeTheme.Added)},
+				GenericDeleted:  gansi.StylePrimitive{Color: color(activeTheme.Removed)},
+			},
+		},
+		Table: gansi.StyleTable{CenterSeparator: &center, ColumnSeparator: &column, RowSeparator: &row},
+	}
+}
+
+// extractCommand takes the command out of the part of a response before
+// the explanation: the first fenced block if there is one, else the first
+// line, without a prompt sign or the quotes and backticks models like to
+// wrap it in. Lines continued with a backslash are joined.
+func extractCommand(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			lines = lines[i+1:]
+			break
+		}
+	}
+
+	var command string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" && command == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "```") {
+			break
+		}
+		if strings.HasSuffix(line, "\\") {
+			command += strings.TrimSuffix(line, "\\")
+			continue
+		}
+		command += line
+		break
+	}
+
+	command = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(command), "$ "))
+	for _, q := range []string{"`", `"`, "'"} {
+		inner := strings.TrimSuffix(strings.TrimPrefix(command, q), q)
+		if len(command) >= 2 && strings.HasPrefix(command, q) && strings.HasSuffix(command, q) && !strings.Contains(inner, q) {
+			command = strings.TrimSpace(inner)
+		}
+	}
+	return command
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// matrixRun runs a recipe once per value of one of its parameters, at most
+// limit at a time, and keeps every run's output around.
+type matrixRun struct {
+	batch    bool // Values are recipes to run rather than parameter values
+	recipe   string
+	param    string
+	values   []string
+	commands [][]string
+	jobs     []*job // Same order as values, nil until started
+	limit    int
+	stopped  bool
+	selected int
+}
+
+// startMatrix splits the focused parameter field on commas and runs the
+// recipe once per value, with the other fields as they are.
+func (m *model) startMatrix() tea.Cmd {
+	if m.selectedRecipe == nil || m.editsCommand() || len(m.inputs) == 0 {
+		return nil
+	}
+	param := m.selectedRecipe.Parameters[m.focusIndex]
+	var values []string
+	for _, v := range strings.Split(m.inputs[m.focusIndex].Value(), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		m.err = fmt.Errorf("enter comma separated values for %q to run a matrix", param.Name)
+		return nil
+	}
+
+	limit := 1
+	if cfg, _ := LoadConfig(); cfg != nil && cfg.MatrixConcurrency > 0 {
+		limit = cfg.MatrixConcurrency
+	}
+	run := &matrixRun{
+		recipe: m.selectedRecipe.Name,
+		param:  param.Name,
+		values: values,
+		jobs:   make([]*job, len(values)),
+		limit:  limit,
+	}
+	for _, v := range values {
+		c := *m
+		c.inputs = append([]textinput.Model(nil), m.inputs...)
+		c.inputs[m.focusIndex].SetValue(v)
+		run.commands = append(run.commands, c.formCommand())
+	}
+	if guard := guardRecipes(m.recipes, []string{run.recipe}, func() tea.Msg { return matrixConfirmedMsg{run} }); guard != nil {
+		return guard
+	}
+	return m.runMatrix(run)
+}
+
+// matrixConfirmedMsg starts a matrix run once its recipe is confirmed.
+type matrixConfirmedMsg struct{ run *matrixRun }
+
+// runMatrix switches to the output pane and starts the first runs.
+func (m *model) runMatrix(run *matrixRun) tea.Cmd {
+	m.matrix = run
+	m.job = nil
+	m.state = viewOutput
+	m.inputs = nil
+	m.inputSources = nil
+
+	cmds := run.startPending()
+	m.output = viewport.New(m.terminalWidth-4, m.outputHeight())
+	m.showJob(run.selected)
+	m.usageSeq++
+	return tea.Batch(append(cmds, m.spinTick(), m.sampleUsage())...)
+}
+
+// startPending starts waiting runs until the concurrency limit is reached.
+func (r *matrixRun) startPending() []tea.Cmd {
+	if r.stopped {
+		return nil
+	}
+	// A batch in order stops at a failure, like just with several recipes
+	if r.batch && r.limit == 1 {
+		for _, j := range r.jobs {
+			if j != nil && j.finished && (j.err != nil || j.exitCode != 0) {
+				r.stopped = true
+				return nil
+			}
+		}
+	}
+	running := 0
+	for _, j := range r.jobs {
+		if j != nil && !j.finished {
+			running++
+		}
+	}
+	var cmds []tea.Cmd
+	for i, j := range r.jobs {
+		if running >= r.limit {
+			break
+		}
+		if j == nil {
+			recipe := r.recipe
+			if r.batch {
+				recipe = r.values[i]
+			}
+			r.jobs[i] = startJob(recipe, r.commands[i], nil, "")
+			cmds = append(cmds, waitForJob(r.jobs[i]))
+			running++
+		}
+	}
+	return cmds
+}
+
+// stop cancels the running jobs and skips the ones that haven't started.
+func (r *matrixRun) stop() {
+	r.stopped = true
+	for _, j := range r.jobs {
+		if j != nil && !j.finished {
+			j.cancel()
+		}
+	}
+}
+
+func (r *matrixRun) done() bool {
+	for _, j := range r.jobs {
+		if j == nil && !r.stopped || j != nil && !j.finished {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *matrixRun) find(id int) *job {
+	for _, j := range r.jobs {
+		if j != nil && j.id == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// showJob shows the output of the i-th matrix run in the output pane.
+func (m *model) showJob(i int) {
+	m.matrix.selected = i
+	m.job = m.matrix.jobs[i]
+	if m.job == nil {
+		m.output.SetContent("")
+		return
+	}
+	m.output.SetContent(m.job.content())
+	m.output.GotoBottom()
+}
+
+// summaryView renders one line per value with the state of its run.
+func (r *matrixRun) summaryView(spin string) string {
+	width := 0
+	for _, v := range r.values {
+		width = max(width, lipgloss.Width(v))
+	}
+	var b strings.Builder
+	if r.batch {
+		fmt.Fprintln(&b, r.batchSummary())
+	} else {
+		fmt.Fprintf(&b, "%s = each of %d values, %d at a time\n", r.param, len(r.values), r.limit)
+	}
+	for i, v := range r.values {
+		cursor := "  "
+		if i == r.selected {
+			cursor = "▸ "
+		}
+		var state string
+		switch j := r.jobs[i]; {
+		case j == nil && r.stopped:
+			state = "- skipped"
+		case j == nil:
+			state = "· waiting"
+		case !j.finished:
+			state = spin + " running"
+			if usage := j.usageSummary(); usage != "" {
+				state += ", " + usage
+			}
+		case j.err != nil:
+			state = fmt.Sprintf("✗ %v", j.err)
+		case j.exitCode == 0:
+			state = fmt.Sprintf("✓ exit 0, %s", j.duration.Round(time.Millisecond))
+		default:
+			state = fmt.Sprintf("✗ exit %d, %s", j.exitCode, j.duration.Round(time.Millisecond))
+		}
+		fmt.Fprintf(&b, "%s%-*s  %s\n", cursor, width, v, state)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
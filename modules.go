@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// moduleSep separates module path segments in recipe names, as just writes
+// them: `docs::build` is the `build` recipe in the `docs` module.
+const moduleSep = "::"
+
+// flattenModules pulls the recipes and aliases of `mod` submodules up into
+// the top-level maps, named by their module path (`docs::build`), so the rest
+// of the app can treat them like any other recipe.
+func flattenModules(dump *JustDump) {
+	if dump.Recipes == nil {
+		dump.Recipes = make(map[string]Recipe)
+	}
+	if dump.Aliases == nil {
+		dump.Aliases = make(map[string]Alias)
+	}
+	for name, mod := range dump.Modules {
+		flattenModules(&mod)
+		prefix := name + moduleSep
+
+		for rname, r := range mod.Recipes {
+			r.Name = prefix + rname
+			deps := make([]Dependency, len(r.Dependencies))
+			for i, d := range r.Dependencies {
+				deps[i] = Dependency{Recipe: prefix + d.Recipe}
+			}
+			r.Dependencies = deps
+			dump.Recipes[r.Name] = r
+		}
+		for aname, a := range mod.Aliases {
+			dump.Aliases[prefix+aname] = Alias{Name: prefix + aname, Target: prefix + a.Target}
+		}
+	}
+	dump.Modules = nil
+}
+
+// justRecipeCommand builds the command line that runs a recipe, passing the
+// module path as separate arguments (`just docs build`).
+func justRecipeCommand(name string) []string {
+	flags, name := recipeJustfile(name)
+	return append(append([]string{"just"}, flags...), strings.Split(name, moduleSep)...)
+}
+
+// recipeSource is what `just --show` prints for the recipe.
+func recipeSource(name string) ([]byte, error) {
+	flags, name := recipeJustfile(name)
+	return justOutputArgs(append(flags, "--color", "never", "--show", name))
+}
+
+// justArgs puts the forwarded --justfile and --working-directory in front of
+// the arguments to just, unless the arguments already have them, such as a
+// workspace project's.
+func justArgs(args ...string) []string {
+	has := func(flags ...string) bool {
+		for _, a := range args {
+			for _, f := range flags {
+				if a == f || strings.HasPrefix(a, f+"=") {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	var out []string
+	if justFlags.justfile != "" && !has("--justfile", "-f") {
+		out = append(out, "--justfile", justFlags.justfile)
+	}
+	if justFlags.workingDir != "" && !has("--working-directory", "-d") {
+		out = append(out, "--working-directory", justFlags.workingDir)
+	}
+	return append(out, args...)
+}
+
+// justCommand runs just with the forwarded flags.
+func justCommand(args ...string) *exec.Cmd {
+	return exec.Command("just", justArgs(args...)...)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFlattenModules(t *testing.T) {
+	dump := JustDump{
+		Recipes: map[string]Recipe{
+			"build": {Name: "build"},
+		},
+		Modules: map[string]JustDump{
+			"docs": {
+				Recipes: map[string]Recipe{
+					"build": {Name: "build", Dependencies: []Dependency{{Recipe: "clean"}}},
+					"clean": {Name: "clean"},
+				},
+				Aliases: map[string]Alias{"b": {Name: "b", Target: "build"}},
+				Modules: map[string]JustDump{
+					"api": {Recipes: map[string]Recipe{"gen": {Name: "gen"}}},
+				},
+			},
+		},
+	}
+	flattenModules(&dump)
+
+	var names []string
+	for name, r := range dump.Recipes {
+		if r.Name != name {
+			t.Errorf("recipe %q is named %q", name, r.Name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want := []string{"build", "docs::api::gen", "docs::build", "docs::clean"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("recipes = %q, want %q", names, want)
+	}
+	if deps := dump.Recipes["docs::build"].Dependencies; !reflect.DeepEqual(deps, []Dependency{{Recipe: "docs::clean"}}) {
+		t.Errorf("docs::build dependencies = %v, want docs::clean", deps)
+	}
+	if a := dump.Aliases["docs::b"]; a != (Alias{Name: "docs::b", Target: "docs::build"}) {
+		t.Errorf("alias docs::b = %+v", a)
+	}
+	if dump.Modules != nil {
+		t.Errorf("modules = %v, want nil", dump.Modules)
+	}
+}
+
+func TestJustArgs(t *testing.T) {
+	resetJustFlags(t)
+	justFlags.justfile, justFlags.workingDir = "/p/justfile", "/p"
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--list"}, []string{"--justfile", "/p/justfile", "--working-directory", "/p", "--list"}},
+		{[]string{"--justfile", "/w/justfile", "--working-directory", "/w", "build"}, []string{"--justfile", "/w/justfile", "--working-directory", "/w", "build"}},
+		{[]string{"-f", "/w/justfile", "build"}, []string{"--working-directory", "/p", "-f", "/w/justfile", "build"}},
+	}
+	for _, tt := range tests {
+		if got := justArgs(tt.args...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("justArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
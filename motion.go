@@ -0,0 +1,37 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Every frame fills the terminal, with the footer on its last lines, so
+// switching between screens of different heights redraws only the lines
+// that changed instead of the whole screen. With "reduce_motion" set the
+// spinner stands still.
+
+// spinTick starts the spinner, unless motion is reduced.
+func (m model) spinTick() tea.Cmd {
+	if m.reduceMotion {
+		return nil
+	}
+	return m.spinTick()
+}
+
+// spinnerView is the spinner's current frame, a still dot when motion is
+// reduced.
+func (m model) spinnerView() string {
+	if m.reduceMotion {
+		return m.spinner.Style.Render("•")
+	}
+	return m.spinnerView()
+}
+
+// frame pads the content to the height the footer leaves.
+func (m model) frame(content string, footerLines int) string {
+	height := m.terminalHeight - footerLines
+	if height <= lipgloss.Height(content) {
+		return content
+	}
+	return lipgloss.PlaceVertical(height, lipgloss.Top, content)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Recipes can be opened in a new tmux pane or window, or a zellij pane,
+// next to the TUI instead of replacing it, so more can be picked while
+// they run.
+
+// Multiplexer targets, see Config.Multiplexer
+const (
+	muxTmuxPane   = "tmux-pane"
+	muxTmuxWindow = "tmux-window"
+	muxZellij     = "zellij"
+)
+
+// Msg to open a command in the multiplexer once confirmed
+type openPaneMsg struct {
+	name    string
+	command []string
+}
+
+// multiplexerFor picks where to open commands: the configured target, else
+// a pane of the multiplexer the TUI runs in.
+func multiplexerFor(cfg *Config) (string, error) {
+	if cfg != nil && cfg.Multiplexer != "" {
+		switch cfg.Multiplexer {
+		case muxTmuxPane, muxTmuxWindow, muxZellij:
+			return cfg.Multiplexer, nil
+		}
+		return "", fmt.Errorf("unknown multiplexer %q, use %s, %s or %s", cfg.Multiplexer, muxTmuxPane, muxTmuxWindow, muxZellij)
+	}
+	switch {
+	case os.Getenv("TMUX") != "":
+		return muxTmuxPane, nil
+	case os.Getenv("ZELLIJ") != "":
+		return muxZellij, nil
+	}
+	return "", errors.New("not running inside tmux or zellij, set \"multiplexer\" in the config")
+}
+
+// multiplexerCommand builds the command line that opens command in the
+// target. tmux closes panes when their command exits, so there the command
+// waits for enter before closing to keep its output readable; zellij keeps
+// the pane by itself.
+func multiplexerCommand(target, name, dir string, command []string) []string {
+	hold := shellJoin(command) + `; printf '\n[exit %s] Press enter to close' "$?"; read _`
+	switch target {
+	case muxTmuxWindow:
+		return []string{"tmux", "new-window", "-d", "-n", name, "-c", dir, "sh -c " + shellQuote(hold)}
+	case muxZellij:
+		return append([]string{"zellij", "run", "--name", name, "--cwd", dir, "--"}, command...)
+	}
+	return []string{"tmux", "split-window", "-d", "-c", dir, "sh -c " + shellQuote(hold)}
+}
+
+// openInMultiplexer starts the command in a new pane or window, in the
+// project's environment and with the env editor's overrides.
+func openInMultiplexer(name string, command, env []string) tea.Cmd {
+	return func() tea.Msg {
+		cfg, _ := LoadConfig()
+		target, err := multiplexerFor(cfg)
+		if err != nil {
+			return err
+		}
+		command = withEnvironment(command)
+		if len(env) > 0 {
+			command = append(append([]string{"env"}, env...), command...)
+		}
+		argv := multiplexerCommand(target, name, projectDir(), command)
+		if out, err := exec.Command(argv[0], argv[1:]...).CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %s", argv[0], strings.TrimSpace(string(out)))
+		}
+		where := "a new tmux pane"
+		switch target {
+		case muxTmuxWindow:
+			where = "a new tmux window"
+		case muxZellij:
+			where = "a new zellij pane"
+		}
+		return statusMsg(fmt.Sprintf("Running %s in %s", name, where))
+	}
+}
+
+// startOpenPane opens the composed command in the multiplexer, checking git
+// guards and dangerous AI commands first like a run would.
+func (m *model) startOpenPane() tea.Cmd {
+	var name string
+	var command []string
+	switch m.state {
+	case viewInput:
+		name, command = m.selectedRecipe.Name, m.formCommand()
+	case viewList:
+		i, ok := m.list.SelectedItem().(recipeItem)
+		if !ok {
+			return nil
+		}
+		if len(m.recipes[i.name].Parameters) > 0 {
+			return func() tea.Msg { return statusMsg(i.name + " needs arguments, open it from the parameter form") }
+		}
+		name, command = i.name, m.recipeCommand(i.name)
+	default:
+		return nil
+	}
+
+	open := func() tea.Msg { return openPaneMsg{name: name, command: command} }
+	if m.state == viewInput {
+		if guard := m.guardAICommand(open); guard != nil {
+			return guard
+		}
+	}
+	if m.state == viewList || !m.editsCommand() {
+		if guard := guardRecipes(m.recipes, []string{name}, open); guard != nil {
+			return guard
+		}
+	}
+	if m.state == viewInput {
+		m.acceptGeneration()
+		m.rememberFormArgs()
+	}
+	return m.openPane(name, command)
+}
+
+// openPane opens the command and goes back to the list to pick the next.
+func (m *model) openPane(name string, command []string) tea.Cmd {
+	env := m.recipeEnv(name)
+	m.runEnv = nil // Overrides are for a single run
+	if m.state == viewInput {
+		m.state = viewList
+		m.inputs = nil
+		m.inputSources = nil
+	}
+	return openInMultiplexer(name, command, env)
+}
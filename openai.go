@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// The OpenAI provider talks to the chat completions API directly, streaming
+// the answer as server-sent events. Anything OpenAI-compatible works, such
+// as LM Studio or vLLM, through openai_base_url.
+
+const defaultOpenAIURL = "https://api.openai.com/v1"
+
+// openAIURL is the API to use, openai_base_url when set.
+func openAIURL(cfg *Config) string {
+	if cfg.OpenAIBaseURL != "" {
+		return strings.TrimSuffix(cfg.OpenAIBaseURL, "/")
+	}
+	return defaultOpenAIURL
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Temperature float64         `json:"temperature"`
+	MaxTokens   int             `json:"max_tokens"`
+	Stream      bool            `json:"stream"`
+}
+
+// openAIChunk is one event of a streamed completion. Errors can come
+// mid-stream, in place of a chunk.
+type openAIChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Error *openAIError `json:"error"`
+}
+
+type openAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// openAIPost sends a request to the API, turning error responses into
+// errors with the message the API gave.
+func openAIPost(ctx context.Context, cfg *Config, key, path string, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", openAIURL(cfg)+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var result struct {
+			Error openAIError `json:"error"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(raw, &result) == nil && result.Error.Message != "" {
+			return nil, fmt.Errorf("OpenAI API returned %s: %s", resp.Status, result.Error.Message)
+		}
+		return nil, fmt.Errorf("OpenAI API returned %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// openAICompletion streams a chat completion for the prompt to onToken,
+// returning the whole answer.
+func openAICompletion(ctx context.Context, cfg *Config, key, model, prompt string, onToken func(string)) (string, error) {
+	resp, err := openAIPost(ctx, cfg, key, "/chat/completions", openAIChatRequest{
+		Model:       model,
+		Messages:    []openAIMessage{{Role: "user", Content: prompt}},
+		Temperature: 0,
+		MaxTokens:   maxTokens(ctx),
+		Stream:      true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("AI generation failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var full strings.Builder
+	truncated := false
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:"); ok {
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				break
+			}
+			var chunk openAIChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return "", fmt.Errorf("AI generation failed: unexpected event %q", data)
+			}
+			if chunk.Error != nil {
+				return "", fmt.Errorf("AI generation failed: %s", chunk.Error.Message)
+			}
+			for _, c := range chunk.Choices {
+				truncated = truncated || c.FinishReason == "length"
+				if c.Delta.Content == "" {
+					continue
+				}
+				full.WriteString(c.Delta.Content)
+				logDebug("Received chunk: %q", c.Delta.Content)
+				if onToken != nil {
+					onToken(c.Delta.Content)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("AI generation failed: %w", err)
+		}
+	}
+	if full.Len() == 0 {
+		return "", fmt.Errorf("no response from AI")
+	}
+	if truncated {
+		return full.String(), errTruncated
+	}
+	return full.String(), nil
+}
+
+// openAIEmbeddings embeds the texts with the embeddings API.
+func openAIEmbeddings(ctx context.Context, cfg *Config, key, model string, texts []string) ([][]float32, error) {
+	resp, err := openAIPost(ctx, cfg, key, "/embeddings", map[string]any{"model": model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("OpenAI API returned %d embeddings for %d texts", len(result.Data), len(texts))
+	}
+	out := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(out) {
+			return nil, fmt.Errorf("OpenAI API returned an embedding for text %d of %d", d.Index, len(texts))
+		}
+		out[d.Index] = d.Embedding
+	}
+	return out, nil
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// With "log_output" set, the output of every in-app run is also written to
+// a log file in the state directory, one directory per project. ctrl+x L
+// lists them and opens one in a viewer, or, with e, the parameter form of
+// the recipe filled in with the values it ran with.
+
+const (
+	maxOutputLogs   = 100 // Kept per project, the oldest are removed
+	outputLogLayout = "20060102-150405"
+)
+
+// unsafeLogName matches what doesn't belong in a log file name.
+var unsafeLogName = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// outputLogDir is where the project's output logs go.
+func outputLogDir() (string, error) {
+	h := sha256.Sum256([]byte(projectDir()))
+	path, err := xdg.StateFile(filepath.Join("just-do-it", "output", hex.EncodeToString(h[:])[:16], "x"))
+	return filepath.Dir(path), err
+}
+
+// createOutputLog opens a new log for a run of the command, starting with
+// the command line. Logs beyond maxOutputLogs are removed.
+func createOutputLog(recipe string, command []string, started time.Time) (*os.File, error) {
+	dir, err := outputLogDir()
+	if err != nil {
+		return nil, err
+	}
+	name := strings.Trim(unsafeLogName.ReplaceAllString(recipe, "_"), "_")
+	if name == "" {
+		name = "command"
+	}
+	f, err := os.OpenFile(filepath.Join(dir, started.Format(outputLogLayout)+"-"+name+".log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(f, "$ %s\n\n", shellJoin(command))
+
+	if logs := listOutputLogs(); len(logs) > maxOutputLogs {
+		for _, l := range logs[maxOutputLogs:] {
+			os.Remove(l.path)
+		}
+	}
+	return f, nil
+}
+
+// closeOutputLog ends the log with how the run went.
+func closeOutputLog(f *os.File, ev jobEvent, duration time.Duration) {
+	switch {
+	case ev.err != nil:
+		fmt.Fprintf(f, "\n[failed after %s: %v]\n", duration.Round(time.Millisecond), ev.err)
+	default:
+		fmt.Fprintf(f, "\n[exit %d after %s]\n", ev.exitCode, duration.Round(time.Millisecond))
+	}
+	f.Close()
+}
+
+// outputLog is a log file of an earlier run.
+type outputLog struct {
+	path    string
+	recipe  string
+	started time.Time
+	size    int64
+}
+
+// listOutputLogs returns the project's logs, newest first.
+func listOutputLogs() []outputLog {
+	dir, err := outputLogDir()
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var logs []outputLog
+	for _, e := range entries {
+		stamp, recipe, ok := strings.Cut(strings.TrimSuffix(e.Name(), ".log"), "-")
+		if !ok || !strings.HasSuffix(e.Name(), ".log") {
+			continue
+		}
+		clock, recipe, ok := strings.Cut(recipe, "-")
+		if !ok {
+			continue
+		}
+		started, err := time.ParseInLocation(outputLogLayout, stamp+"-"+clock, time.Local)
+		if err != nil {
+			continue
+		}
+		l := outputLog{path: filepath.Join(dir, e.Name()), recipe: recipe, started: started}
+		if info, err := e.Info(); err == nil {
+			l.size = info.Size()
+		}
+		logs = append(logs, l)
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].path > logs[j].path })
+	return logs
+}
+
+// outputLogBrowser lists the logs, and shows the one opened.
+type outputLogBrowser struct {
+	logs   []outputLog
+	cursor int
+	open   bool
+	viewer viewport.Model
+}
+
+func (m *model) startOutputLogs() tea.Cmd {
+	logs := listOutputLogs()
+	if len(logs) == 0 {
+		help := "No output logs for this project yet"
+		if cfg, _ := LoadConfig(); cfg == nil || !cfg.LogOutput {
+			help += `, set "log_output": true in the config to keep them`
+		}
+		return func() tea.Msg { return statusMsg(help) }
+	}
+	m.outputLogs = &outputLogBrowser{logs: logs}
+	m.state = viewOutputLogs
+	return nil
+}
+
+func (m model) updateOutputLogs(msg tea.KeyMsg) (model, tea.Cmd) {
+	b := m.outputLogs
+	if b.open {
+		if key.Matches(msg, m.keys.Cancel, m.keys.Quit) {
+			b.open = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		b.viewer, cmd = b.viewer.Update(msg)
+		return m, cmd
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.Cancel, m.keys.Quit):
+		m.state = viewList
+		m.outputLogs = nil
+	case key.Matches(msg, m.keys.Up):
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if b.cursor < len(b.logs)-1 {
+			b.cursor++
+		}
+	case key.Matches(msg, m.keys.Duplicate):
+		return m, m.duplicateRun(b.logs[b.cursor])
+	case key.Matches(msg, m.keys.Select):
+		data, err := os.ReadFile(b.logs[b.cursor].path)
+		if err != nil {
+			return m, func() tea.Msg { return statusMsg(fmt.Sprintf("Reading the log failed: %v", err)) }
+		}
+		b.viewer = viewport.New(m.terminalWidth-4, m.terminalHeight-7)
+		b.viewer.SetContent(string(data))
+		b.viewer.GotoBottom()
+		b.open = true
+	}
+	return m, nil
+}
+
+func (m model) outputLogsView() string {
+	b := m.outputLogs
+	if b.open {
+		l := b.logs[b.cursor]
+		body := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(activeTheme.Border).
+			Render(b.viewer.View())
+		return lipgloss.NewStyle().Margin(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left,
+			titleStyle.Render(fmt.Sprintf("%s · %s", l.recipe, l.started.Format("2006-01-02 15:04:05"))), body))
+	}
+
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Output logs"))
+	s.WriteString("\n\n")
+	// Only as many as fit, scrolling with the cursor
+	shown := max(m.terminalHeight-8, 1)
+	offset := max(0, b.cursor-shown+1)
+	for i := offset; i < min(len(b.logs), offset+shown); i++ {
+		l := b.logs[i]
+		name := l.recipe
+		cursor := " "
+		if i == b.cursor {
+			name = lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(name)
+			cursor = ">"
+		}
+		fmt.Fprintf(&s, "%s %s  %s  %s\n", cursor, helpStyle.Render(l.started.Format("2006-01-02 15:04:05")), name, helpStyle.Render(formatBytes(l.size)))
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(s.String())
+}
+
+// duplicateRun opens the parameter form of the logged run's recipe with the
+// values it ran with, to be changed before running it again.
+func (m *model) duplicateRun(l outputLog) tea.Cmd {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return func() tea.Msg { return statusMsg(fmt.Sprintf("Reading the log failed: %v", err)) }
+	}
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	f.Close()
+	recipe, args, ok := m.loggedRecipe(shellSplit(strings.TrimPrefix(strings.TrimSpace(line), "$ ")))
+	if !ok {
+		return func() tea.Msg { return statusMsg("This run isn't of a recipe in this project") }
+	}
+	if len(recipe.Parameters) == 0 {
+		return func() tea.Msg { return statusMsg(recipe.Name + " has no parameters, r in the output pane re-runs it") }
+	}
+
+	values := map[string]string{}
+	for i, p := range recipe.Parameters {
+		if i >= len(args) {
+			break
+		}
+		if i == len(recipe.Parameters)-1 && (p.Kind == "plus" || p.Kind == "star") {
+			values[p.Name] = strings.Join(args[i:], " ")
+		} else {
+			values[p.Name] = args[i]
+		}
+	}
+	m.outputLogs = nil
+	cmd := m.startParamInput(recipe, values)
+	for i, p := range recipe.Parameters {
+		if _, ok := values[p.Name]; ok {
+			m.inputSources[i] = "the run of " + l.started.Format("2006-01-02 15:04")
+		}
+	}
+	return cmd
+}
+
+// loggedRecipe finds the recipe in a logged just command line, and the
+// arguments it was given. The options in front of the recipe are skipped,
+// and of the recipes that match, the longest module path wins, then a
+// workspace project's over the root justfile's.
+func (m model) loggedRecipe(command []string) (Recipe, []string, bool) {
+	if len(command) == 0 || command[0] != "just" {
+		return Recipe{}, nil, false
+	}
+	i := 1
+	for i < len(command) && strings.HasPrefix(command[i], "-") {
+		switch command[i] {
+		case "--set":
+			i += 3
+		case "--justfile", "--working-directory", "-f", "-d":
+			i += 2
+		default:
+			i++
+		}
+	}
+	if i > len(command) {
+		return Recipe{}, nil, false
+	}
+	rest := command[i:]
+	var found Recipe
+	var path []string
+	inProject := false
+	for name, r := range m.recipes {
+		flags, justName := recipeJustfile(name)
+		p := strings.Split(justName, moduleSep)
+		if len(p) > len(rest) || !slices.Equal(p, rest[:len(p)]) {
+			continue
+		}
+		// Workspace projects are told apart by their justfile
+		project := strings.Contains(name, moduleSep) && len(flags) > 1 && flags[0] == "--justfile"
+		if project && !containsString(command[:i], flags[1]) {
+			continue
+		}
+		if len(p) < len(path) || len(p) == len(path) && (!project || inProject) {
+			continue
+		}
+		found, path, inProject = r, p, project
+	}
+	if path == nil {
+		return Recipe{}, nil, false
+	}
+	return found, rest[len(path):], true
+}
+
+// shellSplit splits a command line written by shellJoin back into its
+// arguments.
+func shellSplit(s string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg, quoted := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted && c == '\'':
+			quoted = false
+		case quoted:
+			arg.WriteByte(c)
+		case c == '\'':
+			quoted, inArg = true, true
+		case c == ' ':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+			}
+			inArg = false
+		case c == '\\' && i+1 < len(s):
+			i++
+			arg.WriteByte(s[i])
+			inArg = true
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
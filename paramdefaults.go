@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Parameter defaults can be expressions, such as `arch()` or a variable,
+// which the dump writes as a syntax tree rather than a string. They are
+// turned back into just syntax, shown in the form along with the value they
+// evaluate to, and left for just to evaluate when the field stays empty.
+// Parameters exported as environment variables (`$name`) are marked too.
+
+func (p *Parameter) UnmarshalJSON(data []byte) error {
+	type plain Parameter // Without this method
+	var raw struct {
+		plain
+		Default json.RawMessage `json:"default"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = Parameter(raw.plain)
+	p.Default, p.DefaultExpr = nil, false
+	if len(raw.Default) == 0 || string(raw.Default) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(raw.Default, &s); err == nil {
+		p.Default = &s
+		return nil
+	}
+	expr := justExpression(raw.Default)
+	p.Default, p.DefaultExpr = &expr, true
+	return nil
+}
+
+// justExpression writes an expression from the dump in just's syntax. Forms
+// it doesn't know are written as the dump has them.
+func justExpression(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return justString(s)
+	}
+	var node []json.RawMessage
+	if err := json.Unmarshal(raw, &node); err != nil || len(node) == 0 {
+		return string(raw)
+	}
+	var op, name string
+	json.Unmarshal(node[0], &op)
+	args := node[1:]
+	sub := func(i int) string {
+		if i >= len(args) || string(args[i]) == "null" {
+			return ""
+		}
+		return justExpression(args[i])
+	}
+	switch {
+	case op == "variable" && len(args) == 1:
+		json.Unmarshal(args[0], &name)
+		return name
+	case op == "call" && len(args) >= 1:
+		json.Unmarshal(args[0], &name)
+		var params []string
+		for i := 1; i < len(args); i++ {
+			params = append(params, sub(i))
+		}
+		return name + "(" + strings.Join(params, ", ") + ")"
+	case op == "evaluate" && len(args) == 1:
+		json.Unmarshal(args[0], &name)
+		return "`" + name + "`"
+	case op == "concatenate" && len(args) == 2:
+		return sub(0) + " + " + sub(1)
+	case op == "join" && len(args) == 2:
+		if lhs := sub(0); lhs != "" {
+			return lhs + " / " + sub(1)
+		}
+		return "/ " + sub(1)
+	case (op == "and" || op == "or") && len(args) == 2:
+		return sub(0) + map[string]string{"and": " && ", "or": " || "}[op] + sub(1)
+	case op == "if" && len(args) == 3:
+		return "if " + justCondition(args[0]) + " { " + sub(1) + " } else { " + sub(2) + " }"
+	}
+	return string(raw)
+}
+
+// justCondition writes the condition of an if, `[op, lhs, rhs]`.
+func justCondition(raw json.RawMessage) string {
+	var node []json.RawMessage
+	if err := json.Unmarshal(raw, &node); err != nil || len(node) != 3 {
+		return justExpression(raw)
+	}
+	var op string
+	json.Unmarshal(node[0], &op)
+	return justExpression(node[1]) + " " + op + " " + justExpression(node[2])
+}
+
+// justString quotes a string literal, single-quoted unless it holds a
+// single quote.
+func justString(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// evaluateDefault asks just for the value of an expression default, by
+// evaluating it as a variable of a justfile that imports the recipe's. Other
+// parameters and module variables aren't known there, those fail.
+func evaluateDefault(recipe, expr string) (string, error) {
+	flags, name := recipeJustfile(recipe)
+	if strings.Contains(name, moduleSep) {
+		return "", errors.New("defaults of module recipes aren't evaluated")
+	}
+	justfile, dir := "", projectDir()
+	for i := 0; i+1 < len(flags); i++ {
+		switch flags[i] {
+		case "--justfile":
+			justfile = flags[i+1]
+		case "--working-directory":
+			dir = flags[i+1]
+		}
+	}
+	if justfile == "" {
+		var err error
+		if justfile, err = rootJustfile(); err != nil {
+			return "", err
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "just-do-it-default")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	const variable = "just_do_it_default"
+	source := fmt.Sprintf("import %s\n%s := %s\n", justString(justfile), variable, expr)
+	if err := os.WriteFile(filepath.Join(tmp, "justfile"), []byte(source), 0600); err != nil {
+		return "", err
+	}
+	out, err := justCommand("--justfile", filepath.Join(tmp, "justfile"), "--working-directory", dir, "--evaluate", variable).Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+		return "", errors.New(strings.TrimSpace(string(exit.Stderr)))
+	}
+	return string(out), err
+}
+
+// defaultValueMsg carries the evaluated default of a form field.
+type defaultValueMsg struct {
+	recipe string
+	field  int
+	value  string
+}
+
+// evaluateDefaults evaluates the recipe's expression defaults for the form.
+func evaluateDefaults(recipe Recipe) tea.Cmd {
+	var cmds []tea.Cmd
+	for i, p := range recipe.Parameters {
+		if !p.DefaultExpr {
+			continue
+		}
+		cmds = append(cmds, func() tea.Msg {
+			value, err := evaluateDefault(recipe.Name, *p.Default)
+			if err != nil {
+				logDebug("Evaluating the default of %s failed: %v", p.Name, err)
+				return nil
+			}
+			return defaultValueMsg{recipe: recipe.Name, field: i, value: value}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleDefaultValue adds the value to the field's placeholder.
+func (m *model) handleDefaultValue(msg defaultValueMsg) {
+	if m.state != viewInput || m.selectedRecipe == nil || m.selectedRecipe.Name != msg.recipe || msg.field >= len(m.inputs) {
+		return
+	}
+	p := m.selectedRecipe.Parameters[msg.field]
+	m.evaluated[msg.field] = msg.value
+	m.inputs[msg.field].Placeholder = fmt.Sprintf("%s (default, evaluated: %s)", *p.Default, strings.ReplaceAll(msg.value, "\n", " "))
+}
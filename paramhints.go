@@ -0,0 +1,66 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// What a parameter expects is shown under its field in the form. The help
+// comes from, in order of preference:
+//
+//	[arg("tag", help="image tag, e.g. v1.2")]  an arg attribute's help
+//	# tag: image tag, e.g. v1.2                a comment naming the parameter
+//	# @param tag image tag, e.g. v1.2          the same, javadoc style
+
+var (
+	hintComment = regexp.MustCompile(`^#\s*(?:@param\s+([\w-]+)\s+|([\w-]+)\s*:\s*)(.+)$`)
+	argHelp     = regexp.MustCompile(`arg\(\s*["']([\w-]+)["']\s*,.*?help\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// paramHints are the help texts for the form fields of recipe, empty for
+// parameters without one.
+type paramHints struct {
+	recipe string
+	text   []string
+}
+
+// findParamHints reads the help for each parameter of the recipe from the
+// comments and attributes above it. Comments listing values are shown as
+// choices instead and aren't repeated.
+func findParamHints(recipe Recipe) paramHints {
+	header, _ := recipeLines(recipe.Name)
+	hints := paramHints{recipe: recipe.Name, text: make([]string, len(recipe.Parameters))}
+	for i, p := range recipe.Parameters {
+		if p.Help != nil && *p.Help != "" {
+			hints.text[i] = *p.Help
+			continue
+		}
+		hints.text[i] = declaredHint(p.Name, header)
+	}
+	return hints
+}
+
+func declaredHint(param string, header []string) string {
+	var comment string
+	for _, line := range header {
+		line = strings.TrimSpace(line)
+		if m := argHelp.FindStringSubmatch(line); m != nil && m[1] == param {
+			return m[2] + m[3]
+		}
+		if valuesComment.MatchString(line) {
+			continue
+		}
+		if m := hintComment.FindStringSubmatch(line); m != nil && m[1]+m[2] == param && comment == "" {
+			comment = strings.TrimSpace(m[3])
+		}
+	}
+	return comment
+}
+
+// inputHint returns the help for the form field i.
+func (m model) inputHint(i int) string {
+	if m.state != viewInput || m.selectedRecipe == nil || m.hints.recipe != m.selectedRecipe.Name || i >= len(m.hints.text) || m.editsCommand() {
+		return ""
+	}
+	return m.hints.text[i]
+}
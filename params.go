@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// paramEnvPrefix is put before a parameter's upper-cased name to find its
+// pre-fill value, so `env` picks up $JUST_DO_IT_PARAM_ENV. The bare name
+// isn't used: parameters such as `path` or `user` would be filled from
+// variables that have nothing to do with them.
+const paramEnvPrefix = "JUST_DO_IT_PARAM_"
+
+// paramEnvValue looks up a pre-fill value for a parameter from the environment.
+// An explicit mapping in the config wins, then the prefixed name. Returns the
+// env var name used.
+func paramEnvValue(p Parameter, cfg *Config) (value, source string, ok bool) {
+	var candidates []string
+	if cfg != nil {
+		if name, found := cfg.ParamEnv[p.Name]; found && name != "" {
+			candidates = append(candidates, name)
+		}
+	}
+	candidates = append(candidates, paramEnvPrefix+strings.ToUpper(strings.ReplaceAll(p.Name, "-", "_")))
+
+	for _, name := range candidates {
+		if v, found := os.LookupEnv(name); found && v != "" {
+			return v, name, true
+		}
+	}
+	return "", "", false
+}
+
+// startParamInput switches to the parameter form for the given recipe,
+// pre-filling values from the environment where available. Values in prefill
+// (e.g. from --param) take precedence over the environment.
+func (m *model) startParamInput(recipe Recipe, prefill map[string]string) tea.Cmd {
+	cfg, _ := LoadConfig()
+
+	m.selectedRecipe = &recipe
+	m.state = viewInput
+	m.inputs = make([]textinput.Model, len(recipe.Parameters))
+	m.inputSources = make([]string, len(recipe.Parameters))
+	m.choices = paramChoices{recipe: recipe.Name, values: findParamChoices(recipe)}
+	m.history = formHistory(recipe)
+	m.hints = findParamHints(recipe)
+	for i, p := range recipe.Parameters {
+		t := textinput.New()
+		t.Prompt = fmt.Sprintf("%s: ", p.Name)
+		if p.Export {
+			t.Prompt = fmt.Sprintf("$%s: ", p.Name)
+		}
+		t.Width = 50
+		if p.Default != nil {
+			t.Placeholder = fmt.Sprintf("%s (default)", *p.Default)
+		}
+		if kind := paramPathKind(p, cfg); kind != notPath && p.Default == nil {
+			what := "file"
+			if kind == dirPath {
+				what = "directory"
+			}
+			t.Placeholder = fmt.Sprintf("%s (%s to browse)", what, m.keys.FindFile.Help().Key)
+		}
+		if v, ok := prefill[p.Name]; ok {
+			t.SetValue(v)
+			m.inputSources[i] = "--param"
+		} else if v, source, ok := paramEnvValue(p, cfg); ok {
+			t.SetValue(v)
+			m.inputSources[i] = "$" + source
+		}
+		if values := m.inputChoices(i); values != nil {
+			// Pick the default, and keep a given value even if it isn't listed
+			if t.Value() == "" && p.Default != nil && containsString(values, *p.Default) {
+				t.SetValue(*p.Default)
+			} else if t.Value() == "" {
+				t.SetValue(values[0])
+			} else if !containsString(values, t.Value()) {
+				m.choices.values[i] = append(values, t.Value())
+			}
+		}
+		if earlier := m.inputHistory(i); len(earlier) > 0 {
+			// Complete typed values from earlier runs, accepted with →
+			t.ShowSuggestions = true
+			t.SetSuggestions(earlier)
+			t.KeyMap.AcceptSuggestion = m.keys.NextChoice
+		}
+		if i == 0 {
+			t.Focus()
+		}
+		m.inputs[i] = t
+	}
+	m.focusIndex = 0
+	m.evaluated = map[int]string{}
+	return tea.Batch(textinput.Blink, evaluateDefaults(recipe))
+}
+
+func recipeHasParam(recipe Recipe, name string) bool {
+	for _, p := range recipe.Parameters {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// formCommand builds the command line from the parameter form, falling back
+// to defaults for empty inputs.
+func (m model) formCommand() []string {
+	args := []string{}
+	for i, input := range m.inputs {
+		val := input.Value()
+		if val == "" && m.selectedRecipe.Parameters[i].DefaultExpr {
+			// Left to just to evaluate; only trailing ones can be left out
+			rest := true
+			for _, later := range m.inputs[i+1:] {
+				rest = rest && later.Value() == ""
+			}
+			if rest {
+				break
+			}
+			if value, ok := m.evaluated[i]; ok {
+				val = value
+			}
+		}
+		if val == "" && m.selectedRecipe.Parameters[i].Default != nil {
+			val = *m.selectedRecipe.Parameters[i].Default
+		}
+		if m.selectedRecipe.Parameters[i].Kind == "plus" || m.selectedRecipe.Parameters[i].Kind == "star" {
+			args = append(args, strings.Fields(val)...)
+		} else {
+			args = append(args, val)
+		}
+	}
+
+	if m.editsCommand() {
+		return shellCommand(*m.aiShell, args[0])
+	}
+	return append(m.recipeCommand(m.selectedRecipe.Name), args...)
+}
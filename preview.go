@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// setPreview stores the raw recipe preview and renders it into the viewport.
+func (m *model) setPreview(content string) {
+	m.previewContent = content
+	m.renderPreview()
+}
+
+// renderPreview renders the stored preview, adding line numbers and marking
+// the visual selection when those are active.
+func (m *model) renderPreview() {
+	if m.previewContent == "" {
+		return
+	}
+	content := m.previewContent
+	if m.lineNumbers || m.state == viewPreviewSelect {
+		lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+		width := len(fmt.Sprint(len(lines)))
+		lo, hi := m.selectionRange()
+
+		var b strings.Builder
+		for i, line := range lines {
+			gutter := " "
+			if m.state == viewPreviewSelect && i >= lo && i <= hi {
+				gutter = lipgloss.NewStyle().Foreground(activeTheme.Accent).Render("▌")
+			}
+			b.WriteString(gutter)
+			if m.lineNumbers {
+				b.WriteString(helpStyle.Render(fmt.Sprintf("%*d ", width, i+1)))
+			}
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		content = b.String()
+	}
+
+	if m.viewport.Width > 0 {
+		content = lipgloss.NewStyle().Width(m.viewport.Width).Render(content)
+	}
+	m.viewport.SetContent(content)
+}
+
+// previewLines returns the preview as plain text lines.
+func (m model) previewLines() []string {
+	return strings.Split(strings.TrimRight(ansi.Strip(m.previewContent), "\n"), "\n")
+}
+
+// selectionRange returns the selected lines, in order.
+func (m model) selectionRange() (int, int) {
+	if m.selectAnchor <= m.selectCursor {
+		return m.selectAnchor, m.selectCursor
+	}
+	return m.selectCursor, m.selectAnchor
+}
+
+// startPreviewSelect enters visual-select mode on the preview pane.
+func (m *model) startPreviewSelect() {
+	if m.previewContent == "" {
+		return
+	}
+	m.state = viewPreviewSelect
+	m.selectAnchor = m.viewport.YOffset
+	m.selectCursor = m.selectAnchor
+	m.renderPreview()
+}
+
+func (m model) updatePreviewSelect(msg tea.KeyMsg) (model, tea.Cmd) {
+	lines := m.previewLines()
+
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		if m.selectCursor > 0 {
+			m.selectCursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if m.selectCursor < len(lines)-1 {
+			m.selectCursor++
+		}
+	case key.Matches(msg, m.keys.Copy):
+		lo, hi := m.selectionRange()
+		m.state = viewList
+		m.renderPreview()
+
+		text := strings.Join(lines[lo:hi+1], "\n")
+		if err := copyToClipboard(text); err != nil {
+			return m, m.showManualCopy(text, err)
+		}
+		return m, m.list.NewStatusMessage(statusMessageStyle(fmt.Sprintf("Copied %d line(s)", hi-lo+1)))
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewList
+		m.renderPreview()
+		return m, nil
+	}
+
+	// Keep the cursor in view
+	if m.selectCursor < m.viewport.YOffset {
+		m.viewport.SetYOffset(m.selectCursor)
+	} else if m.selectCursor >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(m.selectCursor - m.viewport.Height + 1)
+	}
+	m.renderPreview()
+	return m, nil
+}
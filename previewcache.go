@@ -0,0 +1,46 @@
+package main
+
+import "sync"
+
+// previewCache keeps what `just --show` printed for each recipe, so going
+// back over the list doesn't run just again. It's cleared whenever the
+// recipes are reloaded, which happens when a justfile source changes on disk.
+// Fetches run as commands, off the update loop, hence the lock.
+type previewCache struct {
+	mu      sync.Mutex
+	gen     int // Bumped by clear, so fetches started before it are dropped
+	sources map[string][]byte
+}
+
+func newPreviewCache() *previewCache {
+	return &previewCache{sources: map[string][]byte{}}
+}
+
+func (c *previewCache) get(recipe string) ([]byte, int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	source, ok := c.sources[recipe]
+	return source, c.gen, ok
+}
+
+func (c *previewCache) has(recipe string) bool {
+	_, _, ok := c.get(recipe)
+	return ok
+}
+
+// put stores the source fetched in generation gen, unless the cache was
+// cleared since.
+func (c *previewCache) put(recipe string, gen int, source []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen == c.gen {
+		c.sources[recipe] = source
+	}
+}
+
+func (c *previewCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.sources = map[string][]byte{}
+}
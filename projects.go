@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The justfiles the tool was opened with are remembered, and ctrl+o
+// switches to another one without restarting. Every later just invocation
+// then uses that justfile and its directory.
+
+const maxRecentProjects = 20
+
+// recentProject is a justfile the tool was opened with.
+type recentProject struct {
+	Justfile string    `json:"justfile"`
+	Opened   time.Time `json:"opened"`
+}
+
+func recentProjectsPath() (string, error) {
+	return xdg.DataFile("just-do-it/projects.json")
+}
+
+// loadRecentProjects returns the remembered justfiles, most recent first,
+// leaving out those that are gone.
+func loadRecentProjects() []recentProject {
+	path, err := recentProjectsPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var projects []recentProject
+	if err := json.Unmarshal(data, &projects); err != nil {
+		logDebug("Ignoring broken projects file %s: %v", path, err)
+		return nil
+	}
+	var present []recentProject
+	for _, p := range projects {
+		if _, err := os.Stat(p.Justfile); err == nil {
+			present = append(present, p)
+		}
+	}
+	return present
+}
+
+// rememberProject moves the justfile to the front of the recent projects.
+func rememberProject(justfile string) error {
+	projects := []recentProject{{Justfile: justfile, Opened: time.Now()}}
+	for _, p := range loadRecentProjects() {
+		if p.Justfile != justfile {
+			projects = append(projects, p)
+		}
+	}
+	projects = projects[:min(len(projects), maxRecentProjects)]
+
+	path, err := recentProjectsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(projects, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// currentJustfile is the absolute path of the justfile in use.
+func currentJustfile() (string, error) {
+	path, err := rootJustfile()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// rememberCurrentProject records the justfile in use, if there is one.
+func rememberCurrentProject() {
+	path, err := currentJustfile()
+	if err != nil {
+		return
+	}
+	if err := rememberProject(path); err != nil {
+		logDebug("Saving recent projects failed: %v", err)
+	}
+}
+
+// projectSwitcher is the quick menu of recent projects, narrowed by typing
+// part of the path.
+type projectSwitcher struct {
+	query    string
+	all      []string // Justfiles other than the current one
+	projects []string // Matching the query
+	cursor   int
+}
+
+func (m *model) startProjectSwitcher() tea.Cmd {
+	current, _ := currentJustfile()
+	var all []string
+	for _, p := range loadRecentProjects() {
+		if p.Justfile != current {
+			all = append(all, p.Justfile)
+		}
+	}
+	if len(all) == 0 {
+		return func() tea.Msg {
+			return statusMsg("No other projects yet, they're listed here once just-do-it was opened in them")
+		}
+	}
+	m.switcher = &projectSwitcher{all: all}
+	m.switcher.filter()
+	m.state = viewProjectSwitcher
+	return nil
+}
+
+func (s *projectSwitcher) filter() {
+	s.cursor = 0
+	if s.query == "" {
+		s.projects = s.all
+		return
+	}
+	s.projects = nil
+	for _, match := range fuzzyMatch(s.query, s.all) {
+		s.projects = append(s.projects, s.all[match.Index])
+	}
+}
+
+// switchProject makes the justfile the one every just invocation uses, and
+// loads its recipes.
+func (m *model) switchProject(justfile string) tea.Cmd {
+	dir := filepath.Dir(justfile)
+	if err := os.Chdir(dir); err != nil {
+		m.err = fmt.Errorf("switching to %s: %v", dir, err)
+		return nil
+	}
+	justFlags.justfile, justFlags.workingDir = justfile, dir
+	if err := rememberProject(justfile); err != nil {
+		logDebug("Saving recent projects failed: %v", err)
+	}
+
+	// Everything picked for the previous project goes
+	*m.marked = nil
+	m.groupFilter = ""
+	m.semanticQuery = ""
+	m.collapsed = make(map[string]bool)
+	m.varOverrides = nil
+	m.runEnv = nil
+	m.durations = loadDurations()
+	m.previews.clear()
+	m.project = projectName()
+	m.list.ResetFilter()
+	cfg, _ := LoadConfig()
+	ws, err := loadWorkspace(cfg)
+	if err != nil {
+		logDebug("Loading the workspace failed: %v", err)
+	}
+	workspace = ws
+	return tea.Batch(reloadRecipes, func() tea.Msg { return statusMsg("Switched to " + displayPath(justfile)) })
+}
+
+// displayPath shortens paths in the home directory to ~.
+func displayPath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + path[len(home):]
+	}
+	return path
+}
+
+func (m model) updateProjectSwitcher(msg tea.KeyMsg) (model, tea.Cmd) {
+	s := m.switcher
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewList
+		m.switcher = nil
+	case msg.Type == tea.KeyUp || msg.Type == tea.KeyShiftTab:
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case msg.Type == tea.KeyDown || msg.Type == tea.KeyTab:
+		if s.cursor < len(s.projects)-1 {
+			s.cursor++
+		}
+	case key.Matches(msg, m.keys.Select):
+		if len(s.projects) == 0 {
+			return m, nil
+		}
+		m.state = viewList
+		m.switcher = nil
+		return m, m.switchProject(s.projects[s.cursor])
+	case msg.Type == tea.KeyBackspace:
+		if s.query != "" {
+			runes := []rune(s.query)
+			s.query = string(runes[:len(runes)-1])
+			s.filter()
+		}
+	case msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace:
+		s.query += string(msg.Runes)
+		s.filter()
+	}
+	return m, nil
+}
+
+func (m model) projectSwitcherView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Switch project"))
+	b.WriteString("\n\n")
+	b.WriteString("> " + m.switcher.query + "\n\n")
+	if len(m.switcher.projects) == 0 {
+		b.WriteString(helpStyle.Render("No project matches"))
+	}
+	for i, path := range m.switcher.projects {
+		name := filepath.Base(filepath.Dir(path))
+		cursor := " "
+		if i == m.switcher.cursor {
+			name = lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(name)
+			cursor = ">"
+		}
+		fmt.Fprintf(&b, "%s %s  %s\n", cursor, name, helpStyle.Render(displayPath(path)))
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(b.String())
+}
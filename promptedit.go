@@ -0,0 +1,121 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The instructions at the start of the AI prompts come from
+// "prompt_template", so generation can be tuned: POSIX only, PowerShell,
+// commented commands. ctrl+x P (or P in the AI settings) edits it. The
+// output format the app reads is always added after it.
+
+const defaultPromptTemplate = `You are a helpful assistant that converts natural language requests into a single {shell} command.
+The command is run with {shell}, so use its syntax rather than bash's where they differ.`
+
+// promptPlaceholders are replaced in the template. Without {context}, the
+// project context follows the instructions.
+var promptPlaceholders = []string{"{os}", "{shell}", "{cwd}", "{recipes}", "{context}"}
+
+// promptInstructions renders the configured template for the project.
+func promptInstructions(project projectContext) string {
+	template := defaultPromptTemplate
+	if cfg, _ := LoadConfig(); cfg != nil && strings.TrimSpace(cfg.PromptTemplate) != "" {
+		template = cfg.PromptTemplate
+	}
+	context := project.String()
+	text := strings.NewReplacer(
+		"{os}", runtime.GOOS,
+		"{shell}", project.Shell,
+		"{cwd}", project.Dir,
+		"{recipes}", strings.Join(project.Recipes, "\n"),
+		"{context}", context,
+	).Replace(strings.TrimSpace(template))
+	if !strings.Contains(template, "{context}") {
+		text += "\n" + strings.TrimRight(context, "\n")
+	}
+	return text
+}
+
+// promptEditor edits the template, going back to the screen it was opened
+// from.
+type promptEditor struct {
+	area textarea.Model
+	back state
+	note string
+}
+
+func (m *model) startPromptEditor() tea.Cmd {
+	template := defaultPromptTemplate
+	if cfg, _ := LoadConfig(); cfg != nil && strings.TrimSpace(cfg.PromptTemplate) != "" {
+		template = cfg.PromptTemplate
+	}
+	area := textarea.New()
+	area.ShowLineNumbers = false
+	area.CharLimit = 0
+	area.SetWidth(min(m.terminalWidth-6, 100))
+	area.SetHeight(max(m.terminalHeight-12, 5))
+	area.SetValue(template)
+	m.promptEditor = &promptEditor{area: area, back: m.state}
+	m.state = viewPromptEditor
+	return area.Focus()
+}
+
+func (m model) updatePromptEditor(msg tea.KeyMsg) (model, tea.Cmd) {
+	e := m.promptEditor
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = e.back
+		m.promptEditor = nil
+		return m, nil
+	case key.Matches(msg, m.keys.ResetPrompt):
+		e.area.SetValue(defaultPromptTemplate)
+		e.note = ""
+		return m, nil
+	case key.Matches(msg, m.keys.SavePrompt):
+		cfg, _ := LoadConfig()
+		if cfg == nil {
+			cfg = &Config{}
+		}
+		// The default is left out of the config, so it follows changes to it
+		cfg.PromptTemplate = strings.TrimSpace(e.area.Value())
+		if cfg.PromptTemplate == defaultPromptTemplate {
+			cfg.PromptTemplate = ""
+		}
+		if err := SaveConfig(cfg); err != nil {
+			e.note = "Saving failed: " + err.Error()
+			return m, nil
+		}
+		m.state = e.back
+		m.promptEditor = nil
+		return m, func() tea.Msg { return statusMsg("Prompt template saved") }
+	}
+	var cmd tea.Cmd
+	e.area, cmd = e.area.Update(msg)
+	e.note = ""
+	return m, cmd
+}
+
+func (m model) promptEditorView() string {
+	e := m.promptEditor
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("AI Prompt Template"))
+	b.WriteString("\n\n")
+	b.WriteString(helpStyle.Render("Instructions sent before every request. The output format is added after them."))
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(activeTheme.Border).
+		Render(e.area.View()))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("Placeholders: " + strings.Join(promptPlaceholders, " ")))
+	if e.note != "" {
+		b.WriteString("\n\n" + lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(e.note))
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(b.String())
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// race sends the prompt to several providers at once. The first to stream a
+// token wins, its tokens go to onToken and the others are cancelled. A
+// provider failing only loses it the race; the error is returned if all of
+// them fail.
+func race(ctx context.Context, cfg *Config, ids []string, prompt string, onToken func(string)) (string, error) {
+	var providers []aiProvider
+	for _, id := range ids {
+		p, ok := findProvider(id)
+		if !ok {
+			return "", fmt.Errorf("unknown AI provider %q in race_providers", id)
+		}
+		providers = append(providers, p)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cancels := make([]context.CancelFunc, len(providers))
+	contexts := make([]context.Context, len(providers))
+	for i := range providers {
+		contexts[i], cancels[i] = context.WithCancel(ctx)
+	}
+
+	var mu sync.Mutex
+	winner := -1
+	type result struct {
+		index int
+		text  string
+		err   error
+	}
+	results := make(chan result, len(providers))
+	for i, p := range providers {
+		go func() {
+			defer recoverCrash()
+			text, err := completeWith(contexts[i], cfg, p, cfg.apiKey(p), prompt, func(s string) {
+				mu.Lock()
+				if winner == -1 {
+					winner = i
+					logDebug("%s won the race", p.Label)
+					for j, c := range cancels {
+						if j != i {
+							c()
+						}
+					}
+				}
+				won := winner == i
+				mu.Unlock()
+				if won && onToken != nil {
+					onToken(s)
+				}
+			})
+			results <- result{index: i, text: text, err: err}
+		}()
+	}
+
+	var firstErr error
+	for range providers {
+		r := <-results
+		mu.Lock()
+		w := winner
+		if w == -1 && r.err == nil {
+			// Answered without streaming, that counts too
+			w, winner = r.index, r.index
+		}
+		mu.Unlock()
+		if r.index == w {
+			return r.text, r.err
+		}
+		if r.err != nil && firstErr == nil && w == -1 {
+			firstErr = fmt.Errorf("%s: %w", providers[r.index].Label, r.err)
+		}
+	}
+	return "", firstErr
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The recipe diff shows how a recipe changed since a git revision, HEAD by
+// default, by taking it out of both versions of the justfile it's in.
+
+type recipeDiff struct {
+	recipe string
+	ref    textinput.Model
+	body   viewport.Model
+}
+
+type recipeDiffMsg struct {
+	recipe, ref string
+	text        string
+}
+
+// startRecipeDiff compares the selected recipe with HEAD.
+func (m *model) startRecipeDiff() tea.Cmd {
+	i, ok := m.list.SelectedItem().(recipeItem)
+	if !ok {
+		return nil
+	}
+	ref := textinput.New()
+	ref.Prompt = "Compare with: "
+	ref.SetValue("HEAD")
+	ref.Focus()
+	m.rdiff = &recipeDiff{
+		recipe: i.name,
+		ref:    ref,
+		body:   viewport.New(m.terminalWidth-4, m.terminalHeight-7),
+	}
+	m.rdiff.body.SetContent(helpStyle.Render("Comparing..."))
+	m.state = viewRecipeDiff
+	return tea.Batch(textinput.Blink, diffRecipe(i.name, "HEAD"))
+}
+
+// diffRecipe diffs the recipe in the working tree against ref.
+func diffRecipe(recipe, ref string) tea.Cmd {
+	return func() tea.Msg {
+		text, err := recipeDiffText(recipe, ref)
+		if err != nil {
+			text = err.Error()
+		}
+		return recipeDiffMsg{recipe: recipe, ref: ref, text: text}
+	}
+}
+
+func recipeDiffText(recipe, ref string) (string, error) {
+	docs, err := loadJustfileTree()
+	if err != nil {
+		return "", err
+	}
+	var doc *justfileDoc
+	var current []string
+	for _, d := range docs {
+		if block, ok := d.recipeBlock(recipe); ok {
+			doc, current = d, d.Lines(block)
+			break
+		}
+	}
+	if doc == nil {
+		return "", fmt.Errorf("%s isn't in the justfile", recipe)
+	}
+
+	dir := filepath.Dir(doc.path)
+	if out, err := exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}").Output(); err != nil || len(out) == 0 {
+		return "", fmt.Errorf("%s isn't a git revision here", ref)
+	}
+	var old []string
+	out, err := exec.Command("git", "-C", dir, "show", ref+":./"+filepath.Base(doc.path)).Output()
+	if err == nil {
+		previous := &justfileDoc{lines: strings.Split(string(out), "\n")}
+		if block, ok := previous.recipeBlock(recipe); ok {
+			old = previous.Lines(block)
+		}
+	}
+
+	rel := filepath.Base(doc.path)
+	switch {
+	case old == nil:
+		return fmt.Sprintf("%s is new since %s, in %s:\n\n%s\n", recipe, ref, rel, colorDiff(lineDiff(nil, current))), nil
+	case strings.Join(old, "\n") == strings.Join(current, "\n"):
+		return fmt.Sprintf("%s is unchanged since %s.\n", recipe, ref), nil
+	}
+	return fmt.Sprintf("%s in %s, %s → working tree:\n\n%s", recipe, rel, ref, colorDiff(lineDiff(old, current))), nil
+}
+
+// colorDiff colors added and removed lines of a lineDiff.
+func colorDiff(diff string) string {
+	added := lipgloss.NewStyle().Foreground(activeTheme.Added)
+	removed := lipgloss.NewStyle().Foreground(activeTheme.Removed)
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			lines[i] = added.Render(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = removed.Render(line)
+		default:
+			lines[i] = helpStyle.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleRecipeDiff shows a finished diff, unless another was asked for
+// meanwhile.
+func (m *model) handleRecipeDiff(msg recipeDiffMsg) {
+	if m.rdiff == nil || m.rdiff.recipe != msg.recipe || strings.TrimSpace(m.rdiff.ref.Value()) != msg.ref {
+		return
+	}
+	m.rdiff.body.SetContent(msg.text)
+	m.rdiff.body.GotoTop()
+}
+
+func (m model) updateRecipeDiff(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewList
+		m.rdiff = nil
+		return m, nil
+	case key.Matches(msg, m.keys.Select):
+		ref := strings.TrimSpace(m.rdiff.ref.Value())
+		if ref == "" {
+			return m, nil
+		}
+		m.rdiff.body.SetContent(helpStyle.Render("Comparing..."))
+		return m, diffRecipe(m.rdiff.recipe, ref)
+	case msg.Type == tea.KeyUp || msg.Type == tea.KeyDown || msg.Type == tea.KeyPgUp || msg.Type == tea.KeyPgDown:
+		var cmd tea.Cmd
+		m.rdiff.body, cmd = m.rdiff.body.Update(msg)
+		return m, cmd
+	}
+	var cmd tea.Cmd
+	m.rdiff.ref, cmd = m.rdiff.ref.Update(msg)
+	return m, cmd
+}
+
+func (m model) recipeDiffView() string {
+	return lipgloss.NewStyle().Margin(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("Changes: "+m.rdiff.recipe),
+		"",
+		m.rdiff.ref.View(),
+		"",
+		m.rdiff.body.View(),
+	))
+}
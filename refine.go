@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// startRefine asks for a follow-up to the AI command in the form, such as
+// "exclude node_modules". The form is left as is for going back.
+func (m *model) startRefine() tea.Cmd {
+	t := textinput.New()
+	t.Prompt = "Follow-up: "
+	t.Placeholder = "e.g. make it recursive"
+	t.Width = min(m.terminalWidth-10, 80)
+	t.Focus()
+	m.refineInput = t
+	m.state = viewRefine
+	return textinput.Blink
+}
+
+func (m model) updateRefine(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewInput
+		return m, nil
+	case key.Matches(msg, m.keys.Select):
+		followUp := strings.TrimSpace(m.refineInput.Value())
+		if followUp == "" {
+			return m, nil
+		}
+		if len(m.aiTurns) == 0 {
+			m.aiTurns = []aiTurn{{Request: m.aiRequest}}
+		}
+		// The command as edited in the form is what gets revised
+		m.aiTurns[len(m.aiTurns)-1].Command = m.inputs[0].Value()
+		m.aiTurns = append(m.aiTurns, aiTurn{Request: followUp})
+		return m, m.streamGeneration()
+	}
+
+	var cmd tea.Cmd
+	m.refineInput, cmd = m.refineInput.Update(msg)
+	return m, cmd
+}
+
+func (m model) refineView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Refine AI Command"))
+	b.WriteString("\n\n")
+	for i, t := range m.aiTurns {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("%d. %s", i+1, t.Request)))
+		b.WriteString("\n")
+		command := t.Command
+		if i == len(m.aiTurns)-1 {
+			command = m.inputs[0].Value()
+		}
+		b.WriteString("   " + lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(command))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(m.refineInput.View())
+	return lipgloss.Place(m.terminalWidth, m.terminalHeight-1, lipgloss.Center, lipgloss.Center, b.String())
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// loadJustfileTree reads the justfile just uses and every file it imports,
+// recursively.
+func loadJustfileTree() ([]*justfileDoc, error) {
+	root, err := rootJustfile()
+	if err != nil {
+		return nil, err
+	}
+	return justfileTreeFrom(root)
+}
+
+// justfileTreeFrom reads the justfile at root and every file it imports.
+func justfileTreeFrom(root string) ([]*justfileDoc, error) {
+	var docs []*justfileDoc
+	seen := map[string]bool{}
+	var walk func(path string, optional bool) error
+	walk = func(path string, optional bool) error {
+		if seen[path] {
+			return nil
+		}
+		seen[path] = true
+
+		doc, err := readJustfile(path)
+		if err != nil {
+			if optional && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		docs = append(docs, doc)
+		for _, imp := range doc.imports() {
+			if err := walk(imp.path, imp.optional); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return docs, walk(root, false)
+}
+
+type justfileImport struct {
+	path     string
+	optional bool
+}
+
+// imports lists the files pulled in with `import`, resolved relative to this
+// justfile.
+func (d *justfileDoc) imports() []justfileImport {
+	var out []justfileImport
+	for _, line := range d.lines {
+		rest, optional := "", false
+		if strings.HasPrefix(line, "import? ") {
+			rest, optional = strings.TrimPrefix(line, "import? "), true
+		} else if strings.HasPrefix(line, "import ") {
+			rest = strings.TrimPrefix(line, "import ")
+		} else {
+			continue
+		}
+		path := strings.Trim(strings.TrimSpace(rest), `'"`)
+		if path == "" {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(d.path), path)
+		}
+		out = append(out, justfileImport{path: path, optional: optional})
+	}
+	return out
+}
+
+// renameRecipe renames a recipe's definition, every dependency on it and
+// every alias pointing at it. It returns whether anything changed.
+func (d *justfileDoc) renameRecipe(oldName, newName string) bool {
+	changed := false
+	for i, line := range d.lines {
+		if name, colon, ok := parseRecipeHeader(line); ok {
+			head, deps := line[:colon], line[colon:]
+			if name == oldName {
+				head = strings.Replace(head, oldName, newName, 1)
+			}
+			if updated := head + replaceName(deps, oldName, newName); updated != line {
+				d.lines[i] = updated
+				changed = true
+			}
+			continue
+		}
+
+		// alias x := recipe
+		if strings.HasPrefix(line, "alias ") {
+			if idx := strings.Index(line, ":="); idx >= 0 {
+				updated := line[:idx] + replaceName(line[idx:], oldName, newName)
+				if updated != line {
+					d.lines[i] = updated
+					changed = true
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// replaceName renames the recipe where s names it as a dependency: a bare
+// name, or the first word inside parentheses. Arguments, quoted strings,
+// comments and recipes of modules are left alone, as are longer names
+// containing it, so renaming `build` leaves `build-docs` and
+// `(push "build")` as they are.
+func replaceName(s, oldName, newName string) string {
+	var b strings.Builder
+	depth, first := 0, false // first: the next word is the one after (
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '#':
+			b.WriteString(s[i:])
+			return b.String()
+		case c == '"' || c == '\'' || c == '`':
+			j := i + 1
+			for j < len(s) && s[j] != c {
+				if c == '"' && s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(s))
+			b.WriteString(s[i:j])
+			i, first = j, false
+		case c == '(':
+			b.WriteByte(c)
+			i, depth, first = i+1, depth+1, true
+		case c == ')':
+			b.WriteByte(c)
+			i, depth, first = i+1, max(depth-1, 0), false
+		case isNameChar(c):
+			j := i
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			word := s[i:j]
+			inModule := i >= 2 && s[i-2:i] == moduleSep
+			if word == oldName && (depth == 0 || first) && !inModule {
+				word = newName
+			}
+			b.WriteString(word)
+			i, first = j, false
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func validRecipeName(name string) bool {
+	if name == "" || name[0] == '-' || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isNameChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// startRename opens the rename prompt for the selected recipe.
+func (m *model) startRename() tea.Cmd {
+	i, ok := m.list.SelectedItem().(recipeItem)
+	if !ok {
+		return nil
+	}
+	if strings.Contains(i.name, moduleSep) {
+		m.err = fmt.Errorf("renaming recipes inside modules isn't supported yet")
+		return nil
+	}
+	t := textinput.New()
+	t.Prompt = "New name: "
+	t.Width = 40
+	t.SetValue(i.name)
+	t.Focus()
+
+	m.renameFrom = i.name
+	m.inputs = []textinput.Model{t}
+	m.inputSources = nil
+	m.focusIndex = 0
+	m.state = viewRename
+	return textinput.Blink
+}
+
+func (m model) updateRename(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewList
+		m.inputs = nil
+		return m, nil
+	case key.Matches(msg, m.keys.Select):
+		newName := strings.TrimSpace(m.inputs[0].Value())
+		if newName == m.renameFrom {
+			m.state = viewList
+			m.inputs = nil
+			return m, nil
+		}
+		if !validRecipeName(newName) {
+			m.err = fmt.Errorf("%q is not a valid recipe name", newName)
+			return m, nil
+		}
+		if _, exists := m.recipes[newName]; exists {
+			m.err = fmt.Errorf("a recipe named %q already exists", newName)
+			return m, nil
+		}
+
+		m.state = viewList
+		m.inputs = nil
+		oldName := m.renameFrom
+		return m, func() tea.Msg { return planRename(oldName, newName) }
+	}
+
+	var cmd tea.Cmd
+	m.inputs[0], cmd = m.inputs[0].Update(msg)
+	return m, cmd
+}
+
+// planRename computes the edits for a rename and asks for confirmation with
+// the diff of every touched file.
+func planRename(oldName, newName string) tea.Msg {
+	docs, err := loadJustfileTree()
+	if err != nil {
+		return err
+	}
+
+	var changed []*justfileDoc
+	var diff strings.Builder
+	for _, doc := range docs {
+		if doc.renameRecipe(oldName, newName) {
+			changed = append(changed, doc)
+			diff.WriteString(titleStyle.Render(doc.path))
+			diff.WriteString("\n")
+			diff.WriteString(doc.Diff())
+			diff.WriteString("\n")
+		}
+	}
+	if len(changed) == 0 {
+		return fmt.Errorf("recipe %q not found in the justfile", oldName)
+	}
+
+	return confirmMsg{
+		title: fmt.Sprintf("Rename %s → %s?", oldName, newName),
+		body:  diff.String(),
+		onYes: func() tea.Msg {
+			for _, doc := range changed {
+				if err := doc.CheckUnchanged(); err != nil {
+					return fmt.Errorf("%s: %w", doc.path, err)
+				}
+			}
+			for _, doc := range changed {
+				if err := doc.Save(); err != nil {
+					return fmt.Errorf("%s: %w", doc.path, err)
+				}
+			}
+			return afterJustfileWrite()
+		},
+	}
+}
+
+func (m model) renameView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Rename Recipe: " + m.renameFrom))
+	b.WriteString("\n\n")
+	b.WriteString("Dependencies and aliases in the justfile and its imports are updated too.\n\n")
+	b.WriteString(m.inputs[0].View())
+	return lipgloss.Place(m.terminalWidth, m.terminalHeight-1, lipgloss.Center, lipgloss.Center, b.String())
+}
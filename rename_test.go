@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReplaceName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{": build", ": compile"},
+		{": build-docs build", ": build-docs compile"},
+		{": (build 'x')", ": (compile 'x')"},
+		{`: (push "build")`, `: (push "build")`},
+		{": (push build)", ": (push build)"},
+		{": docs::build", ": docs::build"},
+		{": lint && build", ": lint && compile"},
+		{": lint # then build", ": lint # then build"},
+		{`: (push "a\"build") build`, `: (push "a\"build") compile`},
+		{":= build", ":= compile"},
+	}
+	for _, tt := range tests {
+		if got := replaceName(tt.in, "build", "compile"); got != tt.want {
+			t.Errorf("replaceName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenameRecipe(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		changed bool
+	}{
+		{"definition", "build:\n    go build", "compile:\n    go build", true},
+		{"quiet", "@build:", "@compile:", true},
+		{"dependency", "test: build\n    go test", "test: compile\n    go test", true},
+		{"alias", "alias b := build", "alias b := compile", true},
+		{"body", "run:\n    just build", "run:\n    just build", false},
+		{"default", "deploy what='build':", "deploy what='build':", false},
+		{"other", "build-docs:\nalias bd := build-docs", "build-docs:\nalias bd := build-docs", false},
+	}
+	for _, tt := range tests {
+		doc := &justfileDoc{lines: strings.Split(tt.in, "\n")}
+		changed := doc.renameRecipe("build", "compile")
+		if got := strings.Join(doc.lines, "\n"); got != tt.want || changed != tt.changed {
+			t.Errorf("%s: renameRecipe() = %q, %v, want %q, %v", tt.name, got, changed, tt.want, tt.changed)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// `just-do-it report` bundles what's needed to look into a problem into a
+// .tar.gz to attach to an issue: the doctor output, the config with API keys
+// redacted, the end of the debug and AI logs, and the latest crash report.
+
+// reportTailLines is how much of each log goes into the report.
+const reportTailLines = 500
+
+// configKeyValue matches API key settings in a JSON or TOML config file, so
+// keys are redacted even when they aren't shaped like one.
+var configKeyValue = regexp.MustCompile(`(api_key"?\s*[:=]\s*)"[^"]*"`)
+
+// reportFile is one file in the report.
+type reportFile struct {
+	name string
+	data []byte
+}
+
+func runReport(args []string) int {
+	fs := flag.NewFlagSet("just-do-it report", flag.ContinueOnError)
+	out := fs.String("o", "", "write the report to this file instead of just-do-it-report-<time>.tar.gz")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	path := *out
+	if path == "" {
+		path = "just-do-it-report-" + time.Now().Format("20060102-150405") + ".tar.gz"
+	}
+
+	files := collectReport()
+	if err := writeReport(path, files); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %s with:\n", path)
+	for _, f := range files {
+		fmt.Printf("  %s\n", f.name)
+	}
+	fmt.Println("\nAPI keys are redacted, but look through it before attaching it to an issue.")
+	return 0
+}
+
+// collectReport gathers the report's files, leaving out those that don't
+// exist. Everything is passed through redactKeys.
+func collectReport() []reportFile {
+	cfg, err := LoadConfig()
+	if err != nil || cfg == nil {
+		cfg = &Config{}
+	}
+	redact := func(data []byte) []byte {
+		s := configKeyValue.ReplaceAllString(string(data), `$1"[redacted]"`)
+		return []byte(redactKeys(cfg, s))
+	}
+
+	var doctor bytes.Buffer
+	runDoctor(&doctor)
+	files := []reportFile{{"doctor.txt", redact(doctor.Bytes())}}
+
+	add := func(name string, data []byte, err error) {
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logDebug("Report: leaving out %s: %v", name, err)
+			}
+			return
+		}
+		files = append(files, reportFile{name, redact(data)})
+	}
+	if path, err := GetConfigPath(); err == nil {
+		data, err := os.ReadFile(path)
+		add("config/"+filepath.Base(path), data, err)
+	}
+	data, err := os.ReadFile(projectConfigPath())
+	add("config/"+projectConfigName, data, err)
+
+	data, err = tailFile("debug.log", reportTailLines)
+	add("debug.log", data, err)
+	if path, err := aiLogPath(); err == nil {
+		data, err := tailFile(path, reportTailLines)
+		add("ai-requests.jsonl", data, err)
+	}
+	if path := latestCrash(); path != "" {
+		data, err := os.ReadFile(path)
+		add("crashes/"+filepath.Base(path), data, err)
+	}
+	return files
+}
+
+// tailFile returns the last n lines of the file.
+func tailFile(path string, n int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// latestCrash is the path of the newest crash report, empty when there is
+// none. Their names sort by time.
+func latestCrash() string {
+	dir, err := crashDir()
+	if err != nil {
+		return ""
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "crash-*.log"))
+	if len(matches) == 0 {
+		return ""
+	}
+	sort.Strings(matches)
+	return matches[len(matches)-1]
+}
+
+func writeReport(path string, files []reportFile) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	root := strings.TrimSuffix(filepath.Base(path), ".tar.gz")
+	now := time.Now()
+	for _, file := range files {
+		hdr := &tar.Header{
+			Name:    root + "/" + file.name,
+			Mode:    0600,
+			Size:    int64(len(file.data)),
+			ModTime: now,
+		}
+		if err = tw.WriteHeader(hdr); err != nil {
+			break
+		}
+		if _, err = tw.Write(file.data); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
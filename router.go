@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The router checks an AI request against the existing recipes before
+// generating a command, since the recipe is usually what the user wants.
+
+// routeSimilarity is how similar a recipe has to be to the request to be
+// suggested when routing with embeddings. Related texts typically score
+// well above it and unrelated ones below, for the default models.
+const routeSimilarity = 0.6
+
+const routePrompt = `You pick which existing just recipe fulfills a request, if any.
+%s
+Request: %s
+
+If one of the recipes does what the request asks, answer with its name (as in "just <name>") on the first line and a short reason on the second line.
+If none of them does, answer only NONE.`
+
+// Msg with the recipe the router suggests, empty if none fits
+type routeMsg struct {
+	prompt string
+	recipe string
+	reason string
+}
+
+// Msg to run a recipe as if it was selected in the list
+type selectRecipeMsg string
+
+// Msg to generate an AI command for a prompt
+type generateMsg string
+
+// routeMode returns how requests are routed, empty if they aren't.
+func routeMode() string {
+	cfg, _ := LoadConfig()
+	if cfg == nil {
+		return ""
+	}
+	return cfg.RouteRecipes
+}
+
+func routeRecipe(mode string, recipes map[string]Recipe, prompt string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := appContext()
+		msg := routeMsg{prompt: prompt}
+		var err error
+		if mode == "embeddings" {
+			msg.recipe, msg.reason, err = routeWithEmbeddings(ctx, recipes, prompt)
+		} else {
+			msg.recipe, msg.reason, err = routeWithLLM(ctx, recipes, prompt)
+		}
+		if err != nil {
+			// Command generation reports the problem, if it has the same one
+			logDebug("Routing %q failed: %v", prompt, err)
+			return routeMsg{prompt: prompt}
+		}
+		return msg
+	}
+}
+
+func routeWithLLM(ctx context.Context, recipes map[string]Recipe, prompt string) (string, string, error) {
+	project := gatherProjectContext(recipes, "")
+	response, err := complete(withMaxTokens(ctx, routeMaxTokens), fmt.Sprintf(routePrompt, project, prompt), nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	first, reason, _ := strings.Cut(strings.TrimSpace(response), "\n")
+	// Models quote the name or repeat the whole call now and then
+	name := strings.Trim(strings.TrimSpace(first), "`\"'")
+	name = strings.TrimPrefix(name, "just ")
+	// Module paths are written with spaces, arguments may follow the name
+	fields := strings.Fields(name)
+	for n := len(fields); n > 0; n-- {
+		candidate := strings.Join(fields[:n], moduleSep)
+		if _, ok := recipes[candidate]; ok {
+			return candidate, strings.TrimSpace(reason), nil
+		}
+	}
+	// Also the case for NONE
+	return "", "", nil
+}
+
+func routeWithEmbeddings(ctx context.Context, recipes map[string]Recipe, prompt string) (string, string, error) {
+	cfg, _ := LoadConfig()
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	e, err := newEmbedder(cfg)
+	if err != nil {
+		return "", "", err
+	}
+	ranked, err := rankRecipes(ctx, e, recipes, prompt)
+	if err != nil || len(ranked) == 0 || ranked[0].score < routeSimilarity {
+		return "", "", err
+	}
+	return ranked[0].name, fmt.Sprintf("%.0f%% similar to the request", ranked[0].score*100), nil
+}
+
+// handleRoute offers the suggested recipe, and otherwise goes on to
+// generate a command.
+func (m *model) handleRoute(msg routeMsg) tea.Cmd {
+	if m.state != viewGenerating {
+		return nil // Cancelled meanwhile
+	}
+	if msg.recipe == "" {
+		return m.startGeneration(msg.prompt)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "just %s\n", recipeSignature(m.recipes[msg.recipe]))
+	if doc := m.recipes[msg.recipe].Doc; doc != nil && *doc != "" {
+		fmt.Fprintf(&body, "# %s\n", *doc)
+	}
+	if msg.reason != "" {
+		fmt.Fprintf(&body, "\n%s\n", msg.reason)
+	}
+	fmt.Fprintf(&body, "\n%s\n", helpStyle.Render("Request: "+msg.prompt))
+
+	m.state = viewList
+	m.startConfirm(confirmMsg{
+		title:  fmt.Sprintf("Looks like you want `just %s`", strings.ReplaceAll(msg.recipe, moduleSep, " ")),
+		body:   body.String(),
+		onYes:  func() tea.Msg { return selectRecipeMsg(msg.recipe) },
+		onNo:   func() tea.Msg { return generateMsg(msg.prompt) },
+		noHelp: "generate a command instead",
+	})
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runHeadless implements `just-do-it run <recipe> [args...]`: it runs the
+// recipe without the TUI, asking on stdin for required parameters that
+// weren't given. It only returns on error.
+func runHeadless(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: just-do-it run <recipe> [args...]")
+		return 2
+	}
+
+	dump, err := getJustDump()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching recipes: %v\n", err)
+		return 1
+	}
+	name := args[0]
+	recipe, ok := dump.Recipes[name]
+	if !ok {
+		if alias, found := dump.Aliases[name]; found {
+			recipe, ok = dump.Recipes[alias.Target]
+		}
+	}
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown recipe %q\n", name)
+		return 1
+	}
+
+	values, err := promptMissingParams(recipe, args[1:], os.Stdin, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := confirmGitGuard(recipe.Name, os.Stdin, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	execCommand(append(justRecipeCommand(recipe.Name), values...))
+	return 0
+}
+
+// promptMissingParams asks for the parameters after the given positional
+// values that have no default. Environment pre-fill values are offered as
+// the answer to an empty reply. Prompting needs a terminal on stdin.
+func promptMissingParams(recipe Recipe, given []string, in *os.File, out io.Writer) ([]string, error) {
+	values := append([]string(nil), given...)
+	if len(given) >= len(recipe.Parameters) {
+		return values, nil
+	}
+
+	var reader *bufio.Reader
+	cfg, _ := LoadConfig()
+	for _, p := range recipe.Parameters[len(given):] {
+		variadic := p.Kind == "plus" || p.Kind == "star"
+		if p.Default != nil || p.Kind == "star" {
+			// Everything after this is optional, leave it to just
+			break
+		}
+
+		if reader == nil {
+			if info, err := in.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+				return nil, fmt.Errorf("recipe %q needs a value for %q", recipe.Name, p.Name)
+			}
+			reader = bufio.NewReader(in)
+		}
+
+		envValue, source, hasEnv := paramEnvValue(p, cfg)
+		for {
+			if hasEnv {
+				fmt.Fprintf(out, "%s [$%s=%s]: ", p.Name, source, envValue)
+			} else {
+				fmt.Fprintf(out, "%s: ", p.Name)
+			}
+			line, err := reader.ReadString('\n')
+			line = strings.TrimSpace(line)
+			if line == "" && hasEnv {
+				line = envValue
+			}
+			if line != "" {
+				if variadic {
+					values = append(values, strings.Fields(line)...)
+				} else {
+					values = append(values, line)
+				}
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("no value for %q", p.Name)
+			}
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// --script keys.txt plays keys from a file instead of the keyboard, for demo
+// recordings and bug reports that can be replayed. Each line is one step:
+//
+//	down down enter   keys, named as in the keybindings config
+//	type hello world  text, a key per character
+//	sleep 500ms       a pause
+//	resize 100x30     a terminal size
+//
+// Blank lines and lines starting with # are skipped. Once the script is
+// done the program quits and prints the state it ended in, the screen
+// included, instead of running anything.
+
+const (
+	scriptStartDelay = 300 * time.Millisecond // For the recipes to load
+	scriptKeyDelay   = 50 * time.Millisecond  // Between steps
+)
+
+// scriptStep is a message to send, or a pause when there is none.
+type scriptStep struct {
+	msg   tea.Msg
+	pause time.Duration
+}
+
+// keyTypes maps the names of keys to their type.
+var keyTypes = func() map[string]tea.KeyType {
+	types := map[string]tea.KeyType{"space": tea.KeySpace}
+	for t := tea.KeyType(-100); t <= tea.KeyBackspace; t++ {
+		if name := (tea.Key{Type: t}).String(); name != "" && name != " " {
+			types[name] = t
+		}
+	}
+	return types
+}()
+
+func loadScript(path string) ([]scriptStep, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	steps, err := parseScript(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", path, err)
+	}
+	return steps, nil
+}
+
+func parseScript(r io.Reader) ([]scriptStep, error) {
+	var steps []scriptStep
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		command, rest, _ := strings.Cut(line, " ")
+		switch command {
+		case "type":
+			for _, r := range rest {
+				steps = append(steps, scriptStep{msg: runeKey(r)})
+			}
+		case "sleep":
+			d, err := time.ParseDuration(strings.TrimSpace(rest))
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", n, err)
+			}
+			steps = append(steps, scriptStep{pause: d})
+		case "resize":
+			w, h, ok := strings.Cut(strings.TrimSpace(rest), "x")
+			width, errW := strconv.Atoi(w)
+			height, errH := strconv.Atoi(h)
+			if !ok || errW != nil || errH != nil || width <= 0 || height <= 0 {
+				return nil, fmt.Errorf("%d: expected a size like 100x30, got %q", n, rest)
+			}
+			steps = append(steps, scriptStep{msg: tea.WindowSizeMsg{Width: width, Height: height}})
+		default:
+			for _, name := range strings.Fields(line) {
+				k, err := parseKey(name)
+				if err != nil {
+					return nil, fmt.Errorf("%d: %v", n, err)
+				}
+				steps = append(steps, scriptStep{msg: k})
+			}
+		}
+	}
+	return steps, scanner.Err()
+}
+
+// parseKey reads a key name such as "enter", "ctrl+x", "alt+b" or "q".
+func parseKey(name string) (tea.KeyMsg, error) {
+	alt := false
+	if rest, ok := strings.CutPrefix(name, "alt+"); ok && rest != "" {
+		alt, name = true, rest
+	}
+	if t, ok := keyTypes[name]; ok {
+		return tea.KeyMsg{Type: t, Alt: alt}, nil
+	}
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) && r != utf8.RuneError {
+		k := runeKey(r)
+		k.Alt = alt
+		return k, nil
+	}
+	return tea.KeyMsg{}, fmt.Errorf("unknown key %q", name)
+}
+
+func runeKey(r rune) tea.KeyMsg {
+	if r == ' ' {
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+// playScript sends the steps to the program, then quits it.
+func playScript(p *tea.Program, steps []scriptStep) {
+	time.Sleep(scriptStartDelay)
+	for _, s := range steps {
+		if s.msg == nil {
+			time.Sleep(s.pause)
+			continue
+		}
+		p.Send(s.msg)
+		time.Sleep(scriptKeyDelay)
+	}
+	p.Quit()
+}
+
+// writeStateDump prints the state the script left the program in.
+func writeStateDump(w io.Writer, m model) {
+	selected := ""
+	if i, ok := m.list.SelectedItem().(recipeItem); ok {
+		selected = i.name
+	}
+	fmt.Fprintf(w, "selected: %s\n", selected)
+	fmt.Fprintf(w, "filter: %s\n", m.list.FilterValue())
+	if m.err != nil {
+		fmt.Fprintf(w, "error: %v\n", m.err)
+	}
+	if len(m.finalCmd) > 0 {
+		fmt.Fprintf(w, "command: %s\n", strings.Join(m.finalCmd, " "))
+	}
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	fmt.Fprintf(w, "screen:\n%s\n", strings.TrimRight(strings.Join(lines, "\n"), "\n"))
+}
@@ -0,0 +1,202 @@
+//go:build !noai
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/anthropic"
+	"github.com/tmc/langchaingo/llms/ollama"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// The provider SDKs make up most of the binary. Everything using them is
+// here, so `go build -tags noai` leaves them out, see sdk_noai.go.
+
+const aiEnabled = true
+
+func requestCompletion(ctx context.Context, cfg *Config, provider aiProvider, key, prompt string, onToken func(string)) (string, error) {
+	modelName := cfg.model(provider)
+
+	switch provider.ID {
+	case "google":
+		client, err := genai.NewClient(ctx, option.WithAPIKey(key))
+		if err != nil {
+			return "", fmt.Errorf("failed to create GoogleAI client: %w", err)
+		}
+		defer client.Close()
+
+		model := client.GenerativeModel(modelName)
+		var temp float32 = 0.0
+		model.Temperature = &temp
+		limit := int32(maxTokens(ctx))
+		model.MaxOutputTokens = &limit
+
+		iter := model.GenerateContentStream(ctx, genai.Text(prompt))
+
+		var fullResponse strings.Builder
+		truncated := false
+		for {
+			resp, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return "", fmt.Errorf("stream error: %w", err)
+			}
+
+			if len(resp.Candidates) > 0 {
+				truncated = truncated || resp.Candidates[0].FinishReason == genai.FinishReasonMaxTokens
+				if resp.Candidates[0].Content == nil {
+					continue
+				}
+				for _, part := range resp.Candidates[0].Content.Parts {
+					if txt, ok := part.(genai.Text); ok {
+						chunk := string(txt)
+						fullResponse.WriteString(chunk)
+						logDebug("Received chunk: %q", chunk)
+						if onToken != nil {
+							onToken(chunk)
+						}
+					}
+				}
+			}
+		}
+		if truncated {
+			return fullResponse.String(), errTruncated
+		}
+		return fullResponse.String(), nil
+
+	case "openai":
+		return openAICompletion(ctx, cfg, key, modelName, prompt, onToken)
+
+	case "anthropic":
+		llm, err := anthropic.New(anthropic.WithToken(key), anthropic.WithModel(modelName))
+		if err != nil {
+			return "", fmt.Errorf("failed to create Anthropic client: %w", err)
+		}
+		return generateWithLLM(ctx, llm, prompt, onToken)
+
+	case "ollama":
+		llm, err := ollama.New(ollama.WithServerURL(ollamaURL(cfg)), ollama.WithModel(modelName))
+		if err != nil {
+			return "", fmt.Errorf("failed to create Ollama client: %w", err)
+		}
+		return generateWithLLM(ctx, llm, prompt, onToken)
+	}
+	return "", fmt.Errorf("unknown provider")
+}
+
+// generateWithLLM streams a completion from any langchaingo model. Ollama
+// doesn't say why it stopped, its answers aren't known to be cut off.
+func generateWithLLM(ctx context.Context, llm llms.Model, prompt string, onToken func(string)) (string, error) {
+	content := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+	}
+
+	completion, err := llm.GenerateContent(ctx, content,
+		llms.WithTemperature(0.0),
+		llms.WithMaxTokens(maxTokens(ctx)),
+		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			logDebug("Received chunk: %q", string(chunk))
+			if onToken != nil && len(chunk) > 0 {
+				onToken(string(chunk))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		return "", fmt.Errorf("AI generation failed: %w", err)
+	}
+
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("no response from AI")
+	}
+
+	choice := completion.Choices[0]
+	if choice.StopReason == "max_tokens" || choice.StopReason == "length" {
+		return choice.Content, errTruncated
+	}
+	return choice.Content, nil
+}
+
+// listGoogleModels lists the Gemini models the key can use.
+func listGoogleModels(key string) ([]string, error) {
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, option.WithAPIKey(key))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	var models []string
+	iter := client.ListModels(ctx)
+	for {
+		m, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Only include generation models
+		if strings.Contains(m.Name, "gemini") {
+			// Name comes as "models/gemini-pro", strip prefix if needed or keep it
+			// langchaingo usually expects just "gemini-pro" but "models/" might be needed for pure API
+			// Let's strip "models/" for display
+			name := strings.TrimPrefix(m.Name, "models/")
+			models = append(models, name)
+		}
+	}
+	return models, nil
+}
+
+// providerEmbedding embeds texts with the provider's embeddings API.
+func providerEmbedding(cfg *Config, id, model, key string) func(ctx context.Context, texts []string) ([][]float32, error) {
+	switch id {
+	case "google":
+		return func(ctx context.Context, texts []string) ([][]float32, error) {
+			client, err := genai.NewClient(ctx, option.WithAPIKey(key))
+			if err != nil {
+				return nil, err
+			}
+			defer client.Close()
+			em := client.EmbeddingModel(model)
+
+			// The API takes at most 100 texts per batch
+			var out [][]float32
+			for start := 0; start < len(texts); start += 100 {
+				batch := em.NewBatch()
+				for _, t := range texts[start:min(start+100, len(texts))] {
+					batch.AddContent(genai.Text(t))
+				}
+				res, err := em.BatchEmbedContents(ctx, batch)
+				if err != nil {
+					return nil, err
+				}
+				for _, emb := range res.Embeddings {
+					out = append(out, emb.Values)
+				}
+			}
+			return out, nil
+		}
+	case "openai":
+		return func(ctx context.Context, texts []string) ([][]float32, error) {
+			return openAIEmbeddings(ctx, cfg, key, model, texts)
+		}
+	case "ollama":
+		return func(ctx context.Context, texts []string) ([][]float32, error) {
+			llm, err := ollama.New(ollama.WithServerURL(ollamaURL(cfg)), ollama.WithModel(model))
+			if err != nil {
+				return nil, err
+			}
+			return llm.CreateEmbedding(ctx, texts)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build noai
+
+package main
+
+import "context"
+
+// Built with -tags noai: the provider SDKs are left out, and every AI
+// feature reports errNoAI. The AI item isn't listed.
+
+const aiEnabled = false
+
+func requestCompletion(ctx context.Context, cfg *Config, provider aiProvider, key, prompt string, onToken func(string)) (string, error) {
+	return "", errNoAI
+}
+
+func listGoogleModels(key string) ([]string, error) {
+	return nil, errNoAI
+}
+
+func providerEmbedding(cfg *Config, id, model, key string) func(ctx context.Context, texts []string) ([][]float32, error) {
+	return func(context.Context, []string) ([][]float32, error) {
+		return nil, errNoAI
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// Besides names, the filter matches recipe docs and bodies, for when you
+// remember a command a recipe runs but not what it's called. Name matches
+// come first; the others follow, saying where they matched in place of the
+// description.
+
+// minSearchTerm is the shortest filter that's looked for in docs and bodies,
+// shorter ones would match nearly everything.
+const minSearchTerm = 3
+
+// recipeSearch holds the recipe texts the filter looks through, read when
+// first needed. The list filters off the UI goroutine, so it's guarded.
+type recipeSearch struct {
+	mu      sync.Mutex
+	recipes map[string]Recipe
+	bodies  map[string][]string
+	matches map[string]string // What matched, for recipes not matched by name
+}
+
+func newRecipeSearch(recipes map[string]Recipe) *recipeSearch {
+	return &recipeSearch{recipes: recipes}
+}
+
+// reset drops the texts after the recipes were reloaded.
+func (s *recipeSearch) reset(recipes map[string]Recipe) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recipes, s.bodies, s.matches = recipes, nil, nil
+}
+
+// clear forgets the matches once the filter is gone.
+func (s *recipeSearch) clear() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matches = nil
+}
+
+// loadBodies reads the recipe bodies from the justfile tree.
+func (s *recipeSearch) loadBodies() {
+	s.bodies = map[string][]string{}
+	docs, err := loadJustfileTree()
+	if err != nil {
+		return
+	}
+	for name := range s.recipes {
+		for _, d := range docs {
+			if span, ok := d.recipeSpan(name); ok {
+				s.bodies[name] = d.Lines(lineSpan{Start: span.Start + 1, End: span.End})
+				break
+			}
+		}
+	}
+}
+
+// find returns the indexes of the names whose doc or body contain term,
+// ignoring case, other than those in skip.
+func (s *recipeSearch) find(term string, names []string, skip map[string]bool) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matches = map[string]string{}
+	term = strings.ToLower(strings.TrimSpace(term))
+	if len(term) < minSearchTerm {
+		return nil
+	}
+	if s.bodies == nil {
+		s.loadBodies()
+	}
+
+	var found []int
+	for i, name := range names {
+		if skip[name] || s.matches[name] != "" {
+			continue
+		}
+		if doc := s.recipes[name].Doc; doc != nil && strings.Contains(strings.ToLower(*doc), term) {
+			s.matches[name] = "doc: " + *doc
+			found = append(found, i)
+			continue
+		}
+		for _, line := range s.bodies[name] {
+			if strings.Contains(strings.ToLower(line), term) {
+				s.matches[name] = "body: " + strings.TrimSpace(line)
+				found = append(found, i)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// match says where the recipe matched the filter, empty when it matched
+// by name or not at all.
+func (s *recipeSearch) match(name string) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.matches[name]
+}
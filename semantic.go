@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Semantic search ranks recipes by how close their name, doc and body are
+// in meaning to a query, using embeddings from one of the AI providers.
+// Recipe embeddings are cached per justfile content, so they are only
+// computed again after the justfile changes.
+
+// semanticResults is how many recipes a semantic search lists.
+const semanticResults = 15
+
+// defaultEmbeddingModels are used when embedding_model isn't set.
+var defaultEmbeddingModels = map[string]string{
+	"google": "text-embedding-004",
+	"openai": "text-embedding-3-small",
+	"ollama": "nomic-embed-text",
+}
+
+// embedder turns texts into vectors.
+type embedder struct {
+	provider string
+	model    string
+	embed    func(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// newEmbedder picks the embedding provider: embedding_provider from the
+// config, else the AI provider in use if it has an embeddings API.
+func newEmbedder(cfg *Config) (*embedder, error) {
+	id := cfg.EmbeddingProvider
+	if id == "" {
+		p, _, err := EnsureProvider(cfg)
+		if errors.Is(err, errNoProvider) {
+			return nil, fmt.Errorf("semantic search needs an AI provider, set one up with ctrl+p")
+		}
+		if err != nil {
+			return nil, err
+		}
+		id = p.ID
+	}
+	p, ok := findProvider(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown embedding provider %q", id)
+	}
+	model := cfg.EmbeddingModel
+	if model == "" {
+		model = defaultEmbeddingModels[id]
+	}
+	if model == "" {
+		return nil, fmt.Errorf("%s has no embeddings API, set embedding_provider in the config", p.Label)
+	}
+	key := cfg.apiKey(p)
+	e := &embedder{provider: id, model: model}
+
+	e.embed = providerEmbedding(cfg, id, model, key)
+	return e, nil
+}
+
+// recipeTexts is what gets embedded for each recipe: its name, doc and
+// source from the justfile.
+func recipeTexts(recipes map[string]Recipe) map[string]string {
+	docs, _ := loadJustfileTree()
+	texts := map[string]string{}
+	for name, r := range recipes {
+		var b strings.Builder
+		b.WriteString(strings.ReplaceAll(name, moduleSep, " "))
+		if r.Doc != nil {
+			b.WriteString("\n" + *r.Doc)
+		}
+		for _, doc := range docs {
+			if span, ok := doc.recipeBlock(name); ok {
+				b.WriteString("\n" + strings.Join(doc.Lines(span), "\n"))
+				break
+			}
+		}
+		texts[name] = b.String()
+	}
+	return texts
+}
+
+// embeddingCachePath names the cache file after everything that affects the
+// vectors: the provider, the model and the texts themselves.
+func embeddingCachePath(e *embedder, texts map[string]string) (string, error) {
+	names := make([]string, 0, len(texts))
+	for name := range texts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", e.provider, e.model)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, texts[name])
+	}
+	return xdg.CacheFile(filepath.Join("just-do-it", "embeddings", hex.EncodeToString(h.Sum(nil))[:32]+".json"))
+}
+
+// recipeEmbeddings returns a vector per recipe, from the cache if the
+// justfile hasn't changed since they were computed.
+func recipeEmbeddings(ctx context.Context, e *embedder, recipes map[string]Recipe) (map[string][]float32, error) {
+	texts := recipeTexts(recipes)
+	path, err := embeddingCachePath(e, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	var vectors map[string][]float32
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &vectors) == nil {
+		return vectors, nil
+	}
+
+	var names, inputs []string
+	for name, text := range texts {
+		names = append(names, name)
+		inputs = append(inputs, text)
+	}
+	embedded, err := e.embed(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("embedding recipes with %s: %w", e.model, err)
+	}
+	if len(embedded) != len(names) {
+		return nil, fmt.Errorf("embedding recipes: got %d vectors for %d recipes", len(embedded), len(names))
+	}
+	vectors = make(map[string][]float32, len(names))
+	for i, name := range names {
+		vectors[name] = embedded[i]
+	}
+
+	// The cache is only an optimization, failing to write it is fine
+	if data, err := json.Marshal(vectors); err == nil {
+		_ = os.WriteFile(path, data, 0600)
+	}
+	return vectors, nil
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// scoredRecipe is a recipe with its similarity to a query.
+type scoredRecipe struct {
+	name  string
+	score float64
+}
+
+// rankRecipes orders the recipes by similarity to the query, best first.
+func rankRecipes(ctx context.Context, e *embedder, recipes map[string]Recipe, query string) ([]scoredRecipe, error) {
+	vectors, err := recipeEmbeddings(ctx, e, recipes)
+	if err != nil {
+		return nil, err
+	}
+	q, err := e.embed(ctx, []string{query})
+	if err != nil || len(q) == 0 {
+		return nil, fmt.Errorf("embedding the query with %s: %v", e.model, err)
+	}
+
+	var ranked []scoredRecipe
+	for name, v := range vectors {
+		ranked = append(ranked, scoredRecipe{name, cosineSimilarity(q[0], v)})
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	return ranked, nil
+}
+
+// Msg with the recipes ranked by a semantic search, best first
+type semanticResultsMsg struct {
+	query string
+	names []string
+	err   error
+}
+
+func semanticSearch(recipes map[string]Recipe, query string) tea.Cmd {
+	return func() tea.Msg {
+		cfg, _ := LoadConfig()
+		if cfg == nil {
+			cfg = &Config{}
+		}
+		e, err := newEmbedder(cfg)
+		if err != nil {
+			return semanticResultsMsg{query: query, err: err}
+		}
+
+		ranked, err := rankRecipes(context.Background(), e, recipes, query)
+		if err != nil {
+			return semanticResultsMsg{query: query, err: err}
+		}
+
+		msg := semanticResultsMsg{query: query}
+		for _, r := range ranked[:min(semanticResults, len(ranked))] {
+			msg.names = append(msg.names, r.name)
+		}
+		return msg
+	}
+}
+
+// startSemantic opens the semantic search prompt.
+func (m *model) startSemantic() tea.Cmd {
+	t := textinput.New()
+	t.Prompt = "Describe what you want to do: "
+	t.Width = 60
+	t.SetValue(m.semanticQuery)
+	t.Focus()
+
+	m.inputs = []textinput.Model{t}
+	m.inputSources = nil
+	m.focusIndex = 0
+	m.state = viewSemantic
+	return textinput.Blink
+}
+
+func (m model) updateSemantic(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewList
+		m.inputs = nil
+		return m, nil
+	case key.Matches(msg, m.keys.Select):
+		query := strings.TrimSpace(m.inputs[0].Value())
+		if query == "" {
+			return m, nil
+		}
+		m.state = viewList
+		m.inputs = nil
+		return m, tea.Batch(m.list.NewStatusMessage("Searching recipes..."), semanticSearch(m.recipes, query))
+	}
+
+	var cmd tea.Cmd
+	m.inputs[0], cmd = m.inputs[0].Update(msg)
+	return m, cmd
+}
+
+// showSemanticResults lists the ranked recipes in place of the usual items.
+func (m *model) showSemanticResults(msg semanticResultsMsg) tea.Cmd {
+	if msg.err != nil {
+		m.err = msg.err
+		return nil
+	}
+	if len(msg.names) == 0 {
+		return nil
+	}
+	m.semanticQuery = msg.query
+	m.list.Title = m.listTitle()
+	m.list.ResetFilter()
+
+	var items []list.Item
+	for _, name := range msg.names {
+		desc := ""
+		if doc := m.recipes[name].Doc; doc != nil {
+			desc = *doc
+		}
+		items = append(items, recipeItem{name: name, desc: desc, marked: m.marked, durations: m.durations, search: m.search})
+	}
+	cmd := m.list.SetItems(items)
+	m.list.Select(0)
+	return tea.Batch(cmd, m.updateViewportContent(msg.names[0]))
+}
+
+// clearSemantic goes back to the full recipe list.
+func (m *model) clearSemantic() tea.Cmd {
+	m.semanticQuery = ""
+	m.list.Title = m.listTitle()
+	return m.list.SetItems(m.buildItems())
+}
+
+func (m model) semanticView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Semantic Search"))
+	b.WriteString("\n\n")
+	b.WriteString("Recipes are ranked by how well their name, doc and body match the description.\n\n")
+	b.WriteString(m.inputs[0].View())
+	return lipgloss.Place(m.terminalWidth, m.terminalHeight-1, lipgloss.Center, lipgloss.Center, b.String())
+}
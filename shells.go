@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// targetShell is a shell AI commands can be generated for and run with.
+type targetShell struct {
+	name  string
+	run   []string // Runs a command string, which is appended
+	check []string // Parses a command string without running it, nil if unsupported
+}
+
+var targetShells = []targetShell{
+	{name: "bash", run: []string{"bash", "-c"}, check: []string{"bash", "-n", "-c"}},
+	{name: "zsh", run: []string{"zsh", "-c"}, check: []string{"zsh", "-n", "-c"}},
+	{name: "fish", run: []string{"fish", "-c"}, check: []string{"fish", "--no-execute", "-c"}},
+	{name: "pwsh", run: []string{"pwsh", "-NoProfile", "-Command"}},
+	{name: "nu", run: []string{"nu", "-c"}},
+	{name: "sh", run: []string{"sh", "-c"}, check: []string{"sh", "-n", "-c"}},
+}
+
+func findShell(name string) (targetShell, bool) {
+	for _, s := range targetShells {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return targetShell{}, false
+}
+
+// detectShell works out which shell AI commands are for: the config's
+// ai_shell, else the login shell from $SHELL. Unknown shells get bash.
+func detectShell(cfg *Config) string {
+	if cfg != nil && cfg.AIShell != "" {
+		if _, ok := findShell(cfg.AIShell); ok {
+			return cfg.AIShell
+		}
+	}
+	name := filepath.Base(os.Getenv("SHELL"))
+	if name == "." && runtime.GOOS == "windows" {
+		name = "pwsh"
+	}
+	switch name {
+	case "powershell", "powershell.exe", "pwsh.exe":
+		name = "pwsh"
+	}
+	if _, ok := findShell(name); ok {
+		return name
+	}
+	return "bash"
+}
+
+// nextShell cycles to the following target shell.
+func nextShell(name string) string {
+	for i, s := range targetShells {
+		if s.name == name {
+			return targetShells[(i+1)%len(targetShells)].name
+		}
+	}
+	return targetShells[0].name
+}
+
+// shellCommand is the command line that runs command with the shell.
+func shellCommand(shell, command string) []string {
+	s, ok := findShell(shell)
+	if !ok {
+		s = targetShells[0]
+	}
+	return append(append([]string(nil), s.run...), command)
+}
+
+// Msg with the result of checking an AI command's syntax
+type shellCheckMsg struct {
+	command string
+	shell   string
+	problem string
+}
+
+// checkShellSyntax parses the command with the shell without running it,
+// so commands written for another shell are flagged before they run.
+func checkShellSyntax(shell, command string) tea.Cmd {
+	return func() tea.Msg {
+		msg := shellCheckMsg{command: command, shell: shell}
+		s, ok := findShell(shell)
+		if !ok || s.check == nil {
+			return msg
+		}
+		if _, err := exec.LookPath(s.check[0]); err != nil {
+			return msg
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		args := append(append([]string(nil), s.check[1:]...), command)
+		out, err := exec.CommandContext(ctx, s.check[0], args...).CombinedOutput()
+		if err != nil {
+			msg.problem = strings.TrimSpace(string(out))
+			if msg.problem == "" {
+				msg.problem = err.Error()
+			}
+		}
+		return msg
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Every in-app run is recorded with how long it took and how it exited, per
+// project. ctrl+x S shows them by recipe: run counts, success rate, and the
+// average and last duration, to find the slow and the flaky ones.
+
+const maxRunRecords = 100 // Kept per recipe, the oldest are dropped
+
+// runRecord is one finished run. Runs that couldn't start count as failed,
+// with exit code -1.
+type runRecord struct {
+	At       time.Time     `json:"at"`
+	Duration time.Duration `json:"duration"`
+	ExitCode int           `json:"exit_code"`
+}
+
+// runStatsLog holds the latest runs by recipe, newest last.
+type runStatsLog map[string][]runRecord
+
+func runStatsPath() (string, error) {
+	h := sha256.Sum256([]byte(projectDir()))
+	return xdg.DataFile(filepath.Join("just-do-it", "stats", hex.EncodeToString(h[:])[:16]+".json"))
+}
+
+func loadRunStats() runStatsLog {
+	path, err := runStatsPath()
+	if err != nil {
+		return runStatsLog{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return runStatsLog{}
+	}
+	log := runStatsLog{}
+	if err := json.Unmarshal(data, &log); err != nil {
+		logDebug("Ignoring broken stats file %s: %v", path, err)
+		return runStatsLog{}
+	}
+	return log
+}
+
+// recordRun adds the finished job to the project's stats.
+func recordRun(j *job) error {
+	r := runRecord{At: j.started, Duration: j.duration, ExitCode: j.exitCode}
+	if j.err != nil {
+		r.ExitCode = -1
+	}
+	log := loadRunStats()
+	runs := append(log[j.recipe], r)
+	log[j.recipe] = runs[max(len(runs)-maxRunRecords, 0):]
+
+	path, err := runStatsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// recipeStats sums up the recorded runs of a recipe.
+type recipeStats struct {
+	recipe    string
+	runs      int
+	succeeded int
+	average   time.Duration
+	last      runRecord
+}
+
+func (s recipeStats) successRate() float64 {
+	return float64(s.succeeded) / float64(s.runs)
+}
+
+func summarizeRuns(log runStatsLog) []recipeStats {
+	var stats []recipeStats
+	for recipe, runs := range log {
+		if len(runs) == 0 {
+			continue
+		}
+		s := recipeStats{recipe: recipe, runs: len(runs), last: runs[len(runs)-1]}
+		var total time.Duration
+		for _, r := range runs {
+			total += r.Duration
+			if r.ExitCode == 0 {
+				s.succeeded++
+			}
+		}
+		s.average = total / time.Duration(len(runs))
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// statsOrders are what the table can be sorted by, switched with tab.
+var statsOrders = []struct {
+	name string
+	less func(a, b recipeStats) bool
+}{
+	{"slowest", func(a, b recipeStats) bool { return a.average > b.average }},
+	{"flakiest", func(a, b recipeStats) bool { return a.successRate() < b.successRate() }},
+	{"most run", func(a, b recipeStats) bool { return a.runs > b.runs }},
+	{"last run", func(a, b recipeStats) bool { return a.last.At.After(b.last.At) }},
+}
+
+// statsView is the table of recipe stats.
+type statsView struct {
+	stats  []recipeStats
+	order  int
+	offset int
+}
+
+func (s *statsView) sort() {
+	less := statsOrders[s.order].less
+	sort.SliceStable(s.stats, func(i, j int) bool {
+		if less(s.stats[i], s.stats[j]) != less(s.stats[j], s.stats[i]) {
+			return less(s.stats[i], s.stats[j])
+		}
+		return s.stats[i].recipe < s.stats[j].recipe
+	})
+}
+
+func (m *model) startStats() tea.Cmd {
+	stats := summarizeRuns(loadRunStats())
+	if len(stats) == 0 {
+		return func() tea.Msg { return statusMsg("No runs recorded for this project yet") }
+	}
+	m.stats = &statsView{stats: stats}
+	m.stats.sort()
+	m.state = viewStats
+	return nil
+}
+
+func (m model) updateStats(msg tea.KeyMsg) (model, tea.Cmd) {
+	s := m.stats
+	switch {
+	case key.Matches(msg, m.keys.Cancel, m.keys.Quit):
+		m.state = viewList
+		m.stats = nil
+	case msg.Type == tea.KeyTab:
+		s.order = (s.order + 1) % len(statsOrders)
+		s.offset = 0
+		s.sort()
+	case key.Matches(msg, m.keys.Up):
+		if s.offset > 0 {
+			s.offset--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if s.offset < len(s.stats)-1 {
+			s.offset++
+		}
+	}
+	return m, nil
+}
+
+func (m model) statsView() string {
+	s := m.stats
+	width := len("recipe")
+	for _, st := range s.stats {
+		width = max(width, lipgloss.Width(st.recipe))
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Run stats · " + statsOrders[s.order].name + " first"))
+	b.WriteString("\n\n")
+	b.WriteString(helpStyle.Render(fmt.Sprintf("%-*s  %6s  %8s  %9s  %9s  %s", width, "recipe", "runs", "success", "average", "last", "last run")))
+	b.WriteString("\n")
+	shown := max(m.terminalHeight-9, 1)
+	for _, st := range s.stats[s.offset:min(len(s.stats), s.offset+shown)] {
+		// Recipes that failed stand out
+		rate := fmt.Sprintf("%7.0f%%", st.successRate()*100)
+		if st.succeeded < st.runs {
+			rate = lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(rate)
+		}
+		last := "✓"
+		if st.last.ExitCode != 0 {
+			last = lipgloss.NewStyle().Foreground(activeTheme.Accent).Render("✗")
+		}
+		fmt.Fprintf(&b, "%-*s  %6d  %s  %9s  %9s  %s %s\n", width, st.recipe, st.runs, rate,
+			formatRunDuration(st.average), formatRunDuration(st.last.Duration), last, helpStyle.Render(st.last.At.Format("2006-01-02 15:04")))
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(b.String())
+}
+
+// formatRunDuration renders a duration to a tenth of a second, or minutes
+// and seconds for longer ones.
+func formatRunDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// theme holds the colors used across the UI. Every color carries truecolor,
+// ANSI-256 and 16-color variants; lipgloss picks the one matching the
+// terminal's color profile.
+type theme struct {
+	Name    string
+	TitleFg lipgloss.CompleteColor
+	TitleBg lipgloss.CompleteColor
+	Status  lipgloss.CompleteColor
+	Muted   lipgloss.CompleteColor
+	Accent  lipgloss.CompleteColor
+	Border  lipgloss.CompleteColor
+
+	// Syntax highlighting in the recipe preview
+	Keyword  lipgloss.CompleteColor
+	String   lipgloss.CompleteColor
+	Variable lipgloss.CompleteColor
+
+	// Added and removed lines in diffs
+	Added   lipgloss.CompleteColor
+	Removed lipgloss.CompleteColor
+}
+
+var (
+	darkTheme = theme{
+		Name:    "dark",
+		TitleFg: lipgloss.CompleteColor{TrueColor: "#FFFDF5", ANSI256: "230", ANSI: "15"},
+		TitleBg: lipgloss.CompleteColor{TrueColor: "#25A065", ANSI256: "35", ANSI: "2"},
+		Status:  lipgloss.CompleteColor{TrueColor: "#04B575", ANSI256: "36", ANSI: "2"},
+		Muted:   lipgloss.CompleteColor{TrueColor: "#626262", ANSI256: "241", ANSI: "8"},
+		Accent:  lipgloss.CompleteColor{TrueColor: "#FF5FAF", ANSI256: "205", ANSI: "13"},
+		Border:  lipgloss.CompleteColor{TrueColor: "#5F5FD7", ANSI256: "62", ANSI: "4"},
+
+		Keyword:  lipgloss.CompleteColor{TrueColor: "#87AFFF", ANSI256: "111", ANSI: "12"},
+		String:   lipgloss.CompleteColor{TrueColor: "#D7AF5F", ANSI256: "179", ANSI: "3"},
+		Variable: lipgloss.CompleteColor{TrueColor: "#5FD7AF", ANSI256: "79", ANSI: "6"},
+
+		Added:   lipgloss.CompleteColor{TrueColor: "#5FD75F", ANSI256: "77", ANSI: "2"},
+		Removed: lipgloss.CompleteColor{TrueColor: "#FF5F5F", ANSI256: "203", ANSI: "1"},
+	}
+
+	lightTheme = theme{
+		Name:    "light",
+		TitleFg: lipgloss.CompleteColor{TrueColor: "#FFFDF5", ANSI256: "230", ANSI: "15"},
+		TitleBg: lipgloss.CompleteColor{TrueColor: "#1E8050", ANSI256: "29", ANSI: "2"},
+		Status:  lipgloss.CompleteColor{TrueColor: "#038A55", ANSI256: "29", ANSI: "2"},
+		Muted:   lipgloss.CompleteColor{TrueColor: "#767676", ANSI256: "243", ANSI: "8"},
+		Accent:  lipgloss.CompleteColor{TrueColor: "#D7005F", ANSI256: "161", ANSI: "5"},
+		Border:  lipgloss.CompleteColor{TrueColor: "#5F5FAF", ANSI256: "61", ANSI: "4"},
+
+		Keyword:  lipgloss.CompleteColor{TrueColor: "#005FD7", ANSI256: "26", ANSI: "4"},
+		String:   lipgloss.CompleteColor{TrueColor: "#875F00", ANSI256: "94", ANSI: "3"},
+		Variable: lipgloss.CompleteColor{TrueColor: "#008787", ANSI256: "30", ANSI: "6"},
+
+		Added:   lipgloss.CompleteColor{TrueColor: "#008700", ANSI256: "28", ANSI: "2"},
+		Removed: lipgloss.CompleteColor{TrueColor: "#D70000", ANSI256: "160", ANSI: "1"},
+	}
+
+	draculaTheme = theme{
+		Name:    "dracula",
+		TitleFg: lipgloss.CompleteColor{TrueColor: "#282A36", ANSI256: "236", ANSI: "0"},
+		TitleBg: lipgloss.CompleteColor{TrueColor: "#BD93F9", ANSI256: "141", ANSI: "13"},
+		Status:  lipgloss.CompleteColor{TrueColor: "#50FA7B", ANSI256: "84", ANSI: "10"},
+		Muted:   lipgloss.CompleteColor{TrueColor: "#6272A4", ANSI256: "61", ANSI: "8"},
+		Accent:  lipgloss.CompleteColor{TrueColor: "#FF79C6", ANSI256: "212", ANSI: "13"},
+		Border:  lipgloss.CompleteColor{TrueColor: "#44475A", ANSI256: "239", ANSI: "8"},
+
+		Keyword:  lipgloss.CompleteColor{TrueColor: "#8BE9FD", ANSI256: "117", ANSI: "14"},
+		String:   lipgloss.CompleteColor{TrueColor: "#F1FA8C", ANSI256: "228", ANSI: "11"},
+		Variable: lipgloss.CompleteColor{TrueColor: "#FFB86C", ANSI256: "215", ANSI: "3"},
+
+		Added:   lipgloss.CompleteColor{TrueColor: "#50FA7B", ANSI256: "84", ANSI: "10"},
+		Removed: lipgloss.CompleteColor{TrueColor: "#FF5555", ANSI256: "203", ANSI: "9"},
+	}
+
+	solarizedDarkTheme = theme{
+		Name:    "solarized-dark",
+		TitleFg: lipgloss.CompleteColor{TrueColor: "#FDF6E3", ANSI256: "230", ANSI: "15"},
+		TitleBg: lipgloss.CompleteColor{TrueColor: "#268BD2", ANSI256: "32", ANSI: "4"},
+		Status:  lipgloss.CompleteColor{TrueColor: "#859900", ANSI256: "100", ANSI: "2"},
+		Muted:   lipgloss.CompleteColor{TrueColor: "#586E75", ANSI256: "242", ANSI: "8"},
+		Accent:  lipgloss.CompleteColor{TrueColor: "#D33682", ANSI256: "162", ANSI: "5"},
+		Border:  lipgloss.CompleteColor{TrueColor: "#073642", ANSI256: "23", ANSI: "0"},
+
+		Keyword:  lipgloss.CompleteColor{TrueColor: "#6C71C4", ANSI256: "61", ANSI: "13"},
+		String:   lipgloss.CompleteColor{TrueColor: "#2AA198", ANSI256: "36", ANSI: "6"},
+		Variable: lipgloss.CompleteColor{TrueColor: "#B58900", ANSI256: "136", ANSI: "3"},
+
+		Added:   lipgloss.CompleteColor{TrueColor: "#859900", ANSI256: "100", ANSI: "2"},
+		Removed: lipgloss.CompleteColor{TrueColor: "#DC322F", ANSI256: "160", ANSI: "1"},
+	}
+
+	solarizedLightTheme = theme{
+		Name:    "solarized-light",
+		TitleFg: lipgloss.CompleteColor{TrueColor: "#FDF6E3", ANSI256: "230", ANSI: "15"},
+		TitleBg: lipgloss.CompleteColor{TrueColor: "#268BD2", ANSI256: "32", ANSI: "4"},
+		Status:  lipgloss.CompleteColor{TrueColor: "#859900", ANSI256: "100", ANSI: "2"},
+		Muted:   lipgloss.CompleteColor{TrueColor: "#93A1A1", ANSI256: "247", ANSI: "8"},
+		Accent:  lipgloss.CompleteColor{TrueColor: "#D33682", ANSI256: "162", ANSI: "5"},
+		Border:  lipgloss.CompleteColor{TrueColor: "#EEE8D5", ANSI256: "254", ANSI: "7"},
+
+		Keyword:  lipgloss.CompleteColor{TrueColor: "#6C71C4", ANSI256: "61", ANSI: "5"},
+		String:   lipgloss.CompleteColor{TrueColor: "#2AA198", ANSI256: "36", ANSI: "6"},
+		Variable: lipgloss.CompleteColor{TrueColor: "#B58900", ANSI256: "136", ANSI: "3"},
+
+		Added:   lipgloss.CompleteColor{TrueColor: "#859900", ANSI256: "100", ANSI: "2"},
+		Removed: lipgloss.CompleteColor{TrueColor: "#DC322F", ANSI256: "160", ANSI: "1"},
+	}
+
+	activeTheme = darkTheme
+)
+
+// themePresets are the themes the config and the picker can name. "default"
+// is the dark or light theme, following the terminal background.
+var themePresets = []string{"default", "dark", "light", "dracula", "solarized-dark", "solarized-light"}
+
+func themePreset(name string, bg termBackground) (theme, bool) {
+	switch name {
+	case "", "default":
+		return themeFor(bg), true
+	case "dark":
+		return darkTheme, true
+	case "light":
+		return lightTheme, true
+	case "dracula":
+		return draculaTheme, true
+	case "solarized-dark":
+		return solarizedDarkTheme, true
+	case "solarized-light":
+		return solarizedLightTheme, true
+	}
+	return theme{}, false
+}
+
+// loadTheme builds the theme from the config: the preset it names, with any
+// colors it overrides.
+func loadTheme(cfg *Config, bg termBackground) (theme, error) {
+	name := ""
+	if cfg != nil {
+		name = cfg.Theme
+	}
+	t, ok := themePreset(name, bg)
+	if !ok {
+		return themeFor(bg), fmt.Errorf("unknown theme %q, pick one of %s", name, strings.Join(themePresets, ", "))
+	}
+	if cfg == nil || len(cfg.ThemeColors) == 0 {
+		return t, nil
+	}
+
+	fields := map[string]*lipgloss.CompleteColor{
+		"title_fg": &t.TitleFg,
+		"title_bg": &t.TitleBg,
+		"status":   &t.Status,
+		"muted":    &t.Muted,
+		"accent":   &t.Accent,
+		"border":   &t.Border,
+		"keyword":  &t.Keyword,
+		"string":   &t.String,
+		"variable": &t.Variable,
+		"added":    &t.Added,
+		"removed":  &t.Removed,
+	}
+	var errs []error
+	for name, value := range cfg.ThemeColors {
+		field, ok := fields[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("theme_colors: unknown color %q", name))
+			continue
+		}
+		c, err := hexColor(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("theme_colors: %s: %v", name, err))
+			continue
+		}
+		*field = c
+	}
+	t.Name += " (customized)"
+	return t, errors.Join(errs...)
+}
+
+// hexColor turns "#RRGGBB" into a color with the closest 256 and 16 color
+// variants.
+func hexColor(hex string) (lipgloss.CompleteColor, error) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return lipgloss.CompleteColor{}, fmt.Errorf("%q is not a #RRGGBB color", hex)
+	}
+	if _, err := strconv.ParseUint(hex[1:], 16, 32); err != nil {
+		return lipgloss.CompleteColor{}, fmt.Errorf("%q is not a #RRGGBB color", hex)
+	}
+	rgb := termenv.RGBColor(hex)
+	ansi256, _ := termenv.ANSI256.Convert(rgb).(termenv.ANSI256Color)
+	ansi, _ := termenv.ANSI.Convert(rgb).(termenv.ANSIColor)
+	return lipgloss.CompleteColor{
+		TrueColor: hex,
+		ANSI256:   strconv.Itoa(int(ansi256)),
+		ANSI:      strconv.Itoa(int(ansi)),
+	}, nil
+}
+
+// detectColorProfile works out how many colors the terminal can show. The
+// environment ($NO_COLOR, $COLORTERM, $TERM) decides unless the config pins
+// a profile, which helps on SSH/CI terminals that misreport themselves.
+func detectColorProfile(cfg *Config) termenv.Profile {
+	if cfg != nil {
+		switch cfg.ColorProfile {
+		case "truecolor":
+			return termenv.TrueColor
+		case "256":
+			return termenv.ANSI256
+		case "16":
+			return termenv.ANSI
+		case "none":
+			return termenv.Ascii
+		}
+	}
+	return termenv.NewOutput(os.Stdout).EnvColorProfile()
+}
+
+// termBackground describes what we found out about the terminal background.
+type termBackground struct {
+	Color  string // As reported by the terminal, empty if unknown
+	Dark   bool
+	Source string // "OSC 11", "COLORFGBG", "config" or "default"
+}
+
+// detectBackground queries the terminal for its background color (OSC 11),
+// falling back to $COLORFGBG. The config can force "dark" or "light".
+// This has to run before the TUI starts reading stdin.
+func detectBackground(cfg *Config) termBackground {
+	if cfg != nil {
+		switch cfg.Background {
+		case "dark":
+			return termBackground{Dark: true, Source: "config"}
+		case "light":
+			return termBackground{Dark: false, Source: "config"}
+		}
+	}
+
+	out := termenv.NewOutput(os.Stdout)
+	switch c := out.BackgroundColor().(type) {
+	case termenv.RGBColor:
+		return termBackground{Color: string(c), Dark: out.HasDarkBackground(), Source: "OSC 11"}
+	case termenv.ANSIColor:
+		if os.Getenv("COLORFGBG") != "" {
+			return termBackground{Color: c.String(), Dark: out.HasDarkBackground(), Source: "COLORFGBG"}
+		}
+	}
+	return termBackground{Dark: true, Source: "default"}
+}
+
+// applyTheme rebuilds the shared styles from the given theme.
+func applyTheme(t theme) {
+	activeTheme = t
+
+	titleStyle = lipgloss.NewStyle().
+		Foreground(t.TitleFg).
+		Background(t.TitleBg).
+		Padding(0, 1)
+
+	statusMessageStyle = lipgloss.NewStyle().
+		Foreground(t.Status).
+		Render
+
+	helpStyle = lipgloss.NewStyle().
+		Foreground(t.Muted)
+}
+
+// styleList applies the theme to a list, which otherwise uses the bubbles
+// default colors.
+func styleList(l *list.Model) {
+	l.Styles.Title = titleStyle
+	l.Styles.FilterPrompt = lipgloss.NewStyle().Foreground(activeTheme.Status)
+	l.Styles.FilterCursor = lipgloss.NewStyle().Foreground(activeTheme.Accent)
+	l.Styles.StatusBar = helpStyle.Padding(0, 0, 1, 2)
+	l.Styles.NoItems = helpStyle
+
+	d := list.NewDefaultDelegate()
+	d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(activeTheme.Accent).BorderForeground(activeTheme.Accent)
+	d.Styles.SelectedDesc = d.Styles.SelectedDesc.Foreground(activeTheme.Accent).BorderForeground(activeTheme.Accent)
+	d.Styles.DimmedDesc = d.Styles.DimmedDesc.Foreground(activeTheme.Muted)
+	d.Styles.NormalDesc = d.Styles.NormalDesc.Foreground(activeTheme.Muted)
+	d.Styles.FilterMatch = d.Styles.FilterMatch.Foreground(activeTheme.Status)
+	l.SetDelegate(d)
+}
+
+func themeFor(bg termBackground) theme {
+	if bg.Dark {
+		return darkTheme
+	}
+	return lightTheme
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// themePicker lists the theme presets, previewing each as the cursor moves
+// over it. Only enter saves the choice.
+type themePicker struct {
+	cursor   int
+	previous theme // Restored on cancel
+	returnTo state
+}
+
+func (m *model) startThemePicker() {
+	cfg, _ := LoadConfig()
+	cursor := 0
+	for i, name := range themePresets {
+		if cfg != nil && name == cfg.Theme {
+			cursor = i
+		}
+	}
+	m.themes = &themePicker{cursor: cursor, previous: activeTheme, returnTo: m.state}
+	m.state = viewThemePicker
+}
+
+// setTheme switches the whole UI to the theme, including the list styles
+// and the highlighted preview.
+func (m *model) setTheme(t theme) tea.Cmd {
+	applyTheme(t)
+	styleList(&m.list)
+	m.spinner.Style = lipgloss.NewStyle().Foreground(activeTheme.Accent)
+	if i, ok := m.list.SelectedItem().(recipeItem); ok {
+		return m.updateViewportContent(i.name)
+	}
+	return nil
+}
+
+// previewTheme applies the preset under the cursor, with the configured
+// color overrides.
+func (m *model) previewTheme() tea.Cmd {
+	cfg, _ := LoadConfig()
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	preview := *cfg
+	preview.Theme = themePresets[m.themes.cursor]
+	t, _ := loadTheme(&preview, detectBackground(cfg))
+	return m.setTheme(t)
+}
+
+func (m model) updateThemePicker(msg tea.KeyMsg) (model, tea.Cmd) {
+	p := m.themes
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		if p.cursor > 0 {
+			p.cursor--
+			return m, m.previewTheme()
+		}
+	case key.Matches(msg, m.keys.Down):
+		if p.cursor < len(themePresets)-1 {
+			p.cursor++
+			return m, m.previewTheme()
+		}
+	case key.Matches(msg, m.keys.Select):
+		m.state = p.returnTo
+		m.themes = nil
+		cfg, _ := LoadConfig()
+		if cfg == nil {
+			cfg = &Config{}
+		}
+		cfg.Theme = themePresets[p.cursor]
+		if cfg.Theme == "default" {
+			cfg.Theme = ""
+		}
+		if err := SaveConfig(cfg); err != nil {
+			m.err = fmt.Errorf("failed to save config: %v", err)
+			return m, nil
+		}
+		return m, func() tea.Msg { return statusMsg("Theme: " + themePresets[p.cursor]) }
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = p.returnTo
+		m.themes = nil
+		return m, m.setTheme(p.previous)
+	}
+	return m, nil
+}
+
+func (m model) themePickerView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Theme"))
+	b.WriteString("\n\n")
+	for i, name := range themePresets {
+		cursor := " "
+		if i == m.themes.cursor {
+			name = lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(name)
+			cursor = ">"
+		}
+		fmt.Fprintf(&b, "%s %s\n", cursor, name)
+	}
+
+	// A sample of the colors that aren't on screen otherwise
+	swatch := func(c lipgloss.TerminalColor, s string) string {
+		return lipgloss.NewStyle().Foreground(c).Render(s)
+	}
+	b.WriteString("\n")
+	b.WriteString(statusMessageStyle("status") + "  ")
+	b.WriteString(swatch(activeTheme.Keyword, "keyword") + "  ")
+	b.WriteString(swatch(activeTheme.String, `"string"`) + "  ")
+	b.WriteString(swatch(activeTheme.Variable, "$variable") + "  ")
+	b.WriteString(swatch(activeTheme.Added, "+added") + "  ")
+	b.WriteString(swatch(activeTheme.Removed, "-removed") + "  ")
+	b.WriteString(helpStyle.Render("muted"))
+	return lipgloss.NewStyle().Margin(1, 2).Render(b.String())
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The terminal title can't be read back, so the previous one is saved on the
+// terminal's title stack (xterm CSI 22/23 t) and restored from it on exit.
+// Terminals without a title stack ignore these.
+func pushTerminalTitle() { fmt.Fprint(os.Stdout, "\x1b[22;0t") }
+func popTerminalTitle()  { fmt.Fprint(os.Stdout, "\x1b[23;0t") }
+
+// projectName names the project after the directory holding the justfile.
+func projectName() string {
+	return filepath.Base(projectDir())
+}
+
+// windowTitle is what the terminal title should say right now: the project
+// while browsing, the command while something runs in the app.
+func (m model) windowTitle() string {
+	switch {
+	case m.state == viewOutput && m.running() && m.matrix != nil:
+		return fmt.Sprintf("running: %s (matrix)", m.matrix.recipe)
+	case m.state == viewOutput && m.running():
+		return "running: " + strings.Join(m.job.command, " ")
+	}
+	return m.project
+}
+
+// syncTitle updates the terminal title when it no longer matches the state.
+func (m *model) syncTitle() tea.Cmd {
+	title := m.windowTitle()
+	if title == m.title {
+		return nil
+	}
+	m.title = title
+	return tea.SetWindowTitle(title)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tmuxPane is a pane the composed command can be typed into.
+type tmuxPane struct {
+	id      string // e.g. "%3"
+	target  string // session:window.pane
+	command string // What's running in it
+}
+
+// tmuxPicker is the pane chooser shown before sending a command.
+type tmuxPicker struct {
+	command  string
+	panes    []tmuxPane
+	cursor   int
+	returnTo state
+}
+
+// listTmuxPanes lists every pane on the tmux server except the one we're in.
+func listTmuxPanes() ([]tmuxPane, error) {
+	out, err := exec.Command("tmux", "list-panes", "-a", "-F",
+		"#{pane_id}\t#{session_name}:#{window_index}.#{pane_index}\t#{pane_current_command}").Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list tmux panes, is tmux running? (%v)", err)
+	}
+	var panes []tmuxPane
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 3 || fields[0] == os.Getenv("TMUX_PANE") {
+			continue
+		}
+		panes = append(panes, tmuxPane{id: fields[0], target: fields[1], command: fields[2]})
+	}
+	if len(panes) == 0 {
+		return nil, fmt.Errorf("no other tmux panes to send to")
+	}
+	return panes, nil
+}
+
+// sendToTmux types the command into the pane without pressing enter, so it
+// can be reviewed and run there.
+func sendToTmux(pane tmuxPane, command string) error {
+	out, err := exec.Command("tmux", "send-keys", "-t", pane.id, "-l", command).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("tmux send-keys failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// shellJoin quotes the arguments for a POSIX shell where needed.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_./:=@%+,", c)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// composedCommand is the command line enter would run from the current
+// view, as it would be typed into a shell.
+func (m model) composedCommand() (string, bool) {
+	switch m.state {
+	case viewInput:
+		if m.editsCommand() {
+			// Send the command itself rather than wrapped in sh -c
+			return m.inputs[0].Value(), true
+		}
+		return shellJoin(m.formCommand()), true
+	case viewList:
+		if i, ok := m.list.SelectedItem().(recipeItem); ok && len(m.recipes[i.name].Parameters) == 0 {
+			return shellJoin(m.recipeCommand(i.name)), true
+		}
+	}
+	return "", false
+}
+
+// startTmuxPicker asks which pane to send the composed command to.
+func (m *model) startTmuxPicker() {
+	command, ok := m.composedCommand()
+	if !ok {
+		return
+	}
+	panes, err := listTmuxPanes()
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.tmux = &tmuxPicker{command: command, panes: panes, returnTo: m.state}
+	m.state = viewTmuxPanes
+}
+
+func (m model) updateTmuxPicker(msg tea.KeyMsg) (model, tea.Cmd) {
+	p := m.tmux
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if p.cursor < len(p.panes)-1 {
+			p.cursor++
+		}
+	case key.Matches(msg, m.keys.Select):
+		pane := p.panes[p.cursor]
+		m.state = p.returnTo
+		m.tmux = nil
+		if err := sendToTmux(pane, p.command); err != nil {
+			m.err = err
+			return m, nil
+		}
+		return m, func() tea.Msg { return statusMsg("Sent to tmux pane " + pane.target) }
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = p.returnTo
+		m.tmux = nil
+	}
+	return m, nil
+}
+
+func (m model) tmuxPickerView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Send to tmux pane"))
+	b.WriteString("\n\n")
+	b.WriteString(helpStyle.Render(m.tmux.command))
+	b.WriteString("\n\n")
+	for i, pane := range m.tmux.panes {
+		target := pane.target
+		cursor := " "
+		if i == m.tmux.cursor {
+			target = lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(target)
+			cursor = ">"
+		}
+		fmt.Fprintf(&b, "%s %s  %s\n", cursor, target, helpStyle.Render(pane.command))
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(b.String())
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOML config files are read and written with BurntSushi/toml. Values
+// decode to the same shapes encoding/json uses, so a parsed file can go
+// through json into a Config, and a TOML file merges with a JSON one.
+
+// parseTOML decodes a TOML document into nested maps.
+func parseTOML(src string) (map[string]any, error) {
+	var doc map[string]any
+	if _, err := toml.Decode(src, &doc); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	return values, json.Unmarshal(data, &values)
+}
+
+// encodeTOML writes nested maps, as decoded from JSON, as a TOML document.
+func encodeTOML(doc map[string]any) (string, error) {
+	var b strings.Builder
+	enc := toml.NewEncoder(&b)
+	enc.Indent = ""
+	if err := enc.Encode(tomlIntegers(doc)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// tomlIntegers turns whole numbers, which JSON decodes as floats, back into
+// integers, so they're written as 3 rather than 3.0.
+func tomlIntegers(v any) any {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return int64(v)
+		}
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = tomlIntegers(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = tomlIntegers(item)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ctrl+x U asks GitHub for the latest release and shows its notes. Nothing
+// is checked without asking and nothing but the request for the release is
+// sent.
+
+const (
+	releaseRepo    = "kristianhasselknippe/just-do-it"
+	releaseTimeout = 10 * time.Second
+)
+
+// release is the part of GitHub's release answer that's shown.
+type release struct {
+	Tag       string    `json:"tag_name"`
+	Name      string    `json:"name"`
+	Body      string    `json:"body"`
+	URL       string    `json:"html_url"`
+	Published time.Time `json:"published_at"`
+}
+
+type releaseMsg struct {
+	release *release
+	err     error
+}
+
+func fetchLatestRelease() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.github.com/repos/"+releaseRepo+"/releases/latest", nil)
+	if err != nil {
+		return releaseMsg{err: err}
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return releaseMsg{err: fmt.Errorf("checking for updates: %w", err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return releaseMsg{err: fmt.Errorf("checking for updates: %s has no releases yet", releaseRepo)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return releaseMsg{err: fmt.Errorf("checking for updates: GitHub answered %s", resp.Status)}
+	}
+	var r release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return releaseMsg{err: fmt.Errorf("checking for updates: %w", err)}
+	}
+	return releaseMsg{release: &r}
+}
+
+// currentVersion is the version this binary was installed as, empty for a
+// build from source.
+func currentVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return ""
+}
+
+// newerVersion reports whether version a is newer than b, comparing the
+// numbers of vX.Y.Z. Pre-release and build suffixes are ignored.
+func newerVersion(a, b string) bool {
+	parse := func(v string) []int {
+		v, _, _ = strings.Cut(strings.TrimPrefix(v, "v"), "-")
+		v, _, _ = strings.Cut(v, "+")
+		var nums []int
+		for _, part := range strings.Split(v, ".") {
+			n, _ := strconv.Atoi(part)
+			nums = append(nums, n)
+		}
+		return nums
+	}
+	na, nb := parse(a), parse(b)
+	for i := range max(len(na), len(nb)) {
+		x, y := 0, 0
+		if i < len(na) {
+			x = na[i]
+		}
+		if i < len(nb) {
+			y = nb[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+// releaseNotes shows the latest release's notes.
+type releaseNotes struct {
+	release release
+	viewer  viewport.Model
+}
+
+func (m *model) startUpdateCheck() tea.Cmd {
+	return tea.Batch(fetchLatestRelease, func() tea.Msg { return statusMsg("Checking for updates...") })
+}
+
+func (m *model) handleRelease(msg releaseMsg) tea.Cmd {
+	if msg.err != nil {
+		m.fail(msg.err, func(m *model) tea.Cmd { return m.startUpdateCheck() })
+		return nil
+	}
+	width := m.terminalWidth - 4
+	viewer := viewport.New(width, m.terminalHeight-9)
+	if strings.TrimSpace(msg.release.Body) == "" {
+		viewer.SetContent(helpStyle.Render("This release has no notes."))
+	} else {
+		viewer.SetContent(renderMarkdown(msg.release.Body, width-2))
+	}
+	m.releaseNotes = &releaseNotes{release: *msg.release, viewer: viewer}
+	m.state = viewReleaseNotes
+	return nil
+}
+
+func (m model) updateReleaseNotes(msg tea.KeyMsg) (model, tea.Cmd) {
+	if key.Matches(msg, m.keys.Cancel, m.keys.Quit) {
+		m.state = viewList
+		m.releaseNotes = nil
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.releaseNotes.viewer, cmd = m.releaseNotes.viewer.Update(msg)
+	return m, cmd
+}
+
+func (m model) releaseNotesView() string {
+	n := m.releaseNotes
+	r := n.release
+	name := r.Tag
+	if r.Name != "" && r.Name != r.Tag {
+		name += " · " + r.Name
+	}
+	current := currentVersion()
+	var status string
+	switch {
+	case current == "":
+		status = "This is a build from source, the latest release is " + r.Tag
+	case newerVersion(r.Tag, current):
+		status = fmt.Sprintf("%s is out, you have %s. Update with: go install github.com/%s@latest", r.Tag, current, releaseRepo)
+	default:
+		status = fmt.Sprintf("You have the latest release, %s", current)
+	}
+	body := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(activeTheme.Border).
+		Render(n.viewer.View())
+	return lipgloss.NewStyle().Margin(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("Release "+name),
+		"",
+		helpStyle.Render(status),
+		helpStyle.Render(r.Published.Format("2006-01-02")+" · "+r.URL),
+		body))
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// usageInterval is how often running jobs are sampled.
+const usageInterval = time.Second
+
+// clockTicks is USER_HZ, the unit of the CPU times in /proc. It is 100 on
+// every Linux platform Go supports.
+const clockTicks = 100
+
+// procUsage is the resource usage of a process and all its descendants.
+type procUsage struct {
+	cpuTicks uint64 // User and system time, in clock ticks
+	rss      int64  // Resident memory, in bytes
+}
+
+// jobUsage is what the output pane shows for a running job.
+type jobUsage struct {
+	cpu     float64 // Percent of one core since the previous sample
+	rss     int64
+	ticks   uint64
+	sampled time.Time
+}
+
+// Msg with fresh usage samples, keyed by job id
+type usageMsg struct {
+	seq     int
+	at      time.Time
+	samples map[int]procUsage
+}
+
+// sampleUsage schedules the next sample of the running jobs. Samples come
+// from /proc, so there is nothing to show on other platforms.
+func (m model) sampleUsage() tea.Cmd {
+	pids := map[int]int{}
+	for _, j := range m.runningJobs() {
+		if j.pid > 0 {
+			pids[j.id] = j.pid
+		}
+	}
+	seq := m.usageSeq
+	return tea.Tick(usageInterval, func(t time.Time) tea.Msg {
+		samples := map[int]procUsage{}
+		if len(pids) > 0 {
+			tree := readProcTable()
+			for id, pid := range pids {
+				if u, ok := tree.usage(pid); ok {
+					samples[id] = u
+				}
+			}
+		}
+		return usageMsg{seq: seq, at: t, samples: samples}
+	})
+}
+
+// runningJobs returns the jobs that haven't finished yet.
+func (m model) runningJobs() []*job {
+	jobs := []*job{m.job}
+	if m.matrix != nil {
+		jobs = m.matrix.jobs
+	}
+	var out []*job
+	for _, j := range jobs {
+		if j != nil && !j.finished {
+			out = append(out, j)
+		}
+	}
+	return out
+}
+
+func (m model) handleUsageMsg(msg usageMsg) (model, tea.Cmd) {
+	if msg.seq != m.usageSeq {
+		return m, nil // From a previous run
+	}
+	for _, j := range m.runningJobs() {
+		if u, ok := msg.samples[j.id]; ok {
+			j.recordUsage(u, msg.at)
+		}
+	}
+	if !m.running() {
+		return m, nil
+	}
+	return m, m.sampleUsage()
+}
+
+// recordUsage turns a sample into a CPU percentage over the time since the
+// previous one (or since the job started).
+func (j *job) recordUsage(u procUsage, at time.Time) {
+	since := j.usage.sampled
+	if since.IsZero() {
+		since = j.started
+	}
+	if elapsed := at.Sub(since).Seconds(); elapsed > 0 && u.cpuTicks >= j.usage.ticks {
+		j.usage.cpu = float64(u.cpuTicks-j.usage.ticks) / clockTicks / elapsed * 100
+	}
+	j.usage.ticks = u.cpuTicks
+	j.usage.rss = u.rss
+	j.usage.sampled = at
+}
+
+// usageSummary renders the last sample, e.g. "CPU 153% · RSS 212 MB".
+func (j *job) usageSummary() string {
+	if j.usage.sampled.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("CPU %.0f%% · RSS %s", j.usage.cpu, formatBytes(j.usage.rss))
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%d MB", n>>20)
+	default:
+		return fmt.Sprintf("%d KB", n>>10)
+	}
+}
+
+// procTable is a snapshot of every process in /proc.
+type procTable struct {
+	children map[int][]int
+	procs    map[int]procUsage
+}
+
+func readProcTable() procTable {
+	t := procTable{children: map[int][]int{}, procs: map[int]procUsage{}}
+	dirs, _ := filepath.Glob("/proc/[0-9]*")
+	page := int64(os.Getpagesize())
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue // Exited while we were looking
+		}
+		// The command name is in parentheses and may contain spaces
+		stat := string(data)
+		fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+		if len(fields) < 22 {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		utime, _ := strconv.ParseUint(fields[11], 10, 64)
+		stime, _ := strconv.ParseUint(fields[12], 10, 64)
+		// Children that already exited are counted in cutime and cstime
+		cutime, _ := strconv.ParseUint(fields[13], 10, 64)
+		cstime, _ := strconv.ParseUint(fields[14], 10, 64)
+		rss, _ := strconv.ParseInt(fields[21], 10, 64)
+
+		t.children[ppid] = append(t.children[ppid], pid)
+		t.procs[pid] = procUsage{cpuTicks: utime + stime + cutime + cstime, rss: rss * page}
+	}
+	return t
+}
+
+// usage sums the usage of pid and everything below it.
+func (t procTable) usage(pid int) (procUsage, bool) {
+	root, ok := t.procs[pid]
+	if !ok {
+		return procUsage{}, false
+	}
+	total := root
+	for _, child := range t.children[pid] {
+		if u, ok := t.usage(child); ok {
+			total.cpuTicks += u.cpuTicks
+			total.rss += u.rss
+		}
+	}
+	return total, true
+}
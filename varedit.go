@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The variable editor lists the justfile's variables with their evaluated
+// values. Values typed over them are passed to every recipe run for the
+// rest of the session as NAME=value, just's own override syntax.
+
+type varEditor struct {
+	names  []string
+	inputs []textinput.Model
+	offset int // First row shown
+	focus  int
+}
+
+// Msg with the evaluated value of a variable, or why it failed
+type varValueMsg struct {
+	name, value string
+}
+
+// startVarEditor lists the variables, starting their evaluation.
+func (m *model) startVarEditor() tea.Cmd {
+	names, err := justVariables()
+	if err != nil {
+		m.err = fmt.Errorf("listing the justfile's variables: %v", err)
+		return nil
+	}
+	if len(names) == 0 {
+		return func() tea.Msg { return statusMsg("The justfile has no variables") }
+	}
+
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+	e := &varEditor{names: names}
+	cmds := []tea.Cmd{textinput.Blink}
+	for i, name := range names {
+		t := textinput.New()
+		t.Prompt = fmt.Sprintf("%-*s = ", width, name)
+		t.Placeholder = "evaluating..."
+		t.Width = min(m.terminalWidth-width-10, 60)
+		t.SetValue(m.varOverrides[name])
+		if i == 0 {
+			t.Focus()
+		}
+		e.inputs = append(e.inputs, t)
+		cmds = append(cmds, evaluateVar(name))
+	}
+	m.vars = e
+	m.state = viewVarEditor
+	return tea.Batch(cmds...)
+}
+
+func evaluateVar(name string) tea.Cmd {
+	return func() tea.Msg {
+		value, err := evaluateVariable(name)
+		if err != nil {
+			return varValueMsg{name: name, value: fmt.Sprintf("(%v)", err)}
+		}
+		return varValueMsg{name: name, value: fmt.Sprintf("%q", value)}
+	}
+}
+
+// handleVarValue shows an evaluated value as the placeholder of its row.
+func (m *model) handleVarValue(msg varValueMsg) {
+	if m.vars == nil {
+		return
+	}
+	for i, name := range m.vars.names {
+		if name == msg.name {
+			m.vars.inputs[i].Placeholder = strings.ReplaceAll(msg.value, "\n", " ")
+		}
+	}
+}
+
+// setArgs turns the session's variable overrides into arguments for just.
+func (m model) setArgs() []string {
+	var names []string
+	for name := range m.varOverrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var args []string
+	for _, name := range names {
+		args = append(args, name+"="+m.varOverrides[name])
+	}
+	return args
+}
+
+func (m model) updateVarEditor(msg tea.KeyMsg) (model, tea.Cmd) {
+	e := m.vars
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewList
+		m.vars = nil
+		return m, nil
+	case key.Matches(msg, m.keys.NextField, m.keys.PrevField):
+		e.inputs[e.focus].Blur()
+		if key.Matches(msg, m.keys.NextField) {
+			e.focus = (e.focus + 1) % len(e.inputs)
+		} else {
+			e.focus = (e.focus + len(e.inputs) - 1) % len(e.inputs)
+		}
+		e.inputs[e.focus].Focus()
+		rows := m.varRows()
+		if e.focus < e.offset {
+			e.offset = e.focus
+		} else if e.focus >= e.offset+rows {
+			e.offset = e.focus - rows + 1
+		}
+		return m, textinput.Blink
+	case key.Matches(msg, m.keys.Select):
+		m.varOverrides = map[string]string{}
+		for i, name := range e.names {
+			if value := e.inputs[i].Value(); value != "" {
+				m.varOverrides[name] = value
+			}
+		}
+		m.state = viewList
+		m.vars = nil
+		status := "Recipes run with the justfile's variables"
+		if n := len(m.varOverrides); n > 0 {
+			status = fmt.Sprintf("Recipes run with %d variable %s overridden", n, plural(n, "value", "values"))
+		}
+		return m, func() tea.Msg { return statusMsg(status) }
+	}
+	var cmd tea.Cmd
+	e.inputs[e.focus], cmd = e.inputs[e.focus].Update(msg)
+	return m, cmd
+}
+
+// varRows is how many variables fit on the screen.
+func (m model) varRows() int {
+	return max(m.terminalHeight-9, 1)
+}
+
+func (m model) varEditorView() string {
+	e := m.vars
+	end := min(e.offset+m.varRows(), len(e.inputs))
+	var rows []string
+	for _, input := range e.inputs[e.offset:end] {
+		rows = append(rows, input.View())
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("Variables"),
+		"",
+		helpStyle.Render("Typed values are passed with --set for the rest of the session, empty fields keep the justfile's value."),
+		"",
+		strings.Join(rows, "\n"),
+	))
+}
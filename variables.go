@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Recipes often interpolate top-level variables such as a registry URL or
+// a version read with a backtick. Their evaluated values are shown before
+// running, so a misconfigured one is caught first.
+
+var (
+	interpolation = regexp.MustCompile(`\{\{(.*?)\}\}`)
+	quotedString  = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'[^']*'`)
+	identifier    = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_-]*`)
+)
+
+// justVariables lists the names of the justfile's variables, from the dump's
+// assignments when just can't list them.
+func justVariables() ([]string, error) {
+	out, err := justCommand("--variables").Output()
+	if err != nil {
+		dump, dumpErr := getJustDump()
+		if dumpErr != nil {
+			return nil, err
+		}
+		var names []string
+		for name := range dump.Assignments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// interpolatedVariables finds the variables the recipe source interpolates,
+// in order of first use. Parameters shadow variables of the same name and
+// aren't included.
+func interpolatedVariables(source string, recipe Recipe, variables []string) []string {
+	known := map[string]bool{}
+	for _, v := range variables {
+		known[v] = true
+	}
+	for _, p := range recipe.Parameters {
+		delete(known, p.Name)
+	}
+
+	var used []string
+	seen := map[string]bool{}
+	for _, match := range interpolation.FindAllStringSubmatch(source, -1) {
+		expr := quotedString.ReplaceAllString(match[1], "")
+		for _, name := range identifier.FindAllString(expr, -1) {
+			if known[name] && !seen[name] {
+				seen[name] = true
+				used = append(used, name)
+			}
+		}
+	}
+	return used
+}
+
+// evaluateVariable returns the value just gives the variable with the
+// given --set arguments, or what just said when evaluating it failed, such
+// as a failing backtick.
+func evaluateVariable(name string, set ...string) (string, error) {
+	out, err := justCommand(append(set, "--evaluate", name)...).Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+		return "", errors.New(strings.TrimSpace(string(exit.Stderr)))
+	}
+	return string(out), err
+}
+
+// override matches a NAME=value argument to just.
+var override = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*=`)
+
+// setFlags picks the variable overrides out of a just command line: --set
+// arguments and the NAME=value arguments before the recipe.
+func setFlags(command []string) []string {
+	var set []string
+	for i := 1; i < len(command); i++ {
+		switch {
+		case command[i] == "--set" && i+2 < len(command):
+			set = append(set, command[i:i+3]...)
+			i += 2
+		case command[i] == "--justfile" || command[i] == "--working-directory":
+			i++
+		case override.MatchString(command[i]):
+			set = append(set, command[i])
+		case !strings.HasPrefix(command[i], "-"):
+			return set // The recipe, the rest are its arguments
+		}
+	}
+	return set
+}
+
+// evaluatedVariables describes the values of the variables the recipe
+// interpolates, as overridden by set, empty when it uses none.
+func evaluatedVariables(recipe Recipe, set []string) string {
+	variables, err := justVariables()
+	if err != nil || len(variables) == 0 {
+		return ""
+	}
+	source, err := recipeSource(recipe.Name)
+	if err != nil {
+		return ""
+	}
+	used := interpolatedVariables(string(source), recipe, variables)
+	if len(used) == 0 {
+		return ""
+	}
+
+	width := 0
+	for _, name := range used {
+		width = max(width, len(name))
+	}
+	var b strings.Builder
+	b.WriteString(helpStyle.Render("Variables:"))
+	b.WriteString("\n")
+	for _, name := range used {
+		value, err := evaluateVariable(name, set...)
+		if err != nil {
+			value = fmt.Sprintf("(%v)", err)
+		} else {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, "  %-*s = %s\n", width, name, value)
+	}
+	return b.String()
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch mode re-runs the job in the output pane whenever a file in the
+// project changes. fsnotify watches directories rather than trees, so each
+// directory that isn't ignored is added, those created while watching too.
+// Changes are collected until none came for watchDebounce, so a save that
+// touches several files re-runs once. Changes made while the recipe runs,
+// or right after, are its own output more often than not, such as the
+// binary a build writes; they're ignored rather than killing the build and
+// re-running forever.
+
+const (
+	watchDebounce = 300 * time.Millisecond
+	watchSettle   = 500 * time.Millisecond // After a run, changes are still taken for its own
+)
+
+// maxWatchedDirs keeps a huge tree from using up the system's watches.
+// Directories beyond it aren't watched.
+const maxWatchedDirs = 5000
+
+// defaultWatchIgnore are skipped on top of the config's watch_ignore. The
+// debug log is written to while watching, it would re-run forever.
+var defaultWatchIgnore = []string{".git", "node_modules", ".venv", "__pycache__", "target", ".direnv", "debug.log"}
+
+// watcher is the state of watch mode for the job in the output pane.
+type watcher struct {
+	root       string
+	ignore     []string
+	notify     *fsnotify.Watcher
+	dirs       int
+	seq        int // Identifies the current watcher
+	lastChange string
+	runs       int
+}
+
+// watchChange is a changed path, relative to the project, and when the
+// change came.
+type watchChange struct {
+	path string
+	at   time.Time
+}
+
+// Msg with the changes collected since the last one
+type watchMsg struct {
+	seq     int
+	changed []watchChange
+	dirs    int // Directories added meanwhile
+}
+
+var watchSeq int
+
+// startWatch watches the project for the job in the output pane.
+func (m *model) startWatch() tea.Cmd {
+	if m.job == nil || m.matrix != nil {
+		return nil
+	}
+	notify, err := fsnotify.NewWatcher()
+	if err != nil {
+		return func() tea.Msg { return statusMsg(fmt.Sprintf("Can't watch the project: %v", err)) }
+	}
+	ignore := defaultWatchIgnore
+	if cfg, _ := LoadConfig(); cfg != nil {
+		ignore = append(append([]string(nil), ignore...), cfg.WatchIgnore...)
+	}
+	watchSeq++
+	w := &watcher{root: projectDir(), ignore: ignore, notify: notify, seq: watchSeq}
+	w.dirs = w.addTree(w.root, 0)
+	m.watch = w
+	return w.wait()
+}
+
+// stop ends watch mode, the wait going on returns nothing.
+func (w *watcher) stop() {
+	w.notify.Close()
+}
+
+// addTree watches dir and the directories below it that aren't ignored,
+// returning how many were added. watched is how many are already.
+func (w *watcher) addTree(dir string, watched int) int {
+	added := 0
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil // Vanished meanwhile or unreadable, skip it
+		}
+		if rel, _ := filepath.Rel(w.root, p); rel != "." && watchIgnored(filepath.ToSlash(rel), w.ignore) {
+			return filepath.SkipDir
+		}
+		if watched+added >= maxWatchedDirs {
+			return filepath.SkipAll
+		}
+		if err := w.notify.Add(p); err != nil {
+			logDebug("Watching %s failed: %v", p, err)
+			return nil
+		}
+		added++
+		return nil
+	})
+	return added
+}
+
+// wait collects changes, returning them once none came for watchDebounce.
+// Directories created meanwhile are watched as well.
+func (w *watcher) wait() tea.Cmd {
+	notify, seq, watched := w.notify, w.seq, w.dirs
+	return func() tea.Msg {
+		msg := watchMsg{seq: seq}
+		seen := map[string]bool{}
+		var quiet <-chan time.Time
+		for {
+			select {
+			case ev, ok := <-notify.Events:
+				if !ok {
+					return nil // Watch mode was turned off
+				}
+				rel, err := filepath.Rel(w.root, ev.Name)
+				if err != nil || ev.Op == fsnotify.Chmod || watchIgnored(filepath.ToSlash(rel), w.ignore) {
+					continue
+				}
+				if ev.Has(fsnotify.Create) {
+					if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+						msg.dirs += w.addTree(ev.Name, watched+msg.dirs)
+					}
+				}
+				if !seen[rel] {
+					seen[rel] = true
+					msg.changed = append(msg.changed, watchChange{path: rel, at: time.Now()})
+				}
+				quiet = time.After(watchDebounce)
+			case err, ok := <-notify.Errors:
+				if !ok {
+					return nil
+				}
+				logDebug("Watch: %v", err)
+			case <-quiet:
+				sort.Slice(msg.changed, func(i, j int) bool { return msg.changed[i].path < msg.changed[j].path })
+				return msg
+			}
+		}
+	}
+}
+
+// watchIgnored matches the patterns against the path relative to the
+// project and against its base name, so "*.log" works at any depth.
+func watchIgnored(rel string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// handleWatchMsg re-runs the job for the changes made since it finished,
+// and waits for more.
+func (m model) handleWatchMsg(msg watchMsg) (model, tea.Cmd) {
+	w := m.watch
+	if w == nil || msg.seq != w.seq {
+		return m, nil // Watch mode was turned off
+	}
+	w.dirs += msg.dirs
+	next := w.wait()
+	if m.state != viewOutput || m.job == nil || !m.job.finished {
+		return m, next
+	}
+	settled := m.job.started.Add(m.job.duration + watchSettle)
+	var changed []string
+	for _, c := range msg.changed {
+		if c.at.After(settled) {
+			changed = append(changed, c.path)
+		}
+	}
+	if len(changed) == 0 {
+		return m, next
+	}
+
+	w.lastChange = changed[0]
+	if len(changed) > 1 {
+		w.lastChange += fmt.Sprintf(" and %d more", len(changed)-1)
+	}
+	w.runs++
+	m.keepRunDir()
+	cmd := m.runInApp(m.job.recipe, m.job.command)
+	return m, tea.Batch(cmd, next)
+}
+
+// status is the line shown under the job status while watching.
+func (w *watcher) status() string {
+	s := fmt.Sprintf("watching %d directories", w.dirs)
+	if w.dirs >= maxWatchedDirs {
+		s += " (limit reached)"
+	}
+	if w.lastChange != "" {
+		s += fmt.Sprintf(" · re-ran %d×, last for %s", w.runs, strings.TrimPrefix(w.lastChange, "./"))
+	}
+	return s
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestWatchIgnored(t *testing.T) {
+	patterns := []string{"*.log", "target/*", "node_modules", ".git"}
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{"app.log", true},
+		{"logs/deep/app.log", true},
+		{"target/debug", true},
+		{"target/debug/app", false},
+		{"node_modules", true},
+		{"web/node_modules", true},
+		{".git", true},
+		{"main.go", false},
+		{"log/main.go", false},
+		{"app.log.go", false},
+	}
+	for _, tt := range tests {
+		if got := watchIgnored(tt.rel, patterns); got != tt.want {
+			t.Errorf("watchIgnored(%q) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+	if watchIgnored("app.log", nil) {
+		t.Error("watchIgnored() with no patterns = true, want false")
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ctrl+x W runs the selected recipe in another directory. just runs
+// recipes in the justfile's directory, or with [no-cd] where it was
+// started; the directory picked is passed as --working-directory and is
+// also where the command starts, so both kinds run there. The choice is
+// remembered with the recipe's argument history and offered first the
+// next time, and re-runs from the output pane keep it.
+
+const (
+	dirHistoryKey = "@dir" // Not a valid parameter name, so no clash
+	maxDirDepth   = 3      // How deep subdirectories are offered
+	maxDirChoices = 500
+)
+
+// noCD reports whether the recipe runs where just was started instead of
+// in the justfile's directory.
+func (r Recipe) noCD() bool {
+	for _, a := range r.Attributes {
+		if a.Name == "no-cd" {
+			return true
+		}
+	}
+	return false
+}
+
+// recipeDir is the directory the next run of recipe was given, empty for
+// just's own choice.
+func (m model) recipeDir(recipe string) string {
+	if m.runEnv == nil || m.runEnv.recipe != recipe {
+		return ""
+	}
+	return m.runEnv.dir
+}
+
+// withWorkingDir points the just command line at dir. just only takes
+// --working-directory along with --justfile, so that's added if missing.
+func withWorkingDir(command []string, justfile, dir string) []string {
+	out := []string{command[0], "--working-directory", dir}
+	hasJustfile := false
+	for i := 1; i < len(command); i++ {
+		switch command[i] {
+		case "--working-directory", "-d":
+			i++
+			continue
+		case "--justfile", "-f":
+			hasJustfile = true
+		}
+		out = append(out, command[i])
+	}
+	if !hasJustfile && justfile != "" {
+		out = append([]string{out[0], "--justfile", justfile}, out[1:]...)
+	}
+	return out
+}
+
+// keepRunDir carries the directory of the job shown over to running it
+// again.
+func (m *model) keepRunDir() {
+	if m.job != nil && m.job.dir != "" {
+		m.runEnv = &runEnv{recipe: m.job.recipe, dir: m.job.dir}
+	}
+}
+
+// dirPicker chooses the directory for a run, narrowed by typing part of the
+// path. A typed path that exists can be picked as well.
+type dirPicker struct {
+	recipe string
+	query  string
+	all    []string
+	dirs   []string // Matching the query
+	cursor int
+}
+
+func (m *model) startDirPicker() tea.Cmd {
+	i, ok := m.list.SelectedItem().(recipeItem)
+	if !ok {
+		return nil
+	}
+	p := &dirPicker{recipe: i.name, all: runDirChoices(m.recipes[i.name])}
+	p.filter()
+	m.dirPicker = p
+	m.state = viewDirPicker
+	return nil
+}
+
+// runDirChoices lists the directories offered for the recipe: those it ran
+// in before, where just would run it, and the project's subdirectories.
+func runDirChoices(recipe Recipe) []string {
+	var dirs []string
+	seen := map[string]bool{}
+	add := func(dir string) {
+		if dir != "" && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range loadArgHistory()[recipe.Name][dirHistoryKey] {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			add(dir)
+		}
+	}
+	root := projectDir()
+	if cwd, err := os.Getwd(); err == nil && recipe.noCD() {
+		add(cwd)
+	}
+	add(root)
+
+	ignore := append([]string{".*"}, defaultWatchIgnore...)
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || p == root {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if watchIgnored(rel, ignore) || strings.Count(rel, "/") >= maxDirDepth {
+			return filepath.SkipDir
+		}
+		if len(dirs) >= maxDirChoices {
+			return filepath.SkipAll
+		}
+		add(p)
+		return nil
+	})
+	return dirs
+}
+
+func (p *dirPicker) filter() {
+	p.cursor = 0
+	if p.query == "" {
+		p.dirs = p.all
+		return
+	}
+	p.dirs = nil
+	if dir := typedDir(p.query); dir != "" {
+		p.dirs = append(p.dirs, dir)
+	}
+	for _, match := range fuzzyMatch(p.query, p.all) {
+		if dir := p.all[match.Index]; len(p.dirs) == 0 || dir != p.dirs[0] {
+			p.dirs = append(p.dirs, dir)
+		}
+	}
+}
+
+// typedDir is the query as an existing directory, relative to the project
+// or starting with ~, empty if it isn't one.
+func typedDir(query string) string {
+	path := query
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		path = filepath.Join(home, rest)
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(projectDir(), path)
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return ""
+	}
+	return filepath.Clean(path)
+}
+
+func (m model) updateDirPicker(msg tea.KeyMsg) (model, tea.Cmd) {
+	p := m.dirPicker
+	switch {
+	case key.Matches(msg, m.keys.Cancel):
+		m.state = viewList
+		m.dirPicker = nil
+	case msg.Type == tea.KeyUp || msg.Type == tea.KeyShiftTab:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case msg.Type == tea.KeyDown || msg.Type == tea.KeyTab:
+		if p.cursor < len(p.dirs)-1 {
+			p.cursor++
+		}
+	case key.Matches(msg, m.keys.Select):
+		if len(p.dirs) == 0 {
+			return m, nil
+		}
+		dir := p.dirs[p.cursor]
+		if err := rememberArgs(p.recipe, map[string]string{dirHistoryKey: dir}); err != nil {
+			logDebug("Saving argument history failed: %v", err)
+		}
+		m.runEnv = &runEnv{recipe: p.recipe, dir: dir}
+		m.state = viewList
+		m.dirPicker = nil
+		return m, m.selectRecipe(p.recipe)
+	case msg.Type == tea.KeyBackspace:
+		if p.query != "" {
+			runes := []rune(p.query)
+			p.query = string(runes[:len(runes)-1])
+			p.filter()
+		}
+	case msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace:
+		p.query += string(msg.Runes)
+		p.filter()
+	}
+	return m, nil
+}
+
+func (m model) dirPickerView() string {
+	p := m.dirPicker
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Run " + p.recipe + " in"))
+	b.WriteString("\n\n")
+	if m.recipes[p.recipe].noCD() {
+		b.WriteString(helpStyle.Render("[no-cd]: runs where just-do-it was started unless a directory is picked"))
+	} else {
+		b.WriteString(helpStyle.Render("Runs in the justfile's directory unless another is picked"))
+	}
+	b.WriteString("\n\n")
+	b.WriteString("> " + p.query + "\n\n")
+	if len(p.dirs) == 0 {
+		b.WriteString(helpStyle.Render("No directory matches"))
+	}
+	// Only as many as fit, scrolling with the cursor
+	shown := max(m.terminalHeight-12, 1)
+	offset := max(0, p.cursor-shown+1)
+	for i := offset; i < min(len(p.dirs), offset+shown); i++ {
+		name := displayPath(p.dirs[i])
+		cursor := " "
+		if i == p.cursor {
+			name = lipgloss.NewStyle().Foreground(activeTheme.Accent).Render(name)
+			cursor = ">"
+		}
+		fmt.Fprintf(&b, "%s %s\n", cursor, name)
+	}
+	return lipgloss.NewStyle().Margin(1, 2).Render(b.String())
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A workspace lists several project directories, each with its own
+// justfile, such as the services of a monorepo. Their recipes are listed
+// together under the directory's name, like modules (`api::build`), and run
+// with that project's justfile in its directory. The recipes of a justfile
+// at the root are listed as usual next to them. --recursive finds the
+// projects by looking through the subdirectories instead.
+
+// defaultDiscoverDepth is how deep --recursive looks for justfiles.
+const defaultDiscoverDepth = 3
+
+// workspaceProject is a project of the workspace.
+type workspaceProject struct {
+	name     string
+	dir      string
+	justfile string
+}
+
+// workspace is the workspace's projects, empty outside one.
+var workspace []workspaceProject
+
+// loadWorkspace finds the projects of the "workspace" setting. Relative
+// directories are relative to the project directory.
+func loadWorkspace(cfg *Config) ([]workspaceProject, error) {
+	if cfg == nil || len(cfg.Workspace) == 0 {
+		return nil, nil
+	}
+	root := projectDir()
+	var projects []workspaceProject
+	seen := map[string]string{}
+	for _, dir := range cfg.Workspace {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		dir = filepath.Clean(dir)
+		justfile := justfileIn(dir)
+		if justfile == "" {
+			return nil, fmt.Errorf("workspace: no justfile in %s", dir)
+		}
+		name := filepath.Base(dir)
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("workspace: %s and %s are both named %q", other, dir, name)
+		}
+		seen[name] = dir
+		projects = append(projects, workspaceProject{name: name, dir: dir, justfile: justfile})
+	}
+	return projects, nil
+}
+
+// justfileIn is the justfile in the directory itself, empty if there is
+// none.
+func justfileIn(dir string) string {
+	for _, name := range justfileNames {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
+
+// discoverJustfiles finds the justfiles in the subdirectories of root, up
+// to depth directories down, as workspace projects named by their path.
+// Directories matching ignore are skipped, and so are those below a
+// justfile and the modules of the root justfile, which are already listed.
+func discoverJustfiles(root string, depth int, ignore []string) []workspaceProject {
+	modules := map[string]bool{}
+	if justfile := justfileIn(root); justfile != "" {
+		modules = justfileModules(justfile)
+	}
+	var projects []workspaceProject
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || p == root {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if watchIgnored(rel, ignore) || modules[rel] || strings.Count(rel, "/") >= depth {
+			return filepath.SkipDir
+		}
+		if justfile := justfileIn(p); justfile != "" {
+			projects = append(projects, workspaceProject{name: rel, dir: p, justfile: justfile})
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return projects
+}
+
+// justfileModules names the modules declared with `mod` in the justfile
+// and its imports.
+func justfileModules(justfile string) map[string]bool {
+	modules := map[string]bool{}
+	docs, _ := justfileTreeFrom(justfile)
+	for _, d := range docs {
+		for _, line := range d.lines {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && (fields[0] == "mod" || fields[0] == "mod?") {
+				modules[fields[1]] = true
+			}
+		}
+	}
+	return modules
+}
+
+// recipeJustfile returns the flags that point just at the recipe's
+// justfile, and the recipe's name in it. Outside a workspace, those are the
+// forwarded flags and the name as it is.
+func recipeJustfile(name string) ([]string, string) {
+	project, rest, ok := strings.Cut(name, moduleSep)
+	if ok {
+		for _, p := range workspace {
+			if p.name == project {
+				return []string{"--justfile", p.justfile, "--working-directory", p.dir}, rest
+			}
+		}
+	}
+	return justArgs(), name
+}
+
+// workspaceDump reads the recipes of every project in the workspace into
+// one dump, each project as a module, along with the root justfile's.
+func workspaceDump() (*JustDump, error) {
+	dump := JustDump{}
+	if _, err := rootJustfile(); err == nil {
+		output, err := justOutput("--dump", "--dump-format", "json")
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(output, &dump); err != nil {
+			return nil, err
+		}
+	}
+	if dump.Modules == nil {
+		dump.Modules = map[string]JustDump{}
+	}
+	for _, p := range workspace {
+		output, err := justOutputArgs([]string{"--justfile", p.justfile, "--working-directory", p.dir, "--dump", "--dump-format", "json"})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		var project JustDump
+		if err := json.Unmarshal(output, &project); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		dump.Modules[p.name] = project
+	}
+	flattenModules(&dump)
+	return &dump, nil
+}
+
+// workspaceSources is the modification times of the sources of the root
+// justfile and every project's, watched for changes.
+func workspaceSources() map[string]time.Time {
+	files := map[string]time.Time{}
+	if _, err := rootJustfile(); err == nil {
+		dir, _ := os.Getwd()
+		files = sourceFiles(daemonRequest{Dir: dir, Args: justArgs()})
+	}
+	for _, p := range workspace {
+		for path, mod := range sourceFiles(daemonRequest{Dir: p.dir, Args: []string{"--justfile", p.justfile}}) {
+			files[path] = mod
+		}
+	}
+	return files
+}